@@ -119,10 +119,66 @@ type TradeAPI interface {
 	CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error)
 }
 
+// AmendOrderAPI is an optional interface for exchanges that can change the price and volume of an open order in a single request
+type AmendOrderAPI interface {
+	// AmendOrder returns the ID of the amended order which may differ from txID
+	AmendOrder(txID *model.TransactionID, order *model.Order, submitMode SubmitMode) (*model.TransactionID, error)
+}
+
+// BatchAddResult is the result of adding a single order as part of a batch
+type BatchAddResult struct {
+	TxID  *model.TransactionID // nil if there was an error
+	Error error
+}
+
+// BatchCancelResult is the result of cancelling a single order as part of a batch
+type BatchCancelResult struct {
+	Result model.CancelOrderResult
+	Error  error
+}
+
+// BatchTradeAPI is an optional interface for exchanges that can add and cancel many orders at once
+// the error returned by each method is for a failure of the whole batch, the results hold the outcome for each individual order.
+// Implementations without a native batch endpoint for AddOrders or CancelOrders can fall back to individual requests, but
+// CancelAllOrders must be a single request to the exchange.
+type BatchTradeAPI interface {
+	// AddOrders returns one result per order, in the same order as the passed in orders
+	AddOrders(orders []*model.Order, submitMode SubmitMode) ([]BatchAddResult, error)
+
+	// CancelOrders returns one result per txID, in the same order as the passed in txIDs
+	CancelOrders(txIDs []*model.TransactionID, pair model.TradingPair) ([]BatchCancelResult, error)
+
+	// CancelAllOrders cancels every open order on the trading pair
+	CancelAllOrders(pair model.TradingPair) error
+}
+
+// ClientOrderIDTradeAPI is an optional interface for exchanges that pass model.Order.ClientOrderID through when adding orders,
+// so an order can be found after the response to AddOrder was lost (e.g. a timeout) instead of being placed a second time
+type ClientOrderIDTradeAPI interface {
+	// GetOpenOrderByClientID returns nil if there is no open order on the pair with the clientOrderID
+	GetOpenOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error)
+}
+
+// ClosedOrderByClientIDAPI is an optional interface for ClientOrderIDTradeAPI exchanges that can also find an order by its clientOrderID
+// once it is no longer open, so an order that was filled right after the response to AddOrder was lost is not placed a second time
+type ClosedOrderByClientIDAPI interface {
+	// GetClosedOrderByClientID returns nil if there is no recently closed order on the pair with the clientOrderID,
+	// VolumeExecuted of the returned order is the volume that was filled before it was closed
+	GetClosedOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error)
+}
+
+// LongClientOrderIDAPI is an optional interface for ClientOrderIDTradeAPI exchanges that accept longer clientOrderIDs than the ones
+// created by model.MakeClientOrderID, so clientOrderIDs that are derived from a hash can use a wider hash that is less likely to collide
+type LongClientOrderIDAPI interface {
+	// MaxClientOrderIDDigits is the number of decimal digits that can be used in a clientOrderID
+	MaxClientOrderIDDigits() int
+}
+
 // PrepareDepositResult is the result of a PrepareDeposit call
 type PrepareDepositResult struct {
 	Fee      *model.Number // fee that will be deducted from your deposit, i.e. amount available is depositAmount - fee
 	Address  string        // address you should send the funds to
+	Memo     string        // memo or tag that needs to be attached to the deposit, empty if not needed
 	ExpireTs int64         // expire time as a unix timestamp, 0 if it does not expire
 }
 
@@ -194,6 +250,27 @@ type WithdrawAPI interface {
 	) (*WithdrawFunds, error)
 }
 
+// WithdrawTagAPI is implemented by exchanges that can send a tag (also called memo or destination tag) with a withdrawal, which assets
+// like XLM and XRP need when the receiving address is shared by several accounts
+type WithdrawTagAPI interface {
+	/*
+		Input:
+			asset - asset you want to withdraw
+			amountToWithdraw - amount you want deducted from your account (fees will be deducted from here, use GetWithdrawInfo for fee estimate)
+			address - address you want to withdraw to
+			tag - tag or memo of the withdrawal, empty for no tag
+		Output:
+		    WithdrawFunds - result of the withdrawal
+			error - any error
+	*/
+	WithdrawFundsWithTag(
+		asset model.Asset,
+		amountToWithdraw *model.Number,
+		address string,
+		tag string,
+	) (*WithdrawFunds, error)
+}
+
 // ErrWithdrawAmountAboveLimit error type
 type ErrWithdrawAmountAboveLimit error
 
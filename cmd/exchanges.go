@@ -19,11 +19,11 @@ func init() {
 		checkInitRootFlags()
 		// call sdk.GetExchangeList() here so we pre-load exchanges before displaying the table
 		sdk.GetExchangeList()
-		fmt.Printf("  Exchange\t\t\tTested\t\tTrading\t\tAtomic Post-Only\tTrade Has OrderID\t\tDescription\n")
-		fmt.Printf("  -----------------------------------------------------------------------------------------------------------------------------\n")
+		fmt.Printf("  Exchange\t\t\tTested\t\tTrading\t\tAtomic Post-Only\tTrade Has OrderID\tNative Amend\t\tDescription\n")
+		fmt.Printf("  -----------------------------------------------------------------------------------------------------------------------------------------------------\n")
 		exchanges := plugins.Exchanges()
 		for _, name := range sortedExchangeKeys(exchanges) {
-			fmt.Printf("  %-24s\t%v\t\t%v\t\t%v\t\t\t%v\t\t%s\n", name, exchanges[name].Tested, exchanges[name].TradeEnabled, exchanges[name].AtomicPostOnly, exchanges[name].TradeHasOrderId, exchanges[name].Description)
+			fmt.Printf("  %-24s\t%v\t\t%v\t\t%v\t\t\t%v\t\t\t%v\t\t\t%s\n", name, exchanges[name].Tested, exchanges[name].TradeEnabled, exchanges[name].AtomicPostOnly, exchanges[name].TradeHasOrderId, exchanges[name].NativeAmendOrder, exchanges[name].Description)
 		}
 	}
 }
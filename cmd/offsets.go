@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const offsetsExamples = `  kelp offsets list --botConf ./path/trader.cfg
+  kelp offsets retry --botConf ./path/trader.cfg <txid>
+  kelp offsets cancel --botConf ./path/trader.cfg <txid>`
+
+var offsetsCmd = &cobra.Command{
+	Use:     "offsets",
+	Short:   "Lists, retries or cancels the mirror strategy offsets that are queued for a retry",
+	Example: offsetsExamples,
+}
+
+var offsetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the queued offsets of all markets, oldest first",
+	Args:  cobra.NoArgs,
+}
+
+var offsetsRetryCmd = &cobra.Command{
+	Use:   "retry <txid>",
+	Short: "Retries the queued offset of the trade with the given txid in the next update cycle of the running bot, an unverified offset is placed again",
+	Args:  cobra.ExactArgs(1),
+}
+
+var offsetsCancelCmd = &cobra.Command{
+	Use:   "cancel <txid>",
+	Short: "Cancels the queued offset of the trade with the given txid, the running bot drops it from the base surplus in its next update cycle",
+	Args:  cobra.ExactArgs(1),
+}
+
+func init() {
+	botConfigPath := offsetsCmd.PersistentFlags().StringP("botConf", "b", "./trader.cfg", "trading bot's basic config file path, needs POSTGRES_DB to be set")
+
+	offsetsListCmd.Run = func(ccmd *cobra.Command, args []string) {
+		db := connectBotConfigDb(*botConfigPath)
+		defer db.Close()
+
+		rows, e := db.Query(kelpdb.SqlQueryStrategyMirrorOffsetRetriesAll)
+		if e != nil {
+			log.Fatalf("could not query offset retries: %s", e)
+		}
+		defer rows.Close()
+
+		fmt.Printf("  Market ID\tTxID\t\t\t\t\t\t\t\t\tAction\tBase Volume\tPrice\t\tStatus\t\tAttempts\tCreated (UTC)\t\tNext Attempt (UTC)\tAlerted\tLast Error\n")
+		fmt.Printf("  -----------------------------------------------------------------------------------------------------------------------------------------------------\n")
+		count := 0
+		for rows.Next() {
+			var marketID, txID, action, status, lastError string
+			var baseVolume, price float64
+			var attempts int
+			var createdAt, nextAttemptAt time.Time
+			var alerted bool
+			e = rows.Scan(&marketID, &txID, &action, &baseVolume, &price, &status, &attempts, &createdAt, &nextAttemptAt, &lastError, &alerted)
+			if e != nil {
+				log.Fatalf("could not scan offset retry row: %s", e)
+			}
+			fmt.Printf("  %s\t%-64s\t%s\t%.7f\t%.7f\t%-9s\t%d\t\t%s\t%s\t%v\t%s\n",
+				marketID,
+				txID,
+				action,
+				baseVolume,
+				price,
+				status,
+				attempts,
+				createdAt.Format("2006-01-02 15:04:05"),
+				nextAttemptAt.Format("2006-01-02 15:04:05"),
+				alerted,
+				lastError,
+			)
+			count++
+		}
+		e = rows.Err()
+		if e != nil {
+			log.Fatalf("error while iterating over offset retry rows: %s", e)
+		}
+		fmt.Printf("\n  %d queued offsets\n", count)
+	}
+
+	offsetsRetryCmd.Run = func(ccmd *cobra.Command, args []string) {
+		db := connectBotConfigDb(*botConfigPath)
+		defer db.Close()
+
+		updateQueuedOffset(db, args[0], "retry", kelpdb.SqlStrategyMirrorOffsetRetriesUpdateRetryNow, time.Now().UTC())
+	}
+
+	offsetsCancelCmd.Run = func(ccmd *cobra.Command, args []string) {
+		db := connectBotConfigDb(*botConfigPath)
+		defer db.Close()
+
+		updateQueuedOffset(db, args[0], "cancel", kelpdb.SqlStrategyMirrorOffsetRetriesUpdateCancel)
+	}
+
+	offsetsCmd.AddCommand(offsetsListCmd)
+	offsetsCmd.AddCommand(offsetsRetryCmd)
+	offsetsCmd.AddCommand(offsetsCancelCmd)
+}
+
+// connectBotConfigDb connects to the postgres db set in the bot config file, upgrading it if needed so all the tables exist
+func connectBotConfigDb(botConfigPath string) *sql.DB {
+	var botConfig trader.BotConfig
+	e := config.Read(botConfigPath, &botConfig)
+	utils.CheckConfigError(botConfig, e, botConfigPath)
+	if botConfig.PostgresDbConfig == nil {
+		utils.PrintErrorHintf("POSTGRES_DB needs to be set in the bot config file to use this command")
+		log.Fatalf("no POSTGRES_DB specified in the bot config file '%s'", botConfigPath)
+	}
+
+	db, e := database.ConnectInitializedDatabase(botConfig.PostgresDbConfig, upgradeScripts, version)
+	if e != nil {
+		log.Fatalf("problem encountered while initializing the db: %s", e)
+	}
+	return db
+}
+
+// updateQueuedOffset runs the update statement for the pending offset of the txid, the statement takes the txid as the first arg
+func updateQueuedOffset(db *sql.DB, txID string, verb string, sqlUpdate string, extraArgs ...interface{}) {
+	result, e := db.Exec(sqlUpdate, append([]interface{}{txID}, extraArgs...)...)
+	if e != nil {
+		log.Fatalf("could not %s the queued offset for txid '%s': %s", verb, txID, e)
+	}
+	numRows, e := result.RowsAffected()
+	if e != nil {
+		log.Fatalf("could not check whether the queued offset for txid '%s' was updated: %s", txID, e)
+	}
+	if numRows == 0 {
+		log.Fatalf("there is no pending offset queued for txid '%s'", txID)
+	}
+	log.Printf("marked the queued offset for txid '%s' to %s, the running bot will %s it in its next update cycle\n", txID, verb, verb)
+}
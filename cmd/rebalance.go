@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/rebalancer"
+	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/utils"
+)
+
+const rebalanceExamples = `  kelp rebalance --conf ./path/rebalancer.cfg --dryRun --once
+  kelp rebalance --conf ./path/rebalancer.cfg`
+
+var rebalanceCmd = &cobra.Command{
+	Use:     "rebalance",
+	Short:   "Moves funds between SDEX and a backing exchange to keep inventory balanced across both venues",
+	Example: rebalanceExamples,
+}
+
+func init() {
+	configPath := rebalanceCmd.Flags().StringP("conf", "c", "./rebalancer.cfg", "rebalancer's config file path")
+	dryRun := rebalanceCmd.Flags().Bool("dryRun", false, "log (and audit) the transfers that would be made without moving any funds")
+	once := rebalanceCmd.Flags().Bool("once", false, "check balances and make transfers once and then exit instead of running as a service")
+	operationalBuffer := rebalanceCmd.Flags().Float64("operationalBuffer", 20, "buffer of native XLM to keep on SDEX beyond minimum account balance requirement")
+	operationalBufferNonNativePct := rebalanceCmd.Flags().Float64("operationalBufferNonNativePct", 0.001, "buffer of non-native assets to keep on SDEX as a percentage (0.001 = 0.1%)")
+
+	rebalanceCmd.Run = func(ccmd *cobra.Command, args []string) {
+		log.Println("Starting Rebalancer: " + version + " [" + gitHash + "]")
+
+		var configFile rebalancer.Config
+		e := config.Read(*configPath, &configFile)
+		utils.CheckConfigError(configFile, e, *configPath)
+		e = configFile.Init()
+		if e != nil {
+			log.Fatal(e)
+		}
+		utils.LogConfig(configFile)
+		log.Printf("Started Rebalancer for account %s with exchange %s (dryRun=%v)\n", *configFile.TradingAccount, configFile.Exchange, *dryRun)
+
+		// --- start initialization of objects ----
+		client := &horizonclient.Client{
+			HorizonURL: configFile.HorizonURL,
+			HTTP:       http.DefaultClient,
+			AppName:    "kelp",
+			AppVersion: version,
+		}
+		// MakeSDEX uses the trading account as the source account when this is empty
+		sourceAccount := ""
+		if configFile.SourceAccount != nil {
+			sourceAccount = *configFile.SourceAccount
+		}
+		sdex := plugins.MakeSDEX(
+			client,
+			plugins.MakeIEIF(true),
+			nil,
+			configFile.SourceSecretSeed,
+			configFile.TradingSecretSeed,
+			sourceAccount,
+			*configFile.TradingAccount,
+			utils.ParseNetwork(configFile.HorizonURL),
+			multithreading.MakeThreadTracker(),
+			*operationalBuffer,
+			*operationalBufferNonNativePct,
+			*dryRun,
+			nil, // not needed here
+			map[model.Asset]hProtocol.Asset{},
+			plugins.SdexFixedFeeFn(100), // payments are not time-sensitive so the base fee is enough
+		)
+
+		exchange, e := plugins.MakeTradingExchange(
+			configFile.Exchange,
+			configFile.ExchangeAPIKeys.ToExchangeAPIKeys(),
+			configFile.ExchangeParams.ToExchangeParams(),
+			configFile.ExchangeHeaders.ToExchangeHeaders(),
+			*dryRun,
+		)
+		if e != nil {
+			log.Fatalf("unable to make exchange '%s': %s", configFile.Exchange, e)
+		}
+
+		var db *sql.DB
+		if configFile.PostgresDbConfig != nil {
+			db, e = database.ConnectInitializedDatabase(configFile.PostgresDbConfig, upgradeScripts, version)
+			if e != nil {
+				log.Fatalf("problem encountered while initializing the db: %s", e)
+			}
+			log.Printf("made db instance with config: %s\n", configFile.PostgresDbConfig.MakeConnectString())
+		} else {
+			log.Println("no POSTGRES_DB specified in the rebalancer config file, transfers will not be audited")
+		}
+
+		r, e := rebalancer.MakeRebalancer(
+			sdex,
+			exchange,
+			configFile.Exchange,
+			*configFile.TradingAccount,
+			configFile.Assets,
+			configFile.TickIntervalSeconds,
+			configFile.CooldownMinutes,
+			*dryRun,
+			db,
+		)
+		if e != nil {
+			log.Fatal(e)
+		}
+		// --- end initialization of objects ----
+
+		if *once {
+			r.RunOnce()
+			return
+		}
+		r.StartService()
+	}
+}
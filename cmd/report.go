@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/kelp/queries"
+)
+
+const reportExamples = `  kelp report mirror --botConf ./path/trader.cfg
+  kelp report mirror --botConf ./path/trader.cfg --days 7 --marketID 0a1b2c3d4e`
+
+var reportCmd = &cobra.Command{
+	Use:     "report",
+	Short:   "Reports on the trading activity recorded in the database",
+	Example: reportExamples,
+}
+
+var reportMirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Reports the hedge slippage and realized spread of the trades offset by the mirror strategy",
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	botConfigPath := reportCmd.PersistentFlags().StringP("botConf", "b", "./trader.cfg", "trading bot's basic config file path, needs POSTGRES_DB to be set")
+	days := reportMirrorCmd.Flags().Int("days", 30, "number of days of trades to include in the report")
+	marketID := reportMirrorCmd.Flags().String("marketID", "", "only include trades of this market_id (default includes all markets)")
+
+	reportMirrorCmd.Run = func(ccmd *cobra.Command, args []string) {
+		if *days <= 0 {
+			log.Fatalf("days needs to be > 0 but was %d", *days)
+		}
+
+		db := connectBotConfigDb(*botConfigPath)
+		defer db.Close()
+
+		query, e := queries.MakeStrategyMirrorHedges(db, *marketID)
+		if e != nil {
+			log.Fatalf("could not make the StrategyMirrorHedges query: %s", e)
+		}
+		since := time.Now().UTC().AddDate(0, 0, -*days)
+		result, e := query.QueryRow(since)
+		if e != nil {
+			log.Fatalf("could not run the StrategyMirrorHedges query: %s", e)
+		}
+		hedges := result.([]queries.MirrorHedge)
+
+		fmt.Printf("  Date (UTC)\t\tMarket ID\tTxID\t\t\t\t\t\t\t\t\tAction\tPrice\t\tBase Volume\tHedge Price\tHedge Volume\tSlippage\tRealized Spread\n")
+		fmt.Printf("  -----------------------------------------------------------------------------------------------------------------------------------------------------\n")
+		numHedged := 0
+		totalHedgedVolume := 0.0
+		totalRealizedSpread := 0.0
+		weightedSlippage := 0.0
+		for _, h := range hedges {
+			hedgePrice, ok := h.HedgePrice()
+			if !ok && h.BackingOrderID == "" {
+				fmt.Printf("  %s\t%s\t%-64s\t%s\t%.7f\t%.7f\t(not hedged, absorbed into the base surplus)\n",
+					h.DateUTC.Format("2006-01-02 15:04:05"), h.MarketID, h.TxID, h.Action, h.Price, h.BaseVolume)
+				continue
+			}
+			if !ok {
+				fmt.Printf("  %s\t%s\t%-64s\t%s\t%.7f\t%.7f\t(not hedged yet, backing order %s)\n",
+					h.DateUTC.Format("2006-01-02 15:04:05"), h.MarketID, h.TxID, h.Action, h.Price, h.BaseVolume, h.BackingOrderID)
+				continue
+			}
+			slippage, _ := h.Slippage()
+			realizedSpread, _ := h.RealizedSpread()
+			fmt.Printf("  %s\t%s\t%-64s\t%s\t%.7f\t%.7f\t%.7f\t%.7f\t%.4f%%\t\t%.7f\n",
+				h.DateUTC.Format("2006-01-02 15:04:05"), h.MarketID, h.TxID, h.Action, h.Price, h.BaseVolume, hedgePrice, *h.BackingBaseVolume, slippage*100, realizedSpread)
+
+			numHedged++
+			totalHedgedVolume += *h.BackingBaseVolume
+			totalRealizedSpread += realizedSpread
+			weightedSlippage += slippage * *h.BackingBaseVolume
+		}
+
+		fmt.Printf("\n  %d trades in the last %d days, %d with tracked hedges\n", len(hedges), *days, numHedged)
+		if numHedged > 0 {
+			fmt.Printf("  hedged base volume: %.7f\n", totalHedgedVolume)
+			fmt.Printf("  volume-weighted slippage: %.4f%%\n", weightedSlippage/totalHedgedVolume*100)
+			fmt.Printf("  realized spread (quote units, after fees): %.7f\n", totalRealizedSpread)
+		}
+	}
+
+	reportCmd.AddCommand(reportMirrorCmd)
+}
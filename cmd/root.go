@@ -72,6 +72,9 @@ func init() {
 	RootCmd.AddCommand(strategiesCmd)
 	RootCmd.AddCommand(exchangesCmd)
 	RootCmd.AddCommand(terminateCmd)
+	RootCmd.AddCommand(rebalanceCmd)
+	RootCmd.AddCommand(offsetsCmd)
+	RootCmd.AddCommand(reportCmd)
 	RootCmd.AddCommand(versionCmd)
 }
 
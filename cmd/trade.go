@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"reflect"
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
@@ -58,6 +59,23 @@ var upgradeScripts = []*database.UpgradeScript{
 		kelpdb.SqlStrategyMirrorTradeTriggersTableCreate,
 		kelpdb.SqlTradesTableAlter2,
 	),
+	database.MakeUpgradeScript(7,
+		kelpdb.SqlRebalanceTransfersTableCreate,
+		kelpdb.SqlRebalanceTransfersIndexCreate,
+	),
+	database.MakeUpgradeScript(8,
+		kelpdb.SqlBatchedExchangeOfferIDsTableCreate,
+	),
+	database.MakeUpgradeScript(9,
+		kelpdb.SqlStrategyMirrorBaseSurplusTableCreate,
+	),
+	database.MakeUpgradeScript(10,
+		kelpdb.SqlStrategyMirrorOffsetRetriesTableCreate,
+	),
+	database.MakeUpgradeScript(11,
+		kelpdb.SqlStrategyMirrorTradeTriggersTableAlter1,
+		kelpdb.SqlStrategyMirrorTradeTriggersIndexCreate,
+	),
 }
 
 const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buysell --stratConf ./path/buysell.cfg
@@ -65,6 +83,9 @@ const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buyse
 
 const prefsFilename = "kelp.prefs"
 
+// filterConfigPollInterval is how often the bot config file is checked for changes to the filters
+const filterConfigPollInterval = 10 * time.Second
+
 var tradeCmd = &cobra.Command{
 	Use:     "trade",
 	Short:   "Trades against the Stellar universal marketplace using the specified strategy",
@@ -108,6 +129,7 @@ type inputs struct {
 	ui                            *bool
 	cpuProfile                    *string
 	memProfile                    *string
+	explainFilters                *bool
 }
 
 func validateCliParams(l logger.Logger, options inputs) {
@@ -170,6 +192,7 @@ func init() {
 	options.ui = tradeCmd.Flags().Bool("ui", false, "indicates a bot that is started from the Kelp UI server")
 	options.cpuProfile = tradeCmd.Flags().String("cpuprofile", "", "write cpu profile to `file`")
 	options.memProfile = tradeCmd.Flags().String("memprofile", "", "write memory profile to `file`")
+	options.explainFilters = tradeCmd.Flags().Bool("explainFilters", false, "log which filter changed each operation and why in every update cycle (use with --sim for a dry run)")
 
 	requiredFlag("botConf")
 	requiredFlag("strategy")
@@ -247,6 +270,10 @@ func readBotConfig(l logger.Logger, options inputs, botStart time.Time) trader.B
 	var botConfig trader.BotConfig
 	e := config.Read(*options.botConfigPath, &botConfig)
 	utils.CheckConfigError(botConfig, e, *options.botConfigPath)
+	e = plugins.CheckFilterConfigKeys(*options.botConfigPath)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
 	e = botConfig.Init()
 	if e != nil {
 		logger.Fatal(l, e)
@@ -278,6 +305,8 @@ func makeExchangeShimSdex(
 	threadTracker *multithreading.ThreadTracker,
 	tradingPair *model.TradingPair,
 	sdexAssetMap map[model.Asset]hProtocol.Asset,
+	db *sql.DB,
+	marketID string,
 ) (api.ExchangeShim, *plugins.SDEX) {
 	var e error
 	var exchangeShim api.ExchangeShim
@@ -306,7 +335,24 @@ func makeExchangeShimSdex(
 			return nil, nil
 		}
 
-		exchangeShim = plugins.MakeBatchedExchange(exchangeAPI, *options.simMode, botConfig.AssetBase(), botConfig.AssetQuote(), botConfig.TradingAccount())
+		// offer IDs are saved in the db when it is enabled, otherwise in the configured file
+		var offerIDStore plugins.OfferIDStore
+		if db != nil {
+			offerIDStore = plugins.MakePostgresOfferIDStore(db, marketID)
+		} else if botConfig.CentralizedOfferIDsFile != "" {
+			offerIDStore = plugins.MakeFileOfferIDStore(botConfig.CentralizedOfferIDsFile)
+		} else {
+			l.Info("offer IDs will not be saved across restarts because neither POSTGRES_DB nor CENTRALIZED_OFFER_IDS_FILE is set")
+		}
+
+		nativeAmendOrder := plugins.Exchanges()[botConfig.TradingExchange].NativeAmendOrder
+		batchedExchange := plugins.MakeBatchedExchange(exchangeAPI, *options.simMode, botConfig.AssetBase(), botConfig.AssetQuote(), botConfig.TradingAccount(), nativeAmendOrder, offerIDStore)
+		e = batchedExchange.ReconcileOfferIDs()
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("unable to reconcile saved offer IDs with open orders: %s", e))
+			return nil, nil
+		}
+		exchangeShim = batchedExchange
 
 		// update precision overrides
 		exchangeShim.OverrideOrderConstraints(tradingPair, model.MakeOrderConstraintsOverride(
@@ -378,6 +424,7 @@ func makeStrategy(
 	threadTracker *multithreading.ThreadTracker,
 	db *sql.DB,
 	metricsTracker *plugins.MetricsTracker,
+	alert api.Alert,
 ) api.Strategy {
 	// setting the temp hack variables for the sdex price feeds
 	e := plugins.SetPrivateSdexHack(client, plugins.MakeIEIF(true), network)
@@ -403,6 +450,7 @@ func makeStrategy(
 		botConfig.IsTradingSdex(),
 		filterFactory,
 		db,
+		alert,
 	)
 	if e != nil {
 		l.Info("")
@@ -427,6 +475,8 @@ func makeBot(
 	threadTracker *multithreading.ThreadTracker,
 	options inputs,
 	metricsTracker *plugins.MetricsTracker,
+	alert api.Alert,
+	filterExplainer *plugins.FilterExplainer,
 	botStart time.Time,
 ) *trader.Trader {
 	timeController := plugins.MakeIntervalTimeController(
@@ -451,10 +501,6 @@ func makeBot(
 	assetBase := botConfig.AssetBase()
 	assetQuote := botConfig.AssetQuote()
 	dataKey := model.MakeSortedBotKey(assetBase, assetQuote)
-	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
-	if e != nil {
-		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
-	}
 
 	var valueBaseFeed api.PriceFeed
 	var valueQuoteFeed api.PriceFeed
@@ -476,35 +522,20 @@ func makeBot(
 		}
 	}
 
-	// start make filters
-	submitFilters := []plugins.SubmitFilter{}
-	if submitMode == api.SubmitModeMakerOnly {
-		submitFilters = append(submitFilters,
-			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair),
-		)
-	}
-	if len(botConfig.Filters) > 0 && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "delete" {
+	filterStrings, e := botConfig.FilterStrings()
+	if e != nil {
 		log.Println()
-		utils.PrintErrorHintf("FILTERS currently only supported on 'sell' and 'delete' strategies, remove FILTERS from the trader config file")
+		log.Println(e)
 		// we want to delete all the offers and exit here since there is something wrong with our setup
 		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 	}
-	for _, filterString := range botConfig.Filters {
-		filter, e := filterFactory.MakeFilter(filterString)
-		if e != nil {
-			log.Println()
-			log.Println(e)
-			// we want to delete all the offers and exit here since there is something wrong with our setup
-			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
-		}
-		submitFilters = append(submitFilters, filter)
+	submitFilters, e := makeSubmitFilters(filterStrings, *options.strategy, submitMode, filterFactory, exchangeShim, sdex, tradingPair, assetBase, assetQuote)
+	if e != nil {
+		log.Println()
+		log.Println(e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 	}
-	// exchange constraints filter is last so we catch any modifications made by previous filters. this ensures that the exchange is
-	// less likely to reject our updates
-	submitFilters = append(submitFilters,
-		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote),
-	)
-	// end make filters
 
 	return trader.MakeTrader(
 		client,
@@ -525,6 +556,7 @@ func makeBot(
 		botConfig.DeleteCyclesThreshold,
 		submitMode,
 		submitFilters,
+		filterExplainer,
 		threadTracker,
 		options.fixedIterations,
 		dataKey,
@@ -534,6 +566,139 @@ func makeBot(
 	)
 }
 
+// makeSubmitFilters makes the chain of submit filters for the passed in filter strings, this is also used when the filters are reloaded
+func makeSubmitFilters(
+	filterStrings []string,
+	strategyName string,
+	submitMode api.SubmitMode,
+	filterFactory *plugins.FilterFactory,
+	exchangeShim api.ExchangeShim,
+	sdex *plugins.SDEX,
+	tradingPair *model.TradingPair,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+) ([]plugins.SubmitFilter, error) {
+	if strategyName != "sell" && strategyName != "sell_twap" && strategyName != "delete" {
+		for _, filterString := range filterStrings {
+			filterName := strings.Split(filterString, "/")[0]
+			if !plugins.FilterSupportsAllStrategies(filterName) {
+				return nil, fmt.Errorf("FILTERS of type '%s' currently only supported on 'sell' and 'delete' strategies, remove it from FILTERS or [[FILTER]] in the trader config file", filterName)
+			}
+		}
+	}
+
+	submitFilters := []plugins.SubmitFilter{}
+	if submitMode == api.SubmitModeMakerOnly {
+		submitFilters = append(submitFilters,
+			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair, filterFactory.FilterExplainer),
+		)
+	}
+	for _, filterString := range filterStrings {
+		filter, e := filterFactory.MakeFilter(filterString)
+		if e != nil {
+			return nil, e
+		}
+		submitFilters = append(submitFilters, filter)
+	}
+	// exchange constraints filter is last so we catch any modifications made by previous filters. this ensures that the exchange is
+	// less likely to reject our updates
+	submitFilters = append(submitFilters,
+		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote, filterFactory.FilterExplainer),
+	)
+	return submitFilters, nil
+}
+
+// filterConfigWatcher rebuilds the chain of submit filters whenever the enabled filters in the bot config file change
+type filterConfigWatcher struct {
+	l             logger.Logger
+	botConfigPath string
+	filterStrings []string
+	makeFiltersFn func(filterStrings []string) ([]plugins.SubmitFilter, error)
+	setFiltersFn  func(submitFilters []plugins.SubmitFilter)
+	lastModTime   time.Time
+}
+
+// makeFilterConfigWatcher is a factory method, filterStrings are the filters that are currently in use
+func makeFilterConfigWatcher(
+	l logger.Logger,
+	botConfigPath string,
+	filterStrings []string,
+	makeFiltersFn func(filterStrings []string) ([]plugins.SubmitFilter, error),
+	setFiltersFn func(submitFilters []plugins.SubmitFilter),
+) *filterConfigWatcher {
+	w := &filterConfigWatcher{
+		l:             l,
+		botConfigPath: botConfigPath,
+		filterStrings: filterStrings,
+		makeFiltersFn: makeFiltersFn,
+		setFiltersFn:  setFiltersFn,
+	}
+	if fileInfo, e := os.Stat(botConfigPath); e == nil {
+		w.lastModTime = fileInfo.ModTime()
+	}
+	return w
+}
+
+// checkForChanges sets the rebuilt chain of submit filters when the bot config file was modified and its filters changed, and returns
+// whether it did so. The current filters are kept if the changed config file cannot be read or the new filters cannot be made.
+func (w *filterConfigWatcher) checkForChanges() bool {
+	fileInfo, e := os.Stat(w.botConfigPath)
+	if e != nil {
+		w.l.Errorf("unable to check the bot config file for changes to the filters: %s", e)
+		return false
+	}
+	if fileInfo.ModTime().Equal(w.lastModTime) {
+		return false
+	}
+	w.lastModTime = fileInfo.ModTime()
+
+	var newBotConfig trader.BotConfig
+	e = config.Read(w.botConfigPath, &newBotConfig)
+	if e != nil {
+		w.l.Errorf("bot config file was changed but could not be read, continuing with the current filters: %s", e)
+		return false
+	}
+	e = plugins.CheckFilterConfigKeys(w.botConfigPath)
+	if e != nil {
+		w.l.Errorf("bot config file was changed but has invalid filters, continuing with the current filters: %s", e)
+		return false
+	}
+	newFilterStrings, e := newBotConfig.FilterStrings()
+	if e != nil {
+		w.l.Errorf("bot config file was changed but has invalid filters, continuing with the current filters: %s", e)
+		return false
+	}
+	if reflect.DeepEqual(newFilterStrings, w.filterStrings) {
+		return false
+	}
+
+	submitFilters, e := w.makeFiltersFn(newFilterStrings)
+	if e != nil {
+		w.l.Errorf("unable to make the changed filters, continuing with the current filters: %s", e)
+		return false
+	}
+	w.l.Infof("filters were changed in the bot config file, the new filters will be applied from the next update cycle: %v\n", newFilterStrings)
+	w.setFiltersFn(submitFilters)
+	w.filterStrings = newFilterStrings
+	return true
+}
+
+// watchFilterConfig polls the bot config file and sets a rebuilt chain of submit filters on the bot whenever the enabled filters change.
+// The bot keeps running with its current filters if the changed config file cannot be read or the new filters cannot be made.
+func watchFilterConfig(
+	l logger.Logger,
+	botConfigPath string,
+	filterStrings []string,
+	makeFiltersFn func(filterStrings []string) ([]plugins.SubmitFilter, error),
+	bot *trader.Trader,
+) {
+	w := makeFilterConfigWatcher(l, botConfigPath, filterStrings, makeFiltersFn, bot.SetSubmitFilters)
+	for {
+		time.Sleep(filterConfigPollInterval)
+		w.checkForChanges()
+	}
+}
+
 func convertDeprecatedBotConfigValues(l logger.Logger, botConfig trader.BotConfig) trader.BotConfig {
 	if botConfig.CentralizedMinBaseVolumeOverride != nil && botConfig.MinCentralizedBaseVolumeDeprecated != nil {
 		l.Infof("deprecation warning: cannot set both '%s' (deprecated) and '%s' in the trader config, using value from '%s'\n", "MIN_CENTRALIZED_BASE_VOLUME", "CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE", "CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE")
@@ -611,7 +776,7 @@ func runTradeCmd(options inputs) {
 		botConfig.DollarValueFeedBaseAsset != "" && botConfig.DollarValueFeedQuoteAsset != "",
 		botConfig.AlertType,
 		int(botConfig.MonitoringPort) != 0,
-		len(botConfig.Filters) > 0,
+		len(botConfig.Filters) > 0 || len(botConfig.FilterConfigs) > 0,
 		botConfig.PostgresDbConfig != nil,
 		*options.logPrefix != "",
 		*options.operationalBuffer,
@@ -700,6 +865,15 @@ func runTradeCmd(options inputs) {
 		}
 		log.Printf("made db instance with config: %s\n", botConfig.PostgresDbConfig.MakeConnectString())
 	}
+	baseString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert base trading pair to string: %s", e))
+	}
+	quoteString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string: %s", e))
+	}
+	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
 	exchangeShim, sdex := makeExchangeShimSdex(
 		l,
 		botConfig,
@@ -710,24 +884,27 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		tradingPair,
 		sdexAssetMap,
+		db,
+		marketID,
 	)
-	filterFactory := &plugins.FilterFactory{
-		ExchangeName:   botConfig.TradingExchangeName(),
-		TradingPair:    tradingPair,
-		AssetDisplayFn: assetDisplayFn,
-		BaseAsset:      assetBase,
-		QuoteAsset:     assetQuote,
-		DB:             db,
+	// the filter explainer is needed for the --explainFilters flag and to serve explanations from the monitoring server
+	var filterExplainer *plugins.FilterExplainer
+	if *options.explainFilters || botConfig.MonitoringPort != 0 {
+		filterExplainer = plugins.MakeFilterExplainer(*options.explainFilters)
 	}
-	baseString, e := assetDisplayFn(tradingPair.Base)
-	if e != nil {
-		logger.Fatal(l, fmt.Errorf("could not convert base trading pair to string: %s", e))
+	filterFactory := &plugins.FilterFactory{
+		ExchangeName:    botConfig.TradingExchangeName(),
+		TradingPair:     tradingPair,
+		AssetDisplayFn:  assetDisplayFn,
+		BaseAsset:       assetBase,
+		QuoteAsset:      assetQuote,
+		DB:              db,
+		FilterExplainer: filterExplainer,
 	}
-	quoteString, e := assetDisplayFn(tradingPair.Quote)
+	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
 	if e != nil {
-		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string: %s", e))
+		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
 	}
-	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
 	strategy := makeStrategy(
 		l,
 		network,
@@ -745,6 +922,7 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		db,
 		metricsTracker,
+		alert,
 	)
 	fillTracker := makeFillTracker(
 		l,
@@ -774,6 +952,8 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		options,
 		metricsTracker,
+		alert,
+		filterExplainer,
 		botStart,
 	)
 	// --- end initialization of objects ---
@@ -781,7 +961,7 @@ func runTradeCmd(options inputs) {
 	validateTrustlines(l, client, &botConfig)
 	if botConfig.MonitoringPort != 0 {
 		go func() {
-			e := startMonitoringServer(l, botConfig)
+			e := startMonitoringServer(l, botConfig, filterExplainer)
 			if e != nil {
 				l.Info("")
 				l.Info("unable to start the monitoring server or problem encountered while running server:")
@@ -805,6 +985,24 @@ func runTradeCmd(options inputs) {
 			}
 		}()
 	}
+	filterStrings, e := botConfig.FilterStrings()
+	if e != nil {
+		// the filters were already made by makeBot so this should never happen
+		logger.Fatal(l, fmt.Errorf("programmer error? unable to get filters after making the bot: %s", e))
+	}
+	go watchFilterConfig(
+		l,
+		*options.botConfigPath,
+		filterStrings,
+		func(filterStrings []string) ([]plugins.SubmitFilter, error) {
+			submitMode, e := api.ParseSubmitMode(botConfig.SubmitMode)
+			if e != nil {
+				return nil, e
+			}
+			return makeSubmitFilters(filterStrings, *options.strategy, submitMode, filterFactory, exchangeShim, sdex, tradingPair, botConfig.AssetBase(), botConfig.AssetQuote())
+		},
+		bot,
+	)
 	// --- end initialization of services ---
 
 	l.Info("Starting the trader bot...")
@@ -833,7 +1031,7 @@ func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
 	return fmt.Sprint(userIDHashed), nil
 }
 
-func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
+func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig, filterExplainer *plugins.FilterExplainer) error {
 	healthMetrics, e := monitoring.MakeMetricsRecorder(map[string]interface{}{"success": true})
 	if e != nil {
 		return fmt.Errorf("unable to make metrics recorder for the /health endpoint: %s", e)
@@ -856,6 +1054,18 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 		return fmt.Errorf("unable to make /metrics endpoint: %s", e)
 	}
 
+	// serves the explanation of the changes made by the filter chain in the last update cycle
+	filtersEndpoint, e := monitoring.MakeMetricsEndpoint("/filters", filterExplainer, metricsAuth)
+	if e != nil {
+		return fmt.Errorf("unable to make /filters endpoint: %s", e)
+	}
+
+	// serves the throttling metrics of the rate limiters used for requests to exchanges
+	rateLimitsEndpoint, e := monitoring.MakeMetricsEndpoint("/ratelimits", networking.RateLimiterMetrics{}, metricsAuth)
+	if e != nil {
+		return fmt.Errorf("unable to make /ratelimits endpoint: %s", e)
+	}
+
 	serverConfig := &networking.Config{
 		GoogleClientID:     botConfig.GoogleClientID,
 		GoogleClientSecret: botConfig.GoogleClientSecret,
@@ -864,7 +1074,7 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 	for _, email := range strings.Split(botConfig.AcceptableEmails, ",") {
 		serverConfig.PermittedEmails[email] = true
 	}
-	server, e := networking.MakeServer(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint})
+	server, e := networking.MakeServer(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint, filtersEndpoint, rateLimitsEndpoint})
 	if e != nil {
 		return fmt.Errorf("unable to initialize the metrics server: %s", e)
 	}
@@ -2,12 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/plugins"
 	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/logger"
 )
 
 func TestTradeUpgradeScripts(t *testing.T) {
@@ -23,11 +30,15 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	}
 
 	// assert current state of the database
-	assert.Equal(t, 4, database.GetNumTablesInDb(db))
+	assert.Equal(t, 8, database.GetNumTablesInDb(db))
 	assert.True(t, database.CheckTableExists(db, "db_version"))
 	assert.True(t, database.CheckTableExists(db, "markets"))
 	assert.True(t, database.CheckTableExists(db, "trades"))
 	assert.True(t, database.CheckTableExists(db, "strategy_mirror_trade_triggers"))
+	assert.True(t, database.CheckTableExists(db, "rebalance_transfers"))
+	assert.True(t, database.CheckTableExists(db, "batched_exchange_offer_ids"))
+	assert.True(t, database.CheckTableExists(db, "strategy_mirror_base_surplus"))
+	assert.True(t, database.CheckTableExists(db, "strategy_mirror_offset_retries"))
 
 	// check schema of db_version table
 	var columns []database.TableColumn
@@ -218,7 +229,7 @@ func TestTradeUpgradeScripts(t *testing.T) {
 
 	// check schema of strategy_mirror_trade_triggers table
 	columns = database.GetTableSchema(db, "strategy_mirror_trade_triggers")
-	assert.Equal(t, 4, len(columns), fmt.Sprintf("%v", columns))
+	assert.Equal(t, 7, len(columns), fmt.Sprintf("%v", columns))
 	database.AssertTableColumnsEqual(t, &database.TableColumn{
 		ColumnName:             "market_id",
 		OrdinalPosition:        1,
@@ -251,15 +262,281 @@ func TestTradeUpgradeScripts(t *testing.T) {
 		DataType:               "text",
 		CharacterMaximumLength: nil,
 	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_base_volume",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_counter_cost",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_fee",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
 	// check indexes of strategy_mirror_trade_triggers table
 	indexes = database.GetTableIndexes(db, "strategy_mirror_trade_triggers")
-	assert.Equal(t, 1, len(indexes))
+	assert.Equal(t, 2, len(indexes))
 	database.AssertIndex(t, "strategy_mirror_trade_triggers", "strategy_mirror_trade_triggers_pkey", "CREATE UNIQUE INDEX strategy_mirror_trade_triggers_pkey ON public.strategy_mirror_trade_triggers USING btree (market_id, txid)", indexes)
+	database.AssertIndex(t, "strategy_mirror_trade_triggers", "strategy_mirror_trade_triggers_bmo", "CREATE INDEX strategy_mirror_trade_triggers_bmo ON public.strategy_mirror_trade_triggers USING btree (backing_market_id, backing_order_id)", indexes)
+
+	// check schema of rebalance_transfers table
+	columns = database.GetTableSchema(db, "rebalance_transfers")
+	assert.Equal(t, 9, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "transfer_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "exchange_name",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "asset",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "direction",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "amount",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "status",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "reference",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "error",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	// check indexes of rebalance_transfers table
+	indexes = database.GetTableIndexes(db, "rebalance_transfers")
+	assert.Equal(t, 2, len(indexes))
+	database.AssertIndex(t, "rebalance_transfers", "rebalance_transfers_pkey", "CREATE UNIQUE INDEX rebalance_transfers_pkey ON public.rebalance_transfers USING btree (transfer_id)", indexes)
+	database.AssertIndex(t, "rebalance_transfers", "rebalance_transfers_ead", "CREATE INDEX rebalance_transfers_ead ON public.rebalance_transfers USING btree (exchange_name, asset, date_utc)", indexes)
+
+	// check schema of batched_exchange_offer_ids table
+	columns = database.GetTableSchema(db, "batched_exchange_offer_ids")
+	assert.Equal(t, 3, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "offer_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "bigint",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "order_id",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	// check indexes of batched_exchange_offer_ids table
+	indexes = database.GetTableIndexes(db, "batched_exchange_offer_ids")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "batched_exchange_offer_ids", "batched_exchange_offer_ids_pkey", "CREATE UNIQUE INDEX batched_exchange_offer_ids_pkey ON public.batched_exchange_offer_ids USING btree (market_id, offer_id)", indexes)
+
+	// check schema of strategy_mirror_base_surplus table
+	columns = database.GetTableSchema(db, "strategy_mirror_base_surplus")
+	assert.Equal(t, 3, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "action",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "total",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	// check indexes of strategy_mirror_base_surplus table
+	indexes = database.GetTableIndexes(db, "strategy_mirror_base_surplus")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_mirror_base_surplus", "strategy_mirror_base_surplus_pkey", "CREATE UNIQUE INDEX strategy_mirror_base_surplus_pkey ON public.strategy_mirror_base_surplus USING btree (market_id, action)", indexes)
+
+	// check schema of strategy_mirror_offset_retries table
+	columns = database.GetTableSchema(db, "strategy_mirror_offset_retries")
+	assert.Equal(t, 11, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "txid",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "action",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "price",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "status",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "attempts",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "created_at_utc",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "next_attempt_at_utc",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "last_error",
+		OrdinalPosition:        10,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[9])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "alerted",
+		OrdinalPosition:        11,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "boolean",
+		CharacterMaximumLength: nil,
+	}, &columns[10])
+	// check indexes of strategy_mirror_offset_retries table
+	indexes = database.GetTableIndexes(db, "strategy_mirror_offset_retries")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_mirror_offset_retries", "strategy_mirror_offset_retries_pkey", "CREATE UNIQUE INDEX strategy_mirror_offset_retries_pkey ON public.strategy_mirror_offset_retries USING btree (market_id, txid)", indexes)
 
 	// check entries of db_version table
 	var allRows [][]interface{}
 	allRows = database.QueryAllRows(db, "db_version")
-	assert.Equal(t, 6, len(allRows))
+	assert.Equal(t, 11, len(allRows))
 	// first three code_version_string is nil becuase the field was not supported at the time when the upgrade script was run, and only in version 4 of
 	// the database do we add the field. See upgradeScripts and RunUpgradeScripts() for more details
 	database.ValidateDBVersionRow(t, allRows[0], 1, time.Now(), 1, 50, nil)
@@ -268,6 +545,11 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	database.ValidateDBVersionRow(t, allRows[3], 4, time.Now(), 1, 50, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[4], 5, time.Now(), 2, 100, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[5], 6, time.Now(), 2, 100, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[6], 7, time.Now(), 2, 100, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[7], 8, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[8], 9, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[9], 10, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[10], 11, time.Now(), 2, 100, &codeVersionString)
 
 	// check entries of markets table
 	allRows = database.QueryAllRows(db, "markets")
@@ -280,4 +562,159 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	// check entries of strategy_mirror_trade_triggers table
 	allRows = database.QueryAllRows(db, "strategy_mirror_trade_triggers")
 	assert.Equal(t, 0, len(allRows))
+
+	// check entries of rebalance_transfers table
+	allRows = database.QueryAllRows(db, "rebalance_transfers")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of batched_exchange_offer_ids table
+	allRows = database.QueryAllRows(db, "batched_exchange_offer_ids")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_mirror_base_surplus table
+	allRows = database.QueryAllRows(db, "strategy_mirror_base_surplus")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_mirror_offset_retries table
+	allRows = database.QueryAllRows(db, "strategy_mirror_offset_retries")
+	assert.Equal(t, 0, len(allRows))
+}
+
+// namedSubmitFilter is a no-op filter that is identified by the filter string it was made from
+type namedSubmitFilter struct {
+	filterString string
+}
+
+func (f *namedSubmitFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	return ops, nil
+}
+
+func TestFilterConfigWatcherCheckForChanges(t *testing.T) {
+	initialConfig := `FILTERS = ["maxOrders/10/100.0"]`
+	initialFilterStrings := []string{"maxOrders/10/100.0"}
+
+	testCases := []struct {
+		name              string
+		newConfig         string
+		touch             bool
+		wantReloaded      bool
+		wantFilterStrings []string
+	}{
+		{
+			name:              "unmodified file",
+			newConfig:         initialConfig,
+			touch:             false,
+			wantReloaded:      false,
+			wantFilterStrings: initialFilterStrings,
+		}, {
+			name:              "modified file with the same filters",
+			newConfig:         initialConfig,
+			touch:             true,
+			wantReloaded:      false,
+			wantFilterStrings: initialFilterStrings,
+		}, {
+			name:              "changed filters",
+			newConfig:         `FILTERS = ["maxOrders/5/100.0", "schedule/*=00:00-24:00"]`,
+			touch:             true,
+			wantReloaded:      true,
+			wantFilterStrings: []string{"maxOrders/5/100.0", "schedule/*=00:00-24:00"},
+		}, {
+			name:              "all filters removed",
+			newConfig:         `FILTERS = []`,
+			touch:             true,
+			wantReloaded:      true,
+			wantFilterStrings: []string{},
+		}, {
+			name:              "unreadable config",
+			newConfig:         `FILTERS = ["maxOrders/5/100.0"`,
+			touch:             true,
+			wantReloaded:      false,
+			wantFilterStrings: initialFilterStrings,
+		}, {
+			name: "invalid filter table",
+			newConfig: `
+[[FILTER]]
+TYPE="maxOrders"
+MAX_ORDER_BASE_AMOUNT=-1.0
+`,
+			touch:             true,
+			wantReloaded:      false,
+			wantFilterStrings: initialFilterStrings,
+		}, {
+			name:              "filters that cannot be made",
+			newConfig:         `FILTERS = ["invalid/1"]`,
+			touch:             true,
+			wantReloaded:      false,
+			wantFilterStrings: initialFilterStrings,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			dir, e := ioutil.TempDir("", "kelp-filter-watcher")
+			if !assert.NoError(t, e) {
+				return
+			}
+			defer os.RemoveAll(dir)
+
+			botConfigPath := filepath.Join(dir, "trader.cfg")
+			e = ioutil.WriteFile(botConfigPath, []byte(initialConfig), 0644)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			makeFiltersFn := func(filterStrings []string) ([]plugins.SubmitFilter, error) {
+				submitFilters := []plugins.SubmitFilter{}
+				for _, filterString := range filterStrings {
+					if filterString == "invalid/1" {
+						return nil, fmt.Errorf("invalid filter: %s", filterString)
+					}
+					submitFilters = append(submitFilters, &namedSubmitFilter{filterString: filterString})
+				}
+				return submitFilters, nil
+			}
+			var setFilters []plugins.SubmitFilter
+			numSetCalls := 0
+			setFiltersFn := func(submitFilters []plugins.SubmitFilter) {
+				setFilters = submitFilters
+				numSetCalls++
+			}
+			w := makeFilterConfigWatcher(logger.MakeBasicLogger(), botConfigPath, initialFilterStrings, makeFiltersFn, setFiltersFn)
+
+			e = ioutil.WriteFile(botConfigPath, []byte(k.newConfig), 0644)
+			if !assert.NoError(t, e) {
+				return
+			}
+			// set the modification time explicitly so the test does not depend on the resolution of the file system timestamps
+			modTime := w.lastModTime
+			if k.touch {
+				modTime = modTime.Add(time.Minute)
+			}
+			e = os.Chtimes(botConfigPath, modTime, modTime)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, k.wantReloaded, w.checkForChanges())
+			assert.Equal(t, k.wantFilterStrings, w.filterStrings)
+			if !k.wantReloaded {
+				// the bot keeps using the previous filters
+				assert.Equal(t, 0, numSetCalls)
+				return
+			}
+
+			if !assert.Equal(t, 1, numSetCalls) {
+				return
+			}
+			gotFilterStrings := []string{}
+			for _, f := range setFilters {
+				gotFilterStrings = append(gotFilterStrings, f.(*namedSubmitFilter).filterString)
+			}
+			assert.Equal(t, k.wantFilterStrings, gotFilterStrings)
+
+			// nothing changes when the file is checked again
+			assert.False(t, w.checkForChanges())
+			assert.Equal(t, 1, numSetCalls)
+		})
+	}
 }
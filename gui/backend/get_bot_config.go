@@ -54,6 +54,10 @@ func (s *APIServer) getBotConfig(w http.ResponseWriter, r *http.Request) {
 		))
 		return
 	}
+	// the levels can be set only in LEVEL_GENERATOR, BIDS or ASKS, but the GUI expects a list of levels
+	if buysellConfig.Levels == nil {
+		buysellConfig.Levels = []plugins.StaticLevel{}
+	}
 
 	response := botConfigResponse{
 		Name:           botName,
@@ -176,7 +176,7 @@ func (s *APIServer) validateConfigs(req upsertBotConfigRequest) *upsertBotConfig
 		hasError = true
 	}
 
-	if len(req.StrategyConfig.Levels) == 0 || hasNewLevel(req.StrategyConfig.Levels) {
+	if !hasValidLevels(req.StrategyConfig) {
 		errResp.StrategyConfig.Levels = []plugins.StaticLevel{}
 		hasError = true
 	}
@@ -208,6 +208,21 @@ func InitBotNameRegex() (e error) {
 	return nil
 }
 
+// hasValidLevels checks the levels of both sides, which can come from LEVELS, LEVEL_GENERATOR or the overrides in BIDS and ASKS
+func hasValidLevels(config plugins.BuySellConfig) bool {
+	bidLevels, askLevels, e := config.SideLevels()
+	if e != nil {
+		return false
+	}
+
+	for _, levels := range [][]plugins.StaticLevel{bidLevels, askLevels} {
+		if len(levels) == 0 || hasNewLevel(levels) {
+			return false
+		}
+	}
+	return true
+}
+
 func hasNewLevel(levels []plugins.StaticLevel) bool {
 	for _, l := range levels {
 		if l.AMOUNT == 0 || l.SPREAD == 0 {
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/plugins"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -107,3 +108,55 @@ func TestIsBotNameValid(t *testing.T) {
 		})
 	}
 }
+
+func TestHasValidLevels(t *testing.T) {
+	levels := []plugins.StaticLevel{{SPREAD: 0.001, AMOUNT: 100}}
+	newLevels := []plugins.StaticLevel{{SPREAD: 0.001, AMOUNT: 100}, {SPREAD: 0, AMOUNT: 0}}
+	generator := &plugins.LevelGenerator{NumLevels: 2, StartSpread: 0.001, SpreadStep: 0.001, AmountCurve: "linear", StartAmount: 100}
+
+	testCases := []struct {
+		name      string
+		config    plugins.BuySellConfig
+		wantValid bool
+	}{
+		{
+			name:      "top level levels",
+			config:    plugins.BuySellConfig{Levels: levels},
+			wantValid: true,
+		}, {
+			name:      "top level generator",
+			config:    plugins.BuySellConfig{LevelGenerator: generator},
+			wantValid: true,
+		}, {
+			name:      "levels only in bids and asks",
+			config:    plugins.BuySellConfig{Bids: &plugins.BuySellSideConfig{Levels: levels}, Asks: &plugins.BuySellSideConfig{LevelGenerator: generator}},
+			wantValid: true,
+		}, {
+			name:      "levels only in bids",
+			config:    plugins.BuySellConfig{Bids: &plugins.BuySellSideConfig{Levels: levels}},
+			wantValid: false,
+		}, {
+			name:      "no levels",
+			config:    plugins.BuySellConfig{},
+			wantValid: false,
+		}, {
+			name:      "new level",
+			config:    plugins.BuySellConfig{Levels: newLevels},
+			wantValid: false,
+		}, {
+			name:      "new level in asks",
+			config:    plugins.BuySellConfig{Levels: levels, Asks: &plugins.BuySellSideConfig{Levels: newLevels}},
+			wantValid: false,
+		}, {
+			name:      "levels and generator",
+			config:    plugins.BuySellConfig{Levels: levels, LevelGenerator: generator},
+			wantValid: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.wantValid, hasValidLevels(k.config))
+		})
+	}
+}
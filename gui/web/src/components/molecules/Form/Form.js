@@ -52,6 +52,8 @@ class Form extends Component {
     this.newLevel = this.newLevel.bind(this);
     this.hasNewLevel = this.hasNewLevel.bind(this);
     this.removeLevel = this.removeLevel.bind(this);
+    this.toggleLevelGenerator = this.toggleLevelGenerator.bind(this);
+    this.renderLevelGenerator = this.renderLevelGenerator.bind(this);
     this.newSecret = this.newSecret.bind(this);
     this.getError = this.getError.bind(this);
     this.addNumericalError = this.addNumericalError.bind(this);
@@ -65,6 +67,7 @@ class Form extends Component {
     this.getConfigFeedURLTransformIfFiat = this.getConfigFeedURLTransformIfFiat.bind(this);
     this._emptyLevel = this._emptyLevel.bind(this);
     this._triggerUpdateLevels = this._triggerUpdateLevels.bind(this);
+    this._defaultLevelGenerator = this._defaultLevelGenerator.bind(this);
     this._fetchDotNotation = this._fetchDotNotation.bind(this);
     this._last_fill_tracker_sleep_millis = 1000;
 
@@ -334,6 +337,150 @@ class Form extends Component {
     )
   }
 
+  toggleLevelGenerator() {
+    // only one of levels and level_generator can be set so switching between them resets the other one
+    if (this.props.configData.strategy_config.level_generator) {
+      const generatorFields = ["num_levels", "start_spread", "spread_step", "start_amount", "amount_step", "amount_factor"];
+      for (let i = 0; i < generatorFields.length; i++) {
+        this.clearNumericalError("strategy_config.level_generator." + generatorFields[i]);
+      }
+      this.props.onChange(
+        "strategy_config.level_generator", {target: {value: null}},
+        { "strategy_config.levels": (value) => { return [this._emptyLevel()]; } }
+      );
+      return;
+    }
+
+    this.clearNumericalError("strategy_config.levels");
+    this.setState({
+      levelNumericalErrors: {}
+    });
+    // always set amount_of_a_base to 1.0 like we do for the levels
+    this.props.onChange(
+      "strategy_config.level_generator", {target: {value: this._defaultLevelGenerator()}},
+      {
+        "strategy_config.levels": (value) => { return []; },
+        "strategy_config.amount_of_a_base": (value) => { return 1.0; },
+      }
+    );
+  }
+
+  _defaultLevelGenerator() {
+    return {
+      num_levels: 10,
+      start_spread: 0.001,
+      spread_step: 0.0005,
+      amount_curve: "linear",
+      start_amount: 100.0,
+      amount_step: 0.0,
+      amount_factor: 1.2,
+    };
+  }
+
+  renderLevelGenerator() {
+    const generator = this.props.configData.strategy_config.level_generator;
+    const isLinear = generator.amount_curve === "linear";
+
+    let error = "";
+    if (this.getError("strategy_config.levels")) {
+      error = (<ErrorMessage errorList={["the level generator does not generate valid levels"]}/>);
+    }
+
+    return (
+      <FieldGroup groupTitle="Level Generator">
+        <FieldItem>
+          <Label>Number of levels</Label>
+          <Input
+            value={generator.num_levels}
+            type="int_positive"
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.num_levels", event) }}
+            error={this.getError("strategy_config.level_generator.num_levels")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.num_levels", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.num_levels") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label>Start spread</Label>
+          <Input
+            suffix="%"
+            value={generator.start_spread}
+            type="percent_positive"
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.start_spread", event) }}
+            error={this.getError("strategy_config.level_generator.start_spread")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.start_spread", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.start_spread") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label>Spread step</Label>
+          <Input
+            suffix="%"
+            value={generator.spread_step}
+            type="percent_nonnegative"
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.spread_step", event) }}
+            error={this.getError("strategy_config.level_generator.spread_step")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.spread_step", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.spread_step") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label padding>Amount curve</Label>
+          <SegmentedControl
+            segments={["linear", "geometric", "exponential"]}
+            selected={generator.amount_curve}
+            onSelect={(selected) => {
+              if (!this.props.readOnly) {
+                this.props.onChange("strategy_config.level_generator.amount_curve", {target: {value: selected}});
+              }
+            }}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label>Start amount</Label>
+          <Input
+            value={generator.start_amount}
+            type="float_positive"
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.start_amount", event) }}
+            error={this.getError("strategy_config.level_generator.start_amount")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.start_amount", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.start_amount") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label disabled={!isLinear}>Amount step</Label>
+          <Input
+            value={generator.amount_step}
+            type="float"
+            disabled={!isLinear}
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.amount_step", event) }}
+            error={this.getError("strategy_config.level_generator.amount_step")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.amount_step", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.amount_step") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        <FieldItem>
+          <Label disabled={isLinear}>Amount factor</Label>
+          <Input
+            value={generator.amount_factor}
+            type={generator.amount_curve === "exponential" ? "float" : "float_positive"}
+            disabled={isLinear}
+            onChange={(event) => { this.props.onChange("strategy_config.level_generator.amount_factor", event) }}
+            error={this.getError("strategy_config.level_generator.amount_factor")}
+            triggerError={(message) => { this.addNumericalError("strategy_config.level_generator.amount_factor", message) }}
+            clearError={() => { this.clearNumericalError("strategy_config.level_generator.amount_factor") }}
+            readOnly={this.props.readOnly}
+            />
+        </FieldItem>
+        {error}
+      </FieldGroup>
+    );
+  }
+
   newSecret(field) {
     var _this = this;
     this._asyncRequests["secretKey"] = newSecretKey(this.props.baseUrl).then(resp => {
@@ -938,20 +1085,30 @@ class Form extends Component {
             
             <div className={grid.row}>
               <div className={grid.col8}>
-                <FieldGroup groupTitle="Levels">
-                  <Levels
-                    levels={this.props.configData.strategy_config.levels}
-                    updateLevel={(levelIdx, subfield, value) => { this.updateLevel(levelIdx, subfield, value) }}
-                    newLevel={this.newLevel}
-                    hasNewLevel={this.hasNewLevel}
-                    onRemove={(levelIdx) => { this.removeLevel(levelIdx) }}
-                    error={this.getError("strategy_config.levels")}
-                    levelErrors={this.state.levelNumericalErrors}
-                    addLevelError={(levelIdx, subfield, message) => { this.addLevelError(levelIdx, subfield, message) }}
-                    clearLevelError={(levelIdx, subfield) => { this.clearLevelError(levelIdx, subfield) }}
+                <FieldItem inline>
+                  <Switch
+                    value={!!this.props.configData.strategy_config.level_generator}
+                    onChange={(event) => { this.toggleLevelGenerator() }}
                     readOnly={this.props.readOnly}
                     />
-                </FieldGroup>
+                  <Label>Generate levels</Label>
+                </FieldItem>
+                {this.props.configData.strategy_config.level_generator ? this.renderLevelGenerator() : (
+                  <FieldGroup groupTitle="Levels">
+                    <Levels
+                      levels={this.props.configData.strategy_config.levels}
+                      updateLevel={(levelIdx, subfield, value) => { this.updateLevel(levelIdx, subfield, value) }}
+                      newLevel={this.newLevel}
+                      hasNewLevel={this.hasNewLevel}
+                      onRemove={(levelIdx) => { this.removeLevel(levelIdx) }}
+                      error={this.getError("strategy_config.levels")}
+                      levelErrors={this.state.levelNumericalErrors}
+                      addLevelError={(levelIdx, subfield, message) => { this.addLevelError(levelIdx, subfield, message) }}
+                      clearLevelError={(levelIdx, subfield) => { this.clearLevelError(levelIdx, subfield) }}
+                      readOnly={this.props.readOnly}
+                      />
+                  </FieldGroup>
+                )}
               </div>
             </div>
           </FormSection>
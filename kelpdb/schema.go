@@ -8,6 +8,11 @@ const SqlTradesTableCreate = "CREATE TABLE IF NOT EXISTS trades (market_id TEXT
 const SqlTradesTableAlter1 = "ALTER TABLE trades ADD COLUMN account_id TEXT"
 const SqlStrategyMirrorTradeTriggersTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_trade_triggers (market_id TEXT NOT NULL, txid TEXT NOT NULL, backing_market_id TEXT NOT NULL, backing_order_id TEXT NOT NULL, PRIMARY KEY (market_id, txid))"
 const SqlTradesTableAlter2 = "ALTER TABLE trades ADD COLUMN order_id TEXT"
+const SqlRebalanceTransfersTableCreate = "CREATE TABLE IF NOT EXISTS rebalance_transfers (transfer_id TEXT PRIMARY KEY, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, exchange_name TEXT NOT NULL, asset TEXT NOT NULL, direction TEXT NOT NULL, amount DOUBLE PRECISION NOT NULL, status TEXT NOT NULL, reference TEXT NOT NULL, error TEXT NOT NULL)"
+const SqlBatchedExchangeOfferIDsTableCreate = "CREATE TABLE IF NOT EXISTS batched_exchange_offer_ids (market_id TEXT NOT NULL, offer_id BIGINT NOT NULL, order_id TEXT NOT NULL, PRIMARY KEY (market_id, offer_id))"
+const SqlStrategyMirrorBaseSurplusTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_base_surplus (market_id TEXT NOT NULL, action TEXT NOT NULL, total DOUBLE PRECISION NOT NULL, PRIMARY KEY (market_id, action))"
+const SqlStrategyMirrorOffsetRetriesTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_offset_retries (market_id TEXT NOT NULL, txid TEXT NOT NULL, action TEXT NOT NULL, base_volume DOUBLE PRECISION NOT NULL, price DOUBLE PRECISION NOT NULL, status TEXT NOT NULL, attempts INTEGER NOT NULL, created_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, next_attempt_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, last_error TEXT NOT NULL, alerted BOOLEAN NOT NULL, PRIMARY KEY (market_id, txid))"
+const SqlStrategyMirrorTradeTriggersTableAlter1 = "ALTER TABLE strategy_mirror_trade_triggers ADD COLUMN backing_base_volume DOUBLE PRECISION, ADD COLUMN backing_counter_cost DOUBLE PRECISION, ADD COLUMN backing_fee DOUBLE PRECISION"
 
 /*
 	indexes
@@ -15,6 +20,8 @@ const SqlTradesTableAlter2 = "ALTER TABLE trades ADD COLUMN order_id TEXT"
 const SqlTradesIndexCreate = "CREATE INDEX IF NOT EXISTS date ON trades (market_id, date_utc)"
 const SqlTradesIndexDrop = "DROP INDEX IF EXISTS date"
 const SqlTradesIndexCreate2 = "CREATE INDEX IF NOT EXISTS trades_mdd ON trades (market_id, DATE(date_utc), date_utc)"
+const SqlRebalanceTransfersIndexCreate = "CREATE INDEX IF NOT EXISTS rebalance_transfers_ead ON rebalance_transfers (exchange_name, asset, date_utc)"
+const SqlStrategyMirrorTradeTriggersIndexCreate = "CREATE INDEX IF NOT EXISTS strategy_mirror_trade_triggers_bmo ON strategy_mirror_trade_triggers (backing_market_id, backing_order_id)"
 
 // We don't include account_id in the primary key of the trades table because the account_id will initially be null until we clean that up (later)
 // For now we add it as a unique index on which we will later base the primary key. This does not provide us with any immediate benefit because the PK is a subset
@@ -30,11 +37,61 @@ const SqlMarketsInsertTemplate = "INSERT INTO markets (market_id, exchange_name,
 // SqlTradesInsertTemplate inserts into the trades table
 const SqlTradesInsertTemplate = "INSERT INTO trades (market_id, txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, account_id, order_id) VALUES ('%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f, %.15f, '%s', '%s')"
 
-// SqlStrategyMirrorTradeTriggersInsertTemplate inserts into the strategy_mirror_trade_triggers table
-const SqlStrategyMirrorTradeTriggersInsertTemplate = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id) VALUES ('%s', '%s', '%s', '%s')"
+// SqlStrategyMirrorTradeTriggersInsert inserts into the strategy_mirror_trade_triggers table, a trade that already has a trigger is left as it is
+const SqlStrategyMirrorTradeTriggersInsert = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id) VALUES ($1, $2, $3, $4) ON CONFLICT (market_id, txid) DO NOTHING"
+
+// SqlRebalanceTransfersInsert inserts into the rebalance_transfers table, this uses placeholders because the error column can contain arbitrary text
+const SqlRebalanceTransfersInsert = "INSERT INTO rebalance_transfers (transfer_id, date_utc, exchange_name, asset, direction, amount, status, reference, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+
+// SqlBatchedExchangeOfferIDsInsert inserts into the batched_exchange_offer_ids table
+const SqlBatchedExchangeOfferIDsInsert = "INSERT INTO batched_exchange_offer_ids (market_id, offer_id, order_id) VALUES ($1, $2, $3)"
+
+// SqlStrategyMirrorBaseSurplusUpsert inserts or updates the surplus of an action for a market in the strategy_mirror_base_surplus table
+const SqlStrategyMirrorBaseSurplusUpsert = "INSERT INTO strategy_mirror_base_surplus (market_id, action, total) VALUES ($1, $2, $3) ON CONFLICT (market_id, action) DO UPDATE SET total = EXCLUDED.total"
+
+// SqlStrategyMirrorOffsetRetriesInsert inserts a failed offset into the strategy_mirror_offset_retries table, a trade that is already queued is left as it is
+const SqlStrategyMirrorOffsetRetriesInsert = "INSERT INTO strategy_mirror_offset_retries (market_id, txid, action, base_volume, price, status, attempts, created_at_utc, next_attempt_at_utc, last_error, alerted) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (market_id, txid) DO NOTHING"
+
+/*
+	update statements
+*/
+// SqlStrategyMirrorOffsetRetriesUpdateAttempt records a failed retry of an offset in the strategy_mirror_offset_retries table
+const SqlStrategyMirrorOffsetRetriesUpdateAttempt = "UPDATE strategy_mirror_offset_retries SET status = $3, attempts = $4, next_attempt_at_utc = $5, last_error = $6, alerted = $7 WHERE market_id = $1 AND txid = $2"
+
+// SqlStrategyMirrorOffsetRetriesUpdateRetryNow makes a pending or unverified offset due for a retry on the next update cycle of the bot,
+// an unverified offset is marked as pending so it is placed again
+const SqlStrategyMirrorOffsetRetriesUpdateRetryNow = "UPDATE strategy_mirror_offset_retries SET status = 'pending', next_attempt_at_utc = $2 WHERE txid = $1 AND status IN ('pending', 'unverified')"
+
+// SqlStrategyMirrorOffsetRetriesUpdateCancel marks a pending or unverified offset as cancelled, the bot removes it from the queue on the next update cycle
+const SqlStrategyMirrorOffsetRetriesUpdateCancel = "UPDATE strategy_mirror_offset_retries SET status = 'cancelled' WHERE txid = $1 AND status IN ('pending', 'unverified')"
+
+// SqlStrategyMirrorTradeTriggersUpdateBackingFill sets the backing fill columns of the trade triggers that placed a backing order from all the fills of
+// that order in the trades table, so it can be run again for each fill of a partially filled order
+const SqlStrategyMirrorTradeTriggersUpdateBackingFill = "UPDATE strategy_mirror_trade_triggers t SET backing_base_volume = agg.base_volume, backing_counter_cost = agg.counter_cost, backing_fee = agg.fee FROM (SELECT market_id, order_id, SUM(base_volume) AS base_volume, SUM(counter_cost) AS counter_cost, SUM(fee) AS fee FROM trades WHERE market_id = $1 AND order_id = $2 GROUP BY market_id, order_id) agg WHERE t.backing_market_id = agg.market_id AND t.backing_order_id = agg.order_id"
+
+/*
+	delete statements
+*/
+// SqlBatchedExchangeOfferIDsDelete deletes all the offer IDs for a market from the batched_exchange_offer_ids table
+const SqlBatchedExchangeOfferIDsDelete = "DELETE FROM batched_exchange_offer_ids WHERE market_id = $1"
+
+// SqlStrategyMirrorOffsetRetriesDelete deletes an offset from the strategy_mirror_offset_retries table once it is placed or cancelled
+const SqlStrategyMirrorOffsetRetriesDelete = "DELETE FROM strategy_mirror_offset_retries WHERE market_id = $1 AND txid = $2"
 
 /*
 	queries
 */
 // SqlQueryMarketsById queries the markets table
 const SqlQueryMarketsById = "SELECT market_id, exchange_name, base, quote FROM markets WHERE market_id = $1 LIMIT 1"
+
+// SqlQueryBatchedExchangeOfferIDs queries the batched_exchange_offer_ids table for all the offer IDs of a market
+const SqlQueryBatchedExchangeOfferIDs = "SELECT offer_id, order_id FROM batched_exchange_offer_ids WHERE market_id = $1"
+
+// SqlQueryStrategyMirrorBaseSurplus queries the strategy_mirror_base_surplus table for the surplus of each action of a market
+const SqlQueryStrategyMirrorBaseSurplus = "SELECT action, total FROM strategy_mirror_base_surplus WHERE market_id = $1"
+
+// SqlQueryStrategyMirrorOffsetRetries queries the strategy_mirror_offset_retries table for the queued offsets of a market, oldest first
+const SqlQueryStrategyMirrorOffsetRetries = "SELECT txid, action, base_volume, price, status, attempts, created_at_utc, next_attempt_at_utc, last_error, alerted FROM strategy_mirror_offset_retries WHERE market_id = $1 ORDER BY created_at_utc"
+
+// SqlQueryStrategyMirrorOffsetRetriesAll queries the strategy_mirror_offset_retries table for the queued offsets of all markets, oldest first
+const SqlQueryStrategyMirrorOffsetRetriesAll = "SELECT market_id, txid, action, base_volume, price, status, attempts, created_at_utc, next_attempt_at_utc, last_error, alerted FROM strategy_mirror_offset_retries ORDER BY created_at_utc"
@@ -143,6 +143,12 @@ var Display = displayAssetConverter{}
 // CcxtAssetConverter is the asset converter for the CCXT exchange interface
 var CcxtAssetConverter = Display
 
+// BinanceAssetConverter is the asset converter for the native Binance exchange integration
+var BinanceAssetConverter = Display
+
+// CoinbaseproAssetConverter is the asset converter for the native Coinbase Pro exchange integration
+var CoinbaseproAssetConverter = Display
+
 // KrakenAssetConverter is the asset converter for the Kraken exchange
 var KrakenAssetConverter = makeAssetConverter(map[Asset]string{
 	XLM:  "XXLM",
@@ -1,7 +1,13 @@
 package model
 
 import (
+	cryptoRand "crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/stellar/kelp/support/utils"
 )
@@ -62,8 +68,10 @@ type OrderType int8
 
 // These are the available order types
 const (
-	OrderTypeMarket OrderType = 0
-	OrderTypeLimit  OrderType = 1
+	OrderTypeMarket            OrderType = 0
+	OrderTypeLimit             OrderType = 1
+	OrderTypeImmediateOrCancel OrderType = 2 // limit order where any volume that is not filled immediately is cancelled
+	OrderTypeStopLimit         OrderType = 3 // limit order that is only placed once the price reaches the order's StopPrice
 )
 
 // IsMarket returns true for market orders
@@ -76,19 +84,35 @@ func (o OrderType) IsLimit() bool {
 	return o == OrderTypeLimit
 }
 
+// IsImmediateOrCancel returns true for immediate-or-cancel orders
+func (o OrderType) IsImmediateOrCancel() bool {
+	return o == OrderTypeImmediateOrCancel
+}
+
+// IsStopLimit returns true for stop-limit orders
+func (o OrderType) IsStopLimit() bool {
+	return o == OrderTypeStopLimit
+}
+
 // String is the stringer function
 func (o OrderType) String() string {
 	if o == OrderTypeMarket {
 		return "market"
 	} else if o == OrderTypeLimit {
 		return "limit"
+	} else if o == OrderTypeImmediateOrCancel {
+		return "ioc"
+	} else if o == OrderTypeStopLimit {
+		return "stop-limit"
 	}
 	return "error, unrecognized order type"
 }
 
 var orderTypeMap = map[string]OrderType{
-	"market": OrderTypeMarket,
-	"limit":  OrderTypeLimit,
+	"market":     OrderTypeMarket,
+	"limit":      OrderTypeLimit,
+	"ioc":        OrderTypeImmediateOrCancel,
+	"stop-limit": OrderTypeStopLimit,
 }
 
 // OrderTypeFromString is a convenience to convert from common strings to the corresponding OrderType
@@ -96,14 +120,66 @@ func OrderTypeFromString(s string) OrderType {
 	return orderTypeMap[s]
 }
 
+// ParseOrderType is like OrderTypeFromString but returns an error for unrecognized strings instead of defaulting to a market order
+func ParseOrderType(s string) (OrderType, error) {
+	o, ok := orderTypeMap[s]
+	if !ok {
+		return OrderTypeLimit, fmt.Errorf("unrecognized order type '%s'", s)
+	}
+	return o, nil
+}
+
 // Order represents an order in the orderbook
 type Order struct {
 	Pair        *TradingPair
 	OrderAction OrderAction
 	OrderType   OrderType
-	Price       *Number
+	Price       *Number // limit price, only used as a reference price for market orders
 	Volume      *Number
 	Timestamp   *Timestamp
+	// StopPrice is the price that triggers a stop-limit order, nil for other order types
+	StopPrice *Number
+	// ClientOrderID is an optional ID chosen by us when placing the order, empty if not used
+	ClientOrderID string
+}
+
+// ClientOrderIDGenerator generates random ClientOrderIDs.
+// It is a positive 32-bit integer in decimal form because that is the most restrictive format we pass it through to (kraken's userref)
+type ClientOrderIDGenerator struct {
+	mutex sync.Mutex
+	rng   *rand.Rand
+}
+
+// MakeClientOrderIDGenerator is a factory method, each generator has its own seed so IDs are not repeated after a restart
+func MakeClientOrderIDGenerator() *ClientOrderIDGenerator {
+	return &ClientOrderIDGenerator{
+		rng: rand.New(rand.NewSource(makeClientOrderIDSeed())),
+	}
+}
+
+// makeClientOrderIDSeed reads the seed from crypto/rand and falls back to the current time if that fails
+func makeClientOrderIDSeed() int64 {
+	var b [8]byte
+	if _, e := cryptoRand.Read(b[:]); e != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// Next returns the next ClientOrderID
+func (g *ClientOrderIDGenerator) Next() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return strconv.FormatInt(int64(g.rng.Int31n(1<<31-1))+1, 10)
+}
+
+// defaultClientOrderIDGenerator is used by MakeClientOrderID
+var defaultClientOrderIDGenerator = MakeClientOrderIDGenerator()
+
+// MakeClientOrderID generates a random ClientOrderID using a generator that is seeded once per process
+func MakeClientOrderID() string {
+	return defaultClientOrderIDGenerator.Next()
 }
 
 // String is the stringer function
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOrderIDGenerator(t *testing.T) {
+	numIDs := 20
+	g1 := MakeClientOrderIDGenerator()
+	g2 := MakeClientOrderIDGenerator()
+
+	ids1 := []string{}
+	ids2 := []string{}
+	for i := 0; i < numIDs; i++ {
+		ids1 = append(ids1, g1.Next())
+		ids2 = append(ids2, g2.Next())
+	}
+	// independently created generators are seeded differently, like a bot that is restarted
+	assert.NotEqual(t, ids1, ids2)
+
+	for _, id := range append(ids1, ids2...) {
+		v, e := strconv.ParseInt(id, 10, 64)
+		if !assert.NoError(t, e) {
+			return
+		}
+		// the ID needs to be a positive 32-bit integer
+		assert.True(t, v > 0 && v <= 1<<31-1, id)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/stellar/go/build"
@@ -20,6 +21,23 @@ import (
 // largePrecision is a large precision value for in-memory calculations
 const largePrecision = 10
 
+// maxParallelRequests limits the number of concurrent requests made when an exchange does not support batches natively
+const maxParallelRequests = 5
+
+// serialTradeAPI is implemented by exchanges whose private requests have to be made one at a time, such as exchanges that sign each
+// request with a nonce that has to increase (kraken), where parallel requests would arrive out of order and be rejected
+type serialTradeAPI interface {
+	requiresSerialRequests() bool
+}
+
+// maxParallelRequestsFor returns the number of private requests that can be made to the exchange at the same time
+func maxParallelRequestsFor(x interface{}) int {
+	if serialAPI, ok := x.(serialTradeAPI); ok && serialAPI.requiresSerialRequests() {
+		return 1
+	}
+	return maxParallelRequests
+}
+
 // BatchedExchange accumulates instructions that can be read out and processed in a batch-style later
 type BatchedExchange struct {
 	commands        []Command
@@ -30,6 +48,10 @@ type BatchedExchange struct {
 	tradingAccount  string
 	orderID2OfferID map[string]int64
 	offerID2OrderID map[int64]string
+	// modified offers are amended in place instead of cancelled and re-added when the inner exchange supports it natively
+	nativeAmendOrder bool
+	// persists offerID2OrderID so offers keep their IDs across restarts, can be nil
+	offerIDStore OfferIDStore
 }
 
 var _ api.ExchangeShim = BatchedExchange{}
@@ -41,16 +63,25 @@ func MakeBatchedExchange(
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
 	tradingAccount string,
+	nativeAmendOrder bool,
+	offerIDStore OfferIDStore,
 ) *BatchedExchange {
+	// amending is optional for exchanges so it is only used when the exchange is flagged for it and implements it
+	_, canAmend := inner.(api.AmendOrderAPI)
+	if nativeAmendOrder && !canAmend {
+		log.Printf("exchange is flagged to amend orders natively but does not implement AmendOrderAPI, modified offers will be cancelled and re-added\n")
+	}
 	return &BatchedExchange{
-		commands:        []Command{},
-		inner:           inner,
-		simMode:         simMode,
-		baseAsset:       baseAsset,
-		quoteAsset:      quoteAsset,
-		tradingAccount:  tradingAccount,
-		orderID2OfferID: map[string]int64{},
-		offerID2OrderID: map[int64]string{},
+		commands:         []Command{},
+		inner:            inner,
+		simMode:          simMode,
+		baseAsset:        baseAsset,
+		quoteAsset:       quoteAsset,
+		tradingAccount:   tradingAccount,
+		orderID2OfferID:  map[string]int64{},
+		offerID2OrderID:  map[int64]string{},
+		nativeAmendOrder: nativeAmendOrder && canAmend,
+		offerIDStore:     offerIDStore,
 	}
 }
 
@@ -61,9 +92,11 @@ type Operation int8
 const (
 	OpAdd Operation = iota
 	OpCancel
+	OpAmend
 )
 
 // Command struct allows us to follow the Command pattern
+// an amend Command uses cancel for the existing order and add for the order it should become
 type Command struct {
 	op     Operation
 	add    *model.Order
@@ -107,6 +140,15 @@ func MakeCommandCancel(openOrder *model.OpenOrder) Command {
 	}
 }
 
+// MakeCommandAmend impl
+func MakeCommandAmend(openOrder *model.OpenOrder, order *model.Order) Command {
+	return Command{
+		op:     OpAmend,
+		add:    order,
+		cancel: openOrder,
+	}
+}
+
 // GetBalanceHack impl
 func (b BatchedExchange) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
 	modelAsset := model.FromHorizonAsset(asset)
@@ -136,8 +178,13 @@ func (b BatchedExchange) LoadOffersHack() ([]hProtocol.Offer, error) {
 		return nil, fmt.Errorf("error fetching open orders in LoadOffersHack: %s", e)
 	}
 
+	openOrderIDs := map[string]bool{}
+	numOfferIDs := len(b.offerID2OrderID)
 	offers := []hProtocol.Offer{}
 	for i, v := range openOrders {
+		for _, o := range v {
+			openOrderIDs[o.ID] = true
+		}
 		var offers1 []hProtocol.Offer
 		offers1, e = b.OpenOrders2Offers(v, b.baseAsset, b.quoteAsset, b.tradingAccount)
 		if e != nil {
@@ -145,9 +192,81 @@ func (b BatchedExchange) LoadOffersHack() ([]hProtocol.Offer, error) {
 		}
 		offers = append(offers, offers1...)
 	}
+
+	// forget the IDs of orders that are no longer open so the mapping does not grow forever
+	numNewOfferIDs := len(b.offerID2OrderID) - numOfferIDs
+	numRemovedOfferIDs := 0
+	for offerID, orderID := range b.offerID2OrderID {
+		if !openOrderIDs[orderID] {
+			delete(b.offerID2OrderID, offerID)
+			delete(b.orderID2OfferID, orderID)
+			numRemovedOfferIDs++
+		}
+	}
+	if numNewOfferIDs > 0 || numRemovedOfferIDs > 0 {
+		b.saveOfferIDs()
+	}
 	return offers, nil
 }
 
+// ReconcileOfferIDs restores the saved offerIDs of orders that are still open on the exchange, which should be called once on startup.
+// Open orders without a saved offerID get a new one the next time offers are loaded and saved offerIDs of orders that are no longer open are dropped.
+func (b BatchedExchange) ReconcileOfferIDs() error {
+	if b.offerIDStore == nil {
+		return nil
+	}
+
+	savedOfferID2OrderID, e := b.offerIDStore.Load()
+	if e != nil {
+		return fmt.Errorf("could not load saved offer IDs: %s", e)
+	}
+
+	pair := &model.TradingPair{
+		Base:  model.FromHorizonAsset(b.baseAsset),
+		Quote: model.FromHorizonAsset(b.quoteAsset),
+	}
+	openOrders, e := b.GetOpenOrders([]*model.TradingPair{pair})
+	if e != nil {
+		return fmt.Errorf("error fetching open orders to reconcile offer IDs: %s", e)
+	}
+	openOrderIDs := map[string]bool{}
+	for _, v := range openOrders {
+		for _, o := range v {
+			openOrderIDs[o.ID] = true
+		}
+	}
+
+	numRestored := 0
+	for offerID, orderID := range savedOfferID2OrderID {
+		if !openOrderIDs[orderID] {
+			continue
+		}
+		b.offerID2OrderID[offerID] = orderID
+		b.orderID2OfferID[orderID] = offerID
+		numRestored++
+	}
+	log.Printf("reconciled offer IDs with open orders: restored=%d, droppedSaved=%d, unknownOpen=%d\n",
+		numRestored, len(savedOfferID2OrderID)-numRestored, len(openOrderIDs)-numRestored)
+
+	e = b.offerIDStore.Save(b.offerID2OrderID)
+	if e != nil {
+		return fmt.Errorf("could not save reconciled offer IDs: %s", e)
+	}
+	return nil
+}
+
+// saveOfferIDs only logs errors because failing to save should not stop the bot from trading
+func (b BatchedExchange) saveOfferIDs() {
+	if b.offerIDStore == nil {
+		return
+	}
+
+	e := b.offerIDStore.Save(b.offerID2OrderID)
+	if e != nil {
+		log.Printf("error saving offer IDs, they will not survive a restart: %s\n", e)
+	}
+}
+
 // GetOrderConstraints impl
 func (b BatchedExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
 	return b.inner.GetOrderConstraints(pair)
@@ -199,27 +318,14 @@ func (b BatchedExchange) SubmitOps(opsOld []build.TransactionMutator, submitMode
 		return nil
 	}
 
-	results := []submitResult{}
-	numProcessed := 0
-	for _, c := range b.commands {
-		r := c.exec(b.inner, submitMode)
-		if r == nil {
-			// remove all processed commands
-			// b.commands = b.commands[numProcessed:]
-			b.logResults(results)
-			e := fmt.Errorf("unrecognized operation '%v', stopped submitting", c.op)
-			if asyncCallback != nil {
-				go asyncCallback("", e)
-			}
-			return e
+	results, e := b.execCommands(b.commands, submitMode)
+	if e != nil {
+		if asyncCallback != nil {
+			go asyncCallback("", e)
 		}
-		results = append(results, *r)
-		numProcessed++
+		return e
 	}
 
-	// remove all processed commands
-	// b.commands = b.commands[numProcessed:]
-
 	b.logResults(results)
 	if asyncCallback != nil {
 		go asyncCallback("", nil)
@@ -227,6 +333,262 @@ func (b BatchedExchange) SubmitOps(opsOld []build.TransactionMutator, submitMode
 	return nil
 }
 
+// execCommands runs all cancels, then all amends, then all adds so funds are released before new orders are placed.
+// It uses the inner exchange's BatchTradeAPI when available and otherwise makes the individual calls in parallel.
+func (b BatchedExchange) execCommands(commands []Command, submitMode api.SubmitMode) ([]submitResult, error) {
+	cancels := []*model.OpenOrder{}
+	amends := []Command{}
+	adds := []*model.Order{}
+	for _, c := range commands {
+		switch c.op {
+		case OpCancel:
+			cancels = append(cancels, c.cancel)
+		case OpAmend:
+			amends = append(amends, c)
+		case OpAdd:
+			adds = append(adds, c.add)
+		default:
+			return nil, fmt.Errorf("unrecognized operation '%v', did not submit any commands", c.op)
+		}
+	}
+
+	pair := model.TradingPair{
+		Base:  model.FromHorizonAsset(b.baseAsset),
+		Quote: model.FromHorizonAsset(b.quoteAsset),
+	}
+	results := []submitResult{}
+	if len(cancels) > 0 {
+		for _, r := range b.cancelOrders(cancels, pair) {
+			cancelResult := r.Result
+			results = append(results, submitResult{
+				op:     OpCancel,
+				e:      r.Error,
+				cancel: &cancelResult,
+			})
+		}
+	}
+	if len(amends) > 0 {
+		for _, r := range b.amendOrders(amends, submitMode) {
+			results = append(results, submitResult{
+				op:  OpAmend,
+				e:   r.Error,
+				add: r.TxID,
+			})
+		}
+	}
+	if len(adds) > 0 {
+		for _, r := range b.addOrders(adds, submitMode) {
+			results = append(results, submitResult{
+				op:  OpAdd,
+				e:   r.Error,
+				add: r.TxID,
+			})
+		}
+	}
+	return results, nil
+}
+
+func (b BatchedExchange) cancelOrders(cancels []*model.OpenOrder, pair model.TradingPair) []api.BatchCancelResult {
+	txIDs := []*model.TransactionID{}
+	for _, c := range cancels {
+		txIDs = append(txIDs, model.MakeTransactionID(c.ID))
+	}
+
+	batchAPI, ok := b.inner.(api.BatchTradeAPI)
+	if !ok {
+		return cancelOrdersParallel(b.inner, txIDs, pair)
+	}
+
+	if len(txIDs) > 1 && b.cancelsAllOpenOrders(txIDs, pair) {
+		log.Printf("cancelling all %d open orders on %s with a single cancel-all request\n", len(txIDs), pair.String())
+		e := batchAPI.CancelAllOrders(pair)
+		return makeBatchCancelResults(len(txIDs), e)
+	}
+
+	results, e := batchAPI.CancelOrders(txIDs, pair)
+	if e != nil {
+		return makeBatchCancelResults(len(txIDs), fmt.Errorf("batch cancel failed: %s", e))
+	}
+	return results
+}
+
+// cancelsAllOpenOrders returns true if the txIDs are exactly the orders that are open on the exchange right now. The open orders are
+// fetched again instead of using the ones from LoadOffersHack because orders could have been placed on the trading pair since then
+// (e.g. manually or by another bot on the same account), which a cancel-all would also cancel.
+func (b BatchedExchange) cancelsAllOpenOrders(txIDs []*model.TransactionID, pair model.TradingPair) bool {
+	openOrders, e := b.inner.GetOpenOrders([]*model.TradingPair{&pair})
+	if e != nil {
+		log.Printf("could not fetch open orders to check for a cancel-all, cancelling orders individually: %s\n", e)
+		return false
+	}
+
+	openOrderIDs := map[string]bool{}
+	for _, o := range openOrders[pair] {
+		openOrderIDs[o.ID] = true
+	}
+	if len(txIDs) != len(openOrderIDs) {
+		return false
+	}
+	for _, txID := range txIDs {
+		if !openOrderIDs[txID.String()] {
+			return false
+		}
+	}
+	return true
+}
+
+// addOrders sets a clientOrderID on the orders when the inner exchange can look orders up by it, so failed adds can be checked against the exchange
+func (b BatchedExchange) addOrders(orders []*model.Order, submitMode api.SubmitMode) []api.BatchAddResult {
+	lookupAPI, canLookup := b.inner.(api.ClientOrderIDTradeAPI)
+	if canLookup {
+		for _, o := range orders {
+			if o.ClientOrderID == "" {
+				o.ClientOrderID = model.MakeClientOrderID()
+			}
+		}
+	}
+
+	results := b.submitAdds(orders, submitMode)
+	if canLookup {
+		reconcileFailedAdds(lookupAPI, orders, results)
+	}
+	return results
+}
+
+func (b BatchedExchange) submitAdds(orders []*model.Order, submitMode api.SubmitMode) []api.BatchAddResult {
+	batchAPI, ok := b.inner.(api.BatchTradeAPI)
+	if !ok {
+		return addOrdersParallel(b.inner, orders, submitMode)
+	}
+
+	results, e := batchAPI.AddOrders(orders, submitMode)
+	if e != nil {
+		e = fmt.Errorf("batch add failed: %s", e)
+		results = []api.BatchAddResult{}
+		for range orders {
+			results = append(results, api.BatchAddResult{Error: e})
+		}
+	}
+	return results
+}
+
+// amendOrders amends the orders in parallel and points the offerIDs of the amended orders to their new orderIDs
+func (b BatchedExchange) amendOrders(amends []Command, submitMode api.SubmitMode) []api.BatchAddResult {
+	results := make([]api.BatchAddResult, len(amends))
+	amendAPI, ok := b.inner.(api.AmendOrderAPI)
+	if !ok {
+		// this should never happen because nativeAmendOrder is only set when the inner exchange implements AmendOrderAPI
+		e := fmt.Errorf("exchange does not implement AmendOrderAPI")
+		for i := range results {
+			results[i] = api.BatchAddResult{Error: e}
+		}
+		return results
+	}
+
+	runParallel(len(amends), maxParallelRequestsFor(b.inner), func(i int) {
+		txID, e := amendAPI.AmendOrder(model.MakeTransactionID(amends[i].cancel.ID), amends[i].add, submitMode)
+		results[i] = api.BatchAddResult{TxID: txID, Error: e}
+	})
+
+	// update the maps after all the requests complete because they are not safe for concurrent writes
+	numUpdated := 0
+	for i, r := range results {
+		oldOrderID := amends[i].cancel.ID
+		if r.Error != nil || r.TxID == nil || r.TxID.String() == oldOrderID {
+			continue
+		}
+		offerID, ok := b.orderID2OfferID[oldOrderID]
+		if !ok {
+			continue
+		}
+		delete(b.orderID2OfferID, oldOrderID)
+		b.orderID2OfferID[r.TxID.String()] = offerID
+		b.offerID2OrderID[offerID] = r.TxID.String()
+		numUpdated++
+	}
+	if numUpdated > 0 {
+		b.saveOfferIDs()
+	}
+	return results
+}
+
+// reconcileFailedAdds looks up the orders whose add failed by their clientOrderID, since the order may have been placed even
+// though we did not get a response (e.g. a timeout). Orders that are found are marked as successful so they are not treated as missing.
+func reconcileFailedAdds(lookupAPI api.ClientOrderIDTradeAPI, orders []*model.Order, results []api.BatchAddResult) {
+	runParallel(len(results), maxParallelRequestsFor(lookupAPI), func(i int) {
+		if results[i].Error == nil || orders[i].ClientOrderID == "" {
+			return
+		}
+
+		openOrder, e := lookupAPI.GetOpenOrderByClientID(orders[i].Pair, orders[i].ClientOrderID)
+		if e != nil {
+			log.Printf("could not look up order with clientOrderID '%s' after failed add, assuming it was not placed: %s\n", orders[i].ClientOrderID, e)
+			return
+		}
+		if openOrder == nil {
+			log.Printf("order with clientOrderID '%s' was not placed on the exchange\n", orders[i].ClientOrderID)
+			return
+		}
+
+		log.Printf("order with clientOrderID '%s' was placed on the exchange with ID '%s' even though adding it failed (%s)\n", orders[i].ClientOrderID, openOrder.ID, results[i].Error)
+		results[i] = api.BatchAddResult{TxID: model.MakeTransactionID(openOrder.ID)}
+	})
+}
+
+// makeBatchCancelResults makes the same result for every order in a batch, which is a success if e is nil
+func makeBatchCancelResults(n int, e error) []api.BatchCancelResult {
+	r := api.BatchCancelResult{Result: model.CancelResultCancelSuccessful}
+	if e != nil {
+		r = api.BatchCancelResult{Result: model.CancelResultFailed, Error: e}
+	}
+
+	results := []api.BatchCancelResult{}
+	for i := 0; i < n; i++ {
+		results = append(results, r)
+	}
+	return results
+}
+
+// runParallel calls fn for every index in [0, n) with at most maxParallel calls running at the same time
+func runParallel(n int, maxParallel int, fn func(i int)) {
+	semaphore := make(chan bool, maxParallel)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- true
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// addOrdersParallel adds the orders with individual AddOrder calls made in parallel, for exchanges without a batch endpoint.
+// The calls are made one at a time for exchanges that implement serialTradeAPI.
+func addOrdersParallel(x api.TradeAPI, orders []*model.Order, submitMode api.SubmitMode) []api.BatchAddResult {
+	results := make([]api.BatchAddResult, len(orders))
+	runParallel(len(orders), maxParallelRequestsFor(x), func(i int) {
+		txID, e := x.AddOrder(orders[i], submitMode)
+		results[i] = api.BatchAddResult{TxID: txID, Error: e}
+	})
+	return results
+}
+
+// cancelOrdersParallel cancels the orders with individual CancelOrder calls made in parallel, for exchanges without a batch endpoint.
+// The calls are made one at a time for exchanges that implement serialTradeAPI.
+func cancelOrdersParallel(x api.TradeAPI, txIDs []*model.TransactionID, pair model.TradingPair) []api.BatchCancelResult {
+	results := make([]api.BatchCancelResult, len(txIDs))
+	runParallel(len(txIDs), maxParallelRequestsFor(x), func(i int) {
+		result, e := x.CancelOrder(txIDs[i], pair)
+		results[i] = api.BatchCancelResult{Result: result, Error: e}
+	})
+	return results
+}
+
 func (b BatchedExchange) logResults(results []submitResult) {
 	log.Printf("Results from submitting:\n")
 	for _, r := range results {
@@ -236,6 +598,8 @@ func (b BatchedExchange) logResults(results []submitResult) {
 		if r.op == OpCancel {
 			opString = "cancel"
 			v = r.cancel
+		} else if r.op == OpAmend {
+			opString = "amend"
 		}
 
 		errorSuffix := ""
@@ -246,27 +610,6 @@ func (b BatchedExchange) logResults(results []submitResult) {
 	}
 }
 
-func (c Command) exec(x api.Exchange, submitMode api.SubmitMode) *submitResult {
-	switch c.op {
-	case OpAdd:
-		v, e := x.AddOrder(c.add, submitMode)
-		return &submitResult{
-			op:  c.op,
-			e:   e,
-			add: v,
-		}
-	case OpCancel:
-		v, e := x.CancelOrder(model.MakeTransactionID(c.cancel.ID), *c.cancel.Pair)
-		return &submitResult{
-			op:     c.op,
-			e:      e,
-			cancel: &v,
-		}
-	default:
-		return nil
-	}
-}
-
 // GetAccountBalances impl.
 func (b BatchedExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
 	return b.inner.GetAccountBalances(assetList)
@@ -425,7 +768,7 @@ func (b BatchedExchange) Ops2Commands(ops []txnbuild.Operation, baseAsset hProto
 		Base:  model.FromHorizonAsset(baseAsset),
 		Quote: model.FromHorizonAsset(quoteAsset),
 	}
-	return Ops2CommandsHack(ops, baseAsset, quoteAsset, b.offerID2OrderID, b.inner.GetOrderConstraints(pair))
+	return Ops2CommandsHack(ops, baseAsset, quoteAsset, b.offerID2OrderID, b.inner.GetOrderConstraints(pair), b.nativeAmendOrder)
 }
 
 // Ops2CommandsHack converts...
@@ -435,12 +778,13 @@ func Ops2CommandsHack(
 	quoteAsset hProtocol.Asset,
 	offerID2OrderID map[int64]string, // if map is nil then we ignore ID errors
 	orderConstraints *model.OrderConstraints,
+	nativeAmendOrder bool, // modify offers are converted to a single amend Command if this is true
 ) ([]Command, error) {
 	commands := []Command{}
 	for _, op := range ops {
 		switch manageOffer := op.(type) {
 		case *txnbuild.ManageSellOffer:
-			c, e := op2CommandsHack(manageOffer, baseAsset, quoteAsset, offerID2OrderID, orderConstraints)
+			c, e := op2CommandsHack(manageOffer, baseAsset, quoteAsset, offerID2OrderID, orderConstraints, nativeAmendOrder)
 			if e != nil {
 				return nil, fmt.Errorf("unable to convert *txnbuild.ManageSellOffer to a Command: %s", e)
 			}
@@ -459,6 +803,7 @@ func op2CommandsHack(
 	quoteAsset hProtocol.Asset,
 	offerID2OrderID map[int64]string, // if map is nil then we ignore ID errors
 	orderConstraints *model.OrderConstraints,
+	nativeAmendOrder bool,
 ) ([]Command, error) {
 	commands := []Command{}
 	order, e := manageOffer2Order(manageOffer, baseAsset, quoteAsset, orderConstraints)
@@ -489,8 +834,7 @@ func op2CommandsHack(
 		openOrder := order2OpenOrder(order, txID)
 		commands = append(commands, MakeCommandCancel(openOrder))
 	} else if manageOffer.OfferID != 0 {
-		// modify is cancel followed by create
-		// -- cancel
+		// modify is an amend when the exchange supports it natively, otherwise it is a cancel followed by create
 		// fetch real orderID here (hoops we have to jump through because of the hacked approach to using centralized exchanges)
 		var orderID string
 		if offerID2OrderID != nil {
@@ -505,9 +849,12 @@ func op2CommandsHack(
 		}
 		txID := model.MakeTransactionID(orderID)
 		openOrder := order2OpenOrder(order, txID)
-		commands = append(commands, MakeCommandCancel(openOrder))
-		// -- create
-		commands = append(commands, MakeCommandAdd(order))
+		if nativeAmendOrder {
+			commands = append(commands, MakeCommandAmend(openOrder, order))
+		} else {
+			commands = append(commands, MakeCommandCancel(openOrder))
+			commands = append(commands, MakeCommandAdd(order))
+		}
 	} else {
 		// create
 		commands = append(commands, MakeCommandAdd(order))
@@ -0,0 +1,505 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+var testBatchedPair = model.MakeTradingPair(model.XLM, model.USDT)
+var testBatchedQuoteAsset = hProtocol.Asset{
+	Type:   "credit_alphanum4",
+	Code:   "USDT",
+	Issuer: "GCQTGZQQ5G4PTM2GL7CDIFKUBIPEC52BROAQIAPW53XBRJVN6ZJVTG6V",
+}
+
+// recordingExchange is a fake exchange that records the order of the trade calls made on it
+type recordingExchange struct {
+	api.Exchange // only the methods below are implemented, any other call will panic
+
+	mutex      *sync.Mutex
+	calls      []string
+	openOrders []model.OpenOrder
+	nextID     int
+}
+
+var _ api.Exchange = &recordingExchange{}
+
+func makeRecordingExchange(openOrderIDs ...string) *recordingExchange {
+	openOrders := []model.OpenOrder{}
+	for _, id := range openOrderIDs {
+		openOrders = append(openOrders, model.OpenOrder{
+			Order: model.Order{
+				Pair:        testBatchedPair,
+				OrderAction: model.OrderActionSell,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(0.1, 5),
+				Volume:      model.NumberFromFloat(100.0, 1),
+			},
+			ID:             id,
+			VolumeExecuted: model.NumberFromFloat(0.0, 1),
+		})
+	}
+	return &recordingExchange{
+		mutex:      &sync.Mutex{},
+		calls:      []string{},
+		openOrders: openOrders,
+	}
+}
+
+func (x *recordingExchange) record(call string) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.calls = append(x.calls, call)
+}
+
+func (x *recordingExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	return map[model.TradingPair][]model.OpenOrder{*testBatchedPair: x.openOrders}, nil
+}
+
+func (x *recordingExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	return model.MakeOrderConstraints(5, 1, 0.1)
+}
+
+func (x *recordingExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	x.mutex.Lock()
+	x.nextID++
+	id := fmt.Sprintf("new%d", x.nextID)
+	x.mutex.Unlock()
+
+	x.record("add")
+	return model.MakeTransactionID(id), nil
+}
+
+func (x *recordingExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	x.record("cancel " + txID.String())
+	return model.CancelResultCancelSuccessful, nil
+}
+
+func (x *recordingExchange) AmendOrder(txID *model.TransactionID, order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	x.record("amend " + txID.String())
+	return model.MakeTransactionID(txID.String() + "-amended"), nil
+}
+
+// recordingBatchExchange is a recordingExchange that also supports batch requests
+type recordingBatchExchange struct {
+	*recordingExchange
+}
+
+var _ api.BatchTradeAPI = &recordingBatchExchange{}
+
+func (x *recordingBatchExchange) AddOrders(orders []*model.Order, submitMode api.SubmitMode) ([]api.BatchAddResult, error) {
+	x.record(fmt.Sprintf("batchAdd %d", len(orders)))
+	results := []api.BatchAddResult{}
+	for i := range orders {
+		results = append(results, api.BatchAddResult{TxID: model.MakeTransactionID(fmt.Sprintf("batch%d", i))})
+	}
+	return results, nil
+}
+
+func (x *recordingBatchExchange) CancelOrders(txIDs []*model.TransactionID, pair model.TradingPair) ([]api.BatchCancelResult, error) {
+	x.record(fmt.Sprintf("batchCancel %d", len(txIDs)))
+	return makeBatchCancelResults(len(txIDs), nil), nil
+}
+
+func (x *recordingBatchExchange) CancelAllOrders(pair model.TradingPair) error {
+	x.record("cancelAll")
+	return nil
+}
+
+// serialExchange is a recordingExchange that requires serial requests and records the most requests that were in flight at once
+type serialExchange struct {
+	*recordingExchange
+	inFlight    int
+	maxInFlight int
+}
+
+var _ serialTradeAPI = &serialExchange{}
+
+func (x *serialExchange) requiresSerialRequests() bool {
+	return true
+}
+
+func (x *serialExchange) track(fn func()) {
+	x.mutex.Lock()
+	x.inFlight++
+	if x.inFlight > x.maxInFlight {
+		x.maxInFlight = x.inFlight
+	}
+	x.mutex.Unlock()
+
+	// give other requests a chance to start while this one is in flight
+	time.Sleep(time.Millisecond)
+	fn()
+
+	x.mutex.Lock()
+	x.inFlight--
+	x.mutex.Unlock()
+}
+
+func (x *serialExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (txID *model.TransactionID, e error) {
+	x.track(func() { txID, e = x.recordingExchange.AddOrder(order, submitMode) })
+	return txID, e
+}
+
+func (x *serialExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (result model.CancelOrderResult, e error) {
+	x.track(func() { result, e = x.recordingExchange.CancelOrder(txID, pair) })
+	return result, e
+}
+
+// timeoutExchange is a recordingExchange where every AddOrder times out, optionally after the order was placed
+type timeoutExchange struct {
+	*recordingExchange
+	placeOnTimeout bool
+}
+
+var _ api.ClientOrderIDTradeAPI = &timeoutExchange{}
+
+func (x *timeoutExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	x.record("add " + order.ClientOrderID)
+	if x.placeOnTimeout {
+		x.mutex.Lock()
+		x.openOrders = append(x.openOrders, model.OpenOrder{Order: *order, ID: "placed" + order.ClientOrderID})
+		x.mutex.Unlock()
+	}
+	return nil, fmt.Errorf("timed out")
+}
+
+func (x *timeoutExchange) GetOpenOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	for _, o := range x.openOrders {
+		if o.ClientOrderID == clientOrderID {
+			openOrder := o
+			return &openOrder, nil
+		}
+	}
+	return nil, nil
+}
+
+// memoryOfferIDStore is an OfferIDStore that keeps a copy of the saved mapping in memory
+type memoryOfferIDStore struct {
+	saved map[int64]string
+}
+
+func (s *memoryOfferIDStore) Load() (map[int64]string, error) {
+	m := map[int64]string{}
+	for k, v := range s.saved {
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (s *memoryOfferIDStore) Save(offerID2OrderID map[int64]string) error {
+	s.saved = map[int64]string{}
+	for k, v := range offerID2OrderID {
+		s.saved[k] = v
+	}
+	return nil
+}
+
+func makeTestBatchedExchange(inner api.Exchange, nativeAmendOrder bool) *BatchedExchange {
+	return MakeBatchedExchange(inner, false, utils.NativeAsset, testBatchedQuoteAsset, "", nativeAmendOrder, nil)
+}
+
+func makeTestCommands(cancelIDs []string, numAdds int) []Command {
+	commands := []Command{}
+	// interleave cancels and adds to check that all cancels are executed first
+	for i := 0; i < len(cancelIDs) || i < numAdds; i++ {
+		if i < numAdds {
+			commands = append(commands, Command{
+				op: OpAdd,
+				add: &model.Order{
+					Pair:        testBatchedPair,
+					OrderAction: model.OrderActionBuy,
+					OrderType:   model.OrderTypeLimit,
+					Price:       model.NumberFromFloat(0.09, 5),
+					Volume:      model.NumberFromFloat(100.0, 1),
+				},
+			})
+		}
+		if i < len(cancelIDs) {
+			commands = append(commands, Command{
+				op:     OpCancel,
+				cancel: &model.OpenOrder{Order: model.Order{Pair: testBatchedPair}, ID: cancelIDs[i]},
+			})
+		}
+	}
+	return commands
+}
+
+func TestExecCommands_Parallel(t *testing.T) {
+	inner := makeRecordingExchange("1", "2", "3")
+	b := makeTestBatchedExchange(inner, false)
+
+	results, e := b.execCommands(makeTestCommands([]string{"1", "2"}, 3), api.SubmitModeBoth)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 5, len(results)) {
+		return
+	}
+	for i, r := range results {
+		assert.NoError(t, r.e)
+		if i < 2 {
+			assert.Equal(t, OpCancel, r.op)
+			assert.Equal(t, model.CancelResultCancelSuccessful, *r.cancel)
+		} else {
+			assert.Equal(t, OpAdd, r.op)
+			assert.NotNil(t, r.add)
+		}
+	}
+
+	// the parallel calls can complete in any order, but all cancels must come before any add
+	cancels := inner.calls[:2]
+	sort.Strings(cancels)
+	assert.Equal(t, []string{"cancel 1", "cancel 2"}, cancels)
+	assert.Equal(t, []string{"add", "add", "add"}, inner.calls[2:])
+}
+
+func TestExecCommands_Serial(t *testing.T) {
+	inner := &serialExchange{recordingExchange: makeRecordingExchange("1", "2", "3")}
+	b := makeTestBatchedExchange(inner, false)
+
+	results, e := b.execCommands(makeTestCommands([]string{"1", "2", "3"}, 4), api.SubmitModeBoth)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 7, len(results))
+	assert.Equal(t, 7, len(inner.calls))
+	assert.Equal(t, 1, inner.maxInFlight, "requests to an exchange that requires serial requests should never be in flight at the same time")
+}
+
+func TestExecCommands_Batch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		cancelIDs      []string
+		openedSinceIDs []string
+		wantCalls      []string
+	}{
+		{
+			name:      "some open orders",
+			cancelIDs: []string{"1", "2"},
+			wantCalls: []string{"batchCancel 2", "batchAdd 2"},
+		}, {
+			name:      "all open orders",
+			cancelIDs: []string{"3", "1", "2"},
+			wantCalls: []string{"cancelAll", "batchAdd 2"},
+		}, {
+			name:           "orders opened since loading offers",
+			cancelIDs:      []string{"3", "1", "2"},
+			openedSinceIDs: []string{"4"},
+			wantCalls:      []string{"batchCancel 3", "batchAdd 2"},
+		}, {
+			name:      "unknown order",
+			cancelIDs: []string{"1", "2", "4"},
+			wantCalls: []string{"batchCancel 3", "batchAdd 2"},
+		}, {
+			name:      "only adds",
+			cancelIDs: []string{},
+			wantCalls: []string{"batchAdd 2"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			inner := &recordingBatchExchange{makeRecordingExchange("1", "2", "3")}
+			b := makeTestBatchedExchange(inner, false)
+			_, e := b.LoadOffersHack()
+			if !assert.NoError(t, e) {
+				return
+			}
+			// a cancel-all must not cancel orders that were opened on the exchange after the offers were loaded
+			inner.openOrders = append(inner.openOrders, makeRecordingExchange(k.openedSinceIDs...).openOrders...)
+
+			results, e := b.execCommands(makeTestCommands(k.cancelIDs, 2), api.SubmitModeBoth)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, len(k.cancelIDs)+2, len(results))
+			assert.Equal(t, k.wantCalls, inner.calls)
+		})
+	}
+}
+
+func TestExecCommands_UnknownOp(t *testing.T) {
+	inner := makeRecordingExchange()
+	b := makeTestBatchedExchange(inner, false)
+
+	commands := append(makeTestCommands([]string{"1"}, 1), Command{op: Operation(100)})
+	_, e := b.execCommands(commands, api.SubmitModeBoth)
+	assert.Error(t, e)
+	assert.Equal(t, 0, len(inner.calls), "no commands should be submitted when any of them is invalid")
+}
+
+func TestOps2Commands_Modify(t *testing.T) {
+	testCases := []struct {
+		nativeAmendOrder bool
+		canAmend         bool
+		wantOps          []Operation
+	}{
+		{false, true, []Operation{OpCancel, OpAdd}},
+		{true, true, []Operation{OpAmend}},
+		// exchanges that do not implement AmendOrderAPI fall back to a cancel and an add even when flagged
+		{true, false, []Operation{OpCancel, OpAdd}},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("nativeAmendOrder=%v,canAmend=%v", k.nativeAmendOrder, k.canAmend), func(t *testing.T) {
+			var inner api.Exchange = makeRecordingExchange()
+			if !k.canAmend {
+				// only the methods of api.Exchange are promoted so this hides AmendOrder
+				inner = struct{ api.Exchange }{inner}
+			}
+			b := makeTestBatchedExchange(inner, k.nativeAmendOrder)
+			b.offerID2OrderID[5] = "1"
+			b.orderID2OfferID["1"] = 5
+
+			commands, e := b.Ops2Commands([]txnbuild.Operation{&txnbuild.ManageSellOffer{
+				Selling: txnbuild.NativeAsset{},
+				Buying:  txnbuild.CreditAsset{Code: testBatchedQuoteAsset.Code, Issuer: testBatchedQuoteAsset.Issuer},
+				Amount:  "100.0",
+				Price:   "0.12",
+				OfferID: 5,
+			}}, utils.NativeAsset, testBatchedQuoteAsset)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			ops := []Operation{}
+			for _, c := range commands {
+				ops = append(ops, c.GetOp())
+				if c.op != OpAdd {
+					assert.Equal(t, "1", c.cancel.ID)
+				}
+				if c.op != OpCancel {
+					assert.Equal(t, "0.12000", c.add.Price.AsString())
+				}
+			}
+			assert.Equal(t, k.wantOps, ops)
+		})
+	}
+}
+
+func TestExecCommands_Amend(t *testing.T) {
+	inner := makeRecordingExchange("1", "2")
+	b := makeTestBatchedExchange(inner, true)
+	_, e := b.LoadOffersHack()
+	if !assert.NoError(t, e) {
+		return
+	}
+	offerID := b.orderID2OfferID["2"]
+
+	commands := makeTestCommands([]string{"1"}, 1)
+	commands = append(commands, MakeCommandAmend(
+		&model.OpenOrder{Order: model.Order{Pair: testBatchedPair}, ID: "2"},
+		&model.Order{
+			Pair:        testBatchedPair,
+			OrderAction: model.OrderActionSell,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(0.12, 5),
+			Volume:      model.NumberFromFloat(100.0, 1),
+		},
+	))
+	results, e := b.execCommands(commands, api.SubmitModeBoth)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.Equal(t, []string{"cancel 1", "amend 2", "add"}, inner.calls)
+	if assert.Equal(t, 3, len(results)) {
+		assert.Equal(t, OpAmend, results[1].op)
+		assert.Equal(t, "2-amended", results[1].add.String())
+	}
+
+	// the offer keeps its ID on the SDEX side while pointing to the new order on the exchange
+	assert.Equal(t, "2-amended", b.offerID2OrderID[offerID])
+	assert.Equal(t, offerID, b.orderID2OfferID["2-amended"])
+	_, ok := b.orderID2OfferID["2"]
+	assert.False(t, ok)
+}
+
+func TestReconcileOfferIDs(t *testing.T) {
+	// order "1" is still open, order "9" was filled or cancelled while the bot was not running and order "2" was placed outside the bot
+	store := &memoryOfferIDStore{saved: map[int64]string{5: "1", 6: "9"}}
+	inner := makeRecordingExchange("1", "2")
+	b := MakeBatchedExchange(inner, false, utils.NativeAsset, testBatchedQuoteAsset, "", false, store)
+
+	e := b.ReconcileOfferIDs()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[int64]string{5: "1"}, b.offerID2OrderID)
+	assert.Equal(t, map[string]int64{"1": 5}, b.orderID2OfferID)
+	assert.Equal(t, map[int64]string{5: "1"}, store.saved)
+
+	// the restored order keeps its offerID and the unknown order gets a new one which is saved
+	offers, e := b.LoadOffersHack()
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(offers)) {
+		return
+	}
+	assert.Equal(t, int64(5), offers[0].ID)
+	assert.Equal(t, 2, len(store.saved))
+	assert.Equal(t, "2", store.saved[offers[1].ID])
+
+	// orders that are no longer open are forgotten
+	inner.openOrders = inner.openOrders[1:]
+	_, e = b.LoadOffersHack()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[int64]string{offers[1].ID: "2"}, store.saved)
+	_, ok := b.orderID2OfferID["1"]
+	assert.False(t, ok)
+}
+
+func TestExecCommands_ReconcileFailedAdds(t *testing.T) {
+	testCases := []struct {
+		name           string
+		placeOnTimeout bool
+	}{
+		{"placed", true},
+		{"not placed", false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			inner := &timeoutExchange{recordingExchange: makeRecordingExchange(), placeOnTimeout: k.placeOnTimeout}
+			b := makeTestBatchedExchange(inner, false)
+
+			commands := makeTestCommands([]string{}, 2)
+			results, e := b.execCommands(commands, api.SubmitModeBoth)
+			if !assert.NoError(t, e) || !assert.Equal(t, 2, len(results)) {
+				return
+			}
+
+			for i, r := range results {
+				clientOrderID := commands[i].add.ClientOrderID
+				if !assert.NotEqual(t, "", clientOrderID) {
+					continue
+				}
+				if k.placeOnTimeout {
+					assert.NoError(t, r.e)
+					assert.Equal(t, "placed"+clientOrderID, r.add.String())
+				} else {
+					assert.Error(t, r.e)
+					assert.Nil(t, r.add)
+				}
+			}
+			assert.NotEqual(t, commands[0].add.ClientOrderID, commands[1].add.ClientOrderID)
+		})
+	}
+}
@@ -0,0 +1,893 @@
+package plugins
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// ensure that binanceExchange conforms to the Exchange, BatchTradeAPI and AmendOrderAPI interfaces
+var _ api.Exchange = &binanceExchange{}
+var _ api.BatchTradeAPI = &binanceExchange{}
+var _ api.AmendOrderAPI = &binanceExchange{}
+
+const binanceBaseURL = "https://api.binance.com"
+const binanceRecvWindowMillis = 5000
+const binanceTradesLimit = 1000
+
+// binanceTradeHistoryWindow is the largest window of time Binance returns trades for when queried by startTime
+const binanceTradeHistoryWindow = 24 * time.Hour
+
+// binanceExchange is the implementation for the Binance Exchange that talks to the Binance REST API directly instead of via ccxt-rest
+type binanceExchange struct {
+	baseURL            string
+	httpClient         *http.Client
+	apiKey             api.ExchangeAPIKey
+	assetConverter     model.AssetConverterInterface
+	delimiter          string
+	ocOverridesHandler *OrderConstraintsOverridesHandler
+	isSimulated        bool // will simulate add and cancel orders if this is true
+
+	// order constraints of all symbols are loaded from the exchangeInfo endpoint when the exchange is made and not modified afterwards
+	ocBySymbol map[string]*model.OrderConstraints
+}
+
+// makeBinanceExchange is a factory method to make the binance exchange
+func makeBinanceExchange(apiKeys []api.ExchangeAPIKey, exchangeParams []api.ExchangeParam, isSimulated bool) (api.Exchange, error) {
+	if len(apiKeys) != 1 {
+		return nil, fmt.Errorf("need exactly 1 ExchangeAPIKey, even if it is an empty key")
+	}
+
+	baseURL := binanceBaseURL
+	for _, p := range exchangeParams {
+		if p.Param != "base_url" {
+			return nil, fmt.Errorf("unsupported exchange param for binance: %s", p.Param)
+		}
+		v, ok := p.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("exchange param 'base_url' for binance needs to be a string, was of type %T", p.Value)
+		}
+		baseURL = strings.TrimSuffix(v, "/")
+	}
+
+	b := &binanceExchange{
+		baseURL:            baseURL,
+		httpClient:         http.DefaultClient,
+		apiKey:             apiKeys[0],
+		assetConverter:     model.BinanceAssetConverter,
+		delimiter:          "",
+		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+		isSimulated:        isSimulated,
+	}
+	e := b.loadAllOrderConstraints()
+	if e != nil {
+		return nil, fmt.Errorf("could not load the order constraints for binance: %s", e)
+	}
+	return b, nil
+}
+
+// publicRequest makes an unauthenticated request to the Binance API
+func (b *binanceExchange) publicRequest(path string, params url.Values, responseData interface{}) error {
+	reqURL := b.baseURL + path
+	if len(params) > 0 {
+		reqURL = reqURL + "?" + params.Encode()
+	}
+	return networking.JSONRequest(b.httpClient, "GET", reqURL, "", map[string]string{}, responseData, "code")
+}
+
+// signedRequest makes a request to the Binance API that is signed with the API secret
+func (b *binanceExchange) signedRequest(method string, path string, params url.Values, responseData interface{}) error {
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10))
+	params.Set("recvWindow", strconv.Itoa(binanceRecvWindowMillis))
+	query := params.Encode()
+	query = query + "&signature=" + binanceSignature(b.apiKey.Secret, query)
+
+	headers := map[string]string{"X-MBX-APIKEY": b.apiKey.Key}
+	return networking.JSONRequest(b.httpClient, method, b.baseURL+path+"?"+query, "", headers, responseData, "code")
+}
+
+// binanceSignature is the hex-encoded HMAC-SHA256 of the query string using the API secret
+func binanceSignature(secret string, query string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(query))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// precisionFromStepSize converts a step size such as "0.00100000" into the number of decimal places it allows
+func precisionFromStepSize(stepSize string) int8 {
+	parts := strings.SplitN(strings.TrimRight(stepSize, "0"), ".", 2)
+	if len(parts) < 2 {
+		return 0
+	}
+	return int8(len(parts[1]))
+}
+
+func (b *binanceExchange) symbol(pair *model.TradingPair) (string, error) {
+	symbol, e := pair.ToString(b.assetConverter, b.delimiter)
+	if e != nil {
+		return "", fmt.Errorf("error converting pair to string: %s", e)
+	}
+	return symbol, nil
+}
+
+type binanceFilter struct {
+	FilterType  string `json:"filterType"`
+	TickSize    string `json:"tickSize"`
+	StepSize    string `json:"stepSize"`
+	MinQty      string `json:"minQty"`
+	MinNotional string `json:"minNotional"`
+}
+
+type binanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol  string          `json:"symbol"`
+		Filters []binanceFilter `json:"filters"`
+	} `json:"symbols"`
+}
+
+// GetOrderConstraints impl
+func (b *binanceExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	if b.ocOverridesHandler.IsCompletelyOverriden(pair) {
+		override := b.ocOverridesHandler.Get(pair)
+		return model.MakeOrderConstraintsFromOverride(override)
+	}
+
+	symbol, e := b.symbol(pair)
+	if e != nil {
+		// this should never really panic because we would have converted this trading pair to a string previously
+		panic(e)
+	}
+	oc, ok := b.ocBySymbol[symbol]
+	if !ok {
+		panic(fmt.Sprintf("binanceExchange could not find orderConstraints for trading pair %v, the symbol '%s' was not listed by the exchangeInfo endpoint", pair, symbol))
+	}
+	return b.ocOverridesHandler.Apply(pair, oc)
+}
+
+// loadAllOrderConstraints fetches the order constraints of all symbols in one request so we never need to make this request (and
+// handle its failure) when GetOrderConstraints is called during an update cycle
+func (b *binanceExchange) loadAllOrderConstraints() error {
+	var info binanceExchangeInfo
+	e := b.publicRequest("/api/v3/exchangeInfo", url.Values{}, &info)
+	if e != nil {
+		return fmt.Errorf("error fetching exchangeInfo: %s", e)
+	}
+	if len(info.Symbols) == 0 {
+		return fmt.Errorf("exchangeInfo did not contain any symbols")
+	}
+
+	ocBySymbol := map[string]*model.OrderConstraints{}
+	for _, s := range info.Symbols {
+		oc, e := parseBinanceOrderConstraints(s.Symbol, s.Filters)
+		if e != nil {
+			return e
+		}
+		ocBySymbol[s.Symbol] = oc
+	}
+	b.ocBySymbol = ocBySymbol
+	return nil
+}
+
+func parseBinanceOrderConstraints(symbol string, filters []binanceFilter) (*model.OrderConstraints, error) {
+	var e error
+	var pricePrecision, volumePrecision int8
+	var minBaseVolume, minQuoteVolume float64
+	for _, f := range filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			pricePrecision = precisionFromStepSize(f.TickSize)
+		case "LOT_SIZE":
+			volumePrecision = precisionFromStepSize(f.StepSize)
+			minBaseVolume, e = strconv.ParseFloat(f.MinQty, 64)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse minQty '%s' for symbol '%s': %s", f.MinQty, symbol, e)
+			}
+		case "MIN_NOTIONAL", "NOTIONAL":
+			minQuoteVolume, e = strconv.ParseFloat(f.MinNotional, 64)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse minNotional '%s' for symbol '%s': %s", f.MinNotional, symbol, e)
+			}
+		}
+	}
+
+	return model.MakeOrderConstraintsWithCost(pricePrecision, volumePrecision, minBaseVolume, minQuoteVolume), nil
+}
+
+// OverrideOrderConstraints impl, can partially override values for specific pairs
+func (b *binanceExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	b.ocOverridesHandler.Upsert(pair, override)
+}
+
+// GetAssetConverter impl.
+func (b *binanceExchange) GetAssetConverter() model.AssetConverterInterface {
+	return b.assetConverter
+}
+
+type binanceBalance struct {
+	Asset  string `json:"asset"`
+	Free   string `json:"free"`
+	Locked string `json:"locked"`
+}
+
+// GetAccountBalances impl.
+func (b *binanceExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	var account struct {
+		Balances []binanceBalance `json:"balances"`
+	}
+	e := b.signedRequest("GET", "/api/v3/account", url.Values{}, &account)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching account from binance: %s", e)
+	}
+
+	balances := map[string]float64{}
+	for _, bal := range account.Balances {
+		free, e := strconv.ParseFloat(bal.Free, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse free balance '%s' for asset %s: %s", bal.Free, bal.Asset, e)
+		}
+		locked, e := strconv.ParseFloat(bal.Locked, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse locked balance '%s' for asset %s: %s", bal.Locked, bal.Asset, e)
+		}
+		balances[bal.Asset] = free + locked
+	}
+
+	m := map[interface{}]model.Number{}
+	for _, elem := range assetList {
+		var asset model.Asset
+		if v, ok := elem.(model.Asset); ok {
+			asset = v
+		} else {
+			return nil, fmt.Errorf("invalid type of asset passed in, only model.Asset accepted")
+		}
+
+		binanceAssetString, e := b.assetConverter.ToString(asset)
+		if e != nil {
+			return nil, e
+		}
+		// assets that were never held are not listed so they have a zero balance
+		m[asset] = *model.NumberFromFloat(balances[binanceAssetString], precisionBalances)
+	}
+	return m, nil
+}
+
+// GetOrderBook impl.
+func (b *binanceExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	symbol, e := b.symbol(pair)
+	if e != nil {
+		return nil, e
+	}
+
+	// binance only accepts a fixed set of limits so we fetch the smallest one that is large enough and truncate
+	fetchLimit := 5000
+	for _, l := range []int{5, 10, 20, 50, 100, 500, 1000} {
+		if int(maxCount) <= l {
+			fetchLimit = l
+			break
+		}
+	}
+
+	var depth struct {
+		Bids [][]string `json:"bids"`
+		Asks [][]string `json:"asks"`
+	}
+	e = b.publicRequest("/api/v3/depth", url.Values{
+		"symbol": []string{symbol},
+		"limit":  []string{strconv.Itoa(fetchLimit)},
+	}, &depth)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching orderbook for trading pair '%s': %s", symbol, e)
+	}
+
+	asks, e := b.readOrders(depth.Asks, int(maxCount), pair, model.OrderActionSell)
+	if e != nil {
+		return nil, fmt.Errorf("error reading asks: %s", e)
+	}
+	bids, e := b.readOrders(depth.Bids, int(maxCount), pair, model.OrderActionBuy)
+	if e != nil {
+		return nil, fmt.Errorf("error reading bids: %s", e)
+	}
+	return model.MakeOrderBook(pair, asks, bids), nil
+}
+
+func (b *binanceExchange) readOrders(levels [][]string, maxCount int, pair *model.TradingPair, orderAction model.OrderAction) ([]model.Order, error) {
+	if len(levels) > maxCount {
+		levels = levels[:maxCount]
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	orders := []model.Order{}
+	for _, level := range levels {
+		if len(level) < 2 {
+			return nil, fmt.Errorf("orderbook level should have a price and a quantity: %v", level)
+		}
+		price, e := model.NumberFromString(level[0], orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", level[0], e)
+		}
+		volume, e := model.NumberFromString(level[1], orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse quantity '%s': %s", level[1], e)
+		}
+
+		orders = append(orders, model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   nil,
+		})
+	}
+	return orders, nil
+}
+
+// GetTickerPrice impl.
+func (b *binanceExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	priceResult := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		pair := p
+		symbol, e := b.symbol(&pair)
+		if e != nil {
+			return nil, e
+		}
+		params := url.Values{"symbol": []string{symbol}}
+
+		var bookTicker struct {
+			BidPrice string `json:"bidPrice"`
+			AskPrice string `json:"askPrice"`
+		}
+		e = b.publicRequest("/api/v3/ticker/bookTicker", params, &bookTicker)
+		if e != nil {
+			return nil, fmt.Errorf("error fetching book ticker for symbol '%s': %s", symbol, e)
+		}
+		var priceTicker struct {
+			Price string `json:"price"`
+		}
+		e = b.publicRequest("/api/v3/ticker/price", params, &priceTicker)
+		if e != nil {
+			return nil, fmt.Errorf("error fetching price ticker for symbol '%s': %s", symbol, e)
+		}
+
+		pricePrecision := b.GetOrderConstraints(&pair).PricePrecision
+		askPrice, e := model.NumberFromString(bookTicker.AskPrice, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse askPrice '%s': %s", bookTicker.AskPrice, e)
+		}
+		bidPrice, e := model.NumberFromString(bookTicker.BidPrice, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse bidPrice '%s': %s", bookTicker.BidPrice, e)
+		}
+		lastPrice, e := model.NumberFromString(priceTicker.Price, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", priceTicker.Price, e)
+		}
+
+		priceResult[pair] = api.Ticker{
+			AskPrice:  askPrice,
+			BidPrice:  bidPrice,
+			LastPrice: lastPrice,
+		}
+	}
+	return priceResult, nil
+}
+
+type binanceTrade struct {
+	Symbol     string `json:"symbol"`
+	ID         int64  `json:"id"`
+	OrderID    int64  `json:"orderId"`
+	Price      string `json:"price"`
+	Qty        string `json:"qty"`
+	QuoteQty   string `json:"quoteQty"`
+	Commission string `json:"commission"`
+	Time       int64  `json:"time"`
+	IsBuyer    bool   `json:"isBuyer"`
+}
+
+// GetTradeHistory impl, the cursor is the unix time in milliseconds from which to fetch trades
+func (b *binanceExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	symbol, e := b.symbol(&pair)
+	if e != nil {
+		return nil, e
+	}
+
+	var startMillis *int64
+	if maybeCursorStart != nil {
+		v, e := strconv.ParseInt(maybeCursorStart.(string), 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse start cursor '%v': %s", maybeCursorStart, e)
+		}
+		startMillis = &v
+	}
+	var endMillis *int64
+	if maybeCursorEnd != nil {
+		v, e := strconv.ParseInt(maybeCursorEnd.(string), 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse end cursor '%v': %s", maybeCursorEnd, e)
+		}
+		endMillis = &v
+	}
+
+	params := url.Values{
+		"symbol": []string{symbol},
+		"limit":  []string{strconv.Itoa(binanceTradesLimit)},
+	}
+	if startMillis != nil {
+		params.Set("startTime", strconv.FormatInt(*startMillis, 10))
+	}
+
+	trades := []model.Trade{}
+	for {
+		var rawTrades []binanceTrade
+		e = b.signedRequest("GET", "/api/v3/myTrades", params, &rawTrades)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching trade history for trading pair '%s': %s", symbol, e)
+		}
+
+		pastEnd := false
+		for _, raw := range rawTrades {
+			if endMillis != nil && raw.Time > *endMillis {
+				pastEnd = true
+				continue
+			}
+			t, e := b.readTrade(&pair, symbol, raw)
+			if e != nil {
+				return nil, fmt.Errorf("error while reading trade: %s", e)
+			}
+			trades = append(trades, *t)
+		}
+
+		// the following pages only have later trades so there is nothing left to fetch once we are past the end cursor
+		if len(rawTrades) < binanceTradesLimit || pastEnd {
+			break
+		}
+		// page through the remaining trades by ID, which continues past the time window of the first request
+		params = url.Values{
+			"symbol": []string{symbol},
+			"limit":  []string{strconv.Itoa(binanceTradesLimit)},
+			"fromId": []string{strconv.FormatInt(rawTrades[len(rawTrades)-1].ID+1, 10)},
+		}
+	}
+
+	sort.Sort(model.TradesByTsID(trades))
+	cursor := maybeCursorStart
+	if len(trades) > 0 {
+		lastCursor := trades[len(trades)-1].Order.Timestamp.AsInt64()
+		// add 1 to lastCursor so we don't repeat the same cursor on the next run
+		cursor = strconv.FormatInt(lastCursor+1, 10)
+	} else if startMillis != nil {
+		// binance may only search a limited window after startTime, so move the cursor past an empty window once it has elapsed
+		windowEnd := *startMillis + int64(binanceTradeHistoryWindow/time.Millisecond)
+		if windowEnd < time.Now().UnixNano()/int64(time.Millisecond) {
+			cursor = strconv.FormatInt(windowEnd, 10)
+		}
+	}
+
+	return &api.TradeHistoryResult{
+		Cursor: cursor,
+		Trades: trades,
+	}, nil
+}
+
+func (b *binanceExchange) readTrade(pair *model.TradingPair, symbol string, raw binanceTrade) (*model.Trade, error) {
+	if raw.Symbol != symbol {
+		return nil, fmt.Errorf("expected '%s' for 'symbol' field, got: %s", symbol, raw.Symbol)
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	// use bigger precision for fee and cost since they are logically derived from amount and price
+	feeCostPrecision := orderConstraints.PricePrecision
+	if orderConstraints.VolumePrecision > feeCostPrecision {
+		feeCostPrecision = orderConstraints.VolumePrecision
+	}
+
+	price, e := model.NumberFromString(raw.Price, orderConstraints.PricePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse price '%s': %s", raw.Price, e)
+	}
+	volume, e := model.NumberFromString(raw.Qty, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse qty '%s': %s", raw.Qty, e)
+	}
+	cost, e := model.NumberFromString(raw.QuoteQty, feeCostPrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse quoteQty '%s': %s", raw.QuoteQty, e)
+	}
+	fee, e := model.NumberFromString(raw.Commission, feeCostPrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse commission '%s': %s", raw.Commission, e)
+	}
+
+	orderAction := model.OrderActionSell
+	if raw.IsBuyer {
+		orderAction = model.OrderActionBuy
+	}
+
+	return &model.Trade{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   model.MakeTimestamp(raw.Time),
+		},
+		TransactionID: model.MakeTransactionID(strconv.FormatInt(raw.ID, 10)),
+		Cost:          cost,
+		Fee:           fee,
+		OrderID:       strconv.FormatInt(raw.OrderID, 10),
+	}, nil
+}
+
+// GetLatestTradeCursor impl.
+func (b *binanceExchange) GetLatestTradeCursor() (interface{}, error) {
+	timeNowMillis := time.Now().UnixNano() / int64(time.Millisecond)
+	latestTradeCursor := fmt.Sprintf("%d", timeNowMillis)
+	return latestTradeCursor, nil
+}
+
+// GetTrades impl, the cursor is the ID of the aggregate trade from which to fetch public trades
+func (b *binanceExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	symbol, e := b.symbol(pair)
+	if e != nil {
+		return nil, e
+	}
+
+	params := url.Values{
+		"symbol": []string{symbol},
+		"limit":  []string{strconv.Itoa(binanceTradesLimit)},
+	}
+	if maybeCursor != nil {
+		params.Set("fromId", maybeCursor.(string))
+	}
+
+	var rawTrades []struct {
+		ID           int64  `json:"a"`
+		Price        string `json:"p"`
+		Qty          string `json:"q"`
+		Time         int64  `json:"T"`
+		IsBuyerMaker bool   `json:"m"`
+		// encoding/json matches keys case-insensitively so "M" needs its own field, otherwise it overwrites "m"
+		IsBestMatch bool `json:"M"`
+	}
+	e = b.publicRequest("/api/v3/aggTrades", params, &rawTrades)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching trades for trading pair '%s': %s", symbol, e)
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	trades := []model.Trade{}
+	for _, raw := range rawTrades {
+		price, e := model.NumberFromString(raw.Price, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", raw.Price, e)
+		}
+		volume, e := model.NumberFromString(raw.Qty, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse qty '%s': %s", raw.Qty, e)
+		}
+
+		// the taker is the seller when the buyer is the maker
+		orderAction := model.OrderActionBuy
+		if raw.IsBuyerMaker {
+			orderAction = model.OrderActionSell
+		}
+
+		trades = append(trades, model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       price,
+				Volume:      volume,
+				Timestamp:   model.MakeTimestamp(raw.Time),
+			},
+			TransactionID: model.MakeTransactionID(strconv.FormatInt(raw.ID, 10)),
+			// Cost unavailable
+			// Fee unavailable
+		})
+	}
+
+	sort.Sort(model.TradesByTsID(trades))
+	cursor := maybeCursor
+	if len(rawTrades) > 0 {
+		// add 1 to the last ID so we don't repeat the same trade on the next run
+		cursor = strconv.FormatInt(rawTrades[len(rawTrades)-1].ID+1, 10)
+	}
+
+	return &api.TradesResult{
+		Cursor: cursor,
+		Trades: trades,
+	}, nil
+}
+
+type binanceOpenOrder struct {
+	Symbol      string `json:"symbol"`
+	OrderID     int64  `json:"orderId"`
+	Price       string `json:"price"`
+	OrigQty     string `json:"origQty"`
+	ExecutedQty string `json:"executedQty"`
+	Type        string `json:"type"`
+	Side        string `json:"side"`
+	Time        int64  `json:"time"`
+}
+
+// GetOpenOrders impl.
+func (b *binanceExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	result := map[model.TradingPair][]model.OpenOrder{}
+	for _, pair := range pairs {
+		symbol, e := b.symbol(pair)
+		if e != nil {
+			return nil, e
+		}
+
+		var rawOrders []binanceOpenOrder
+		e = b.signedRequest("GET", "/api/v3/openOrders", url.Values{"symbol": []string{symbol}}, &rawOrders)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching open orders for trading pair '%s': %s", symbol, e)
+		}
+
+		openOrderList := []model.OpenOrder{}
+		for _, o := range rawOrders {
+			if o.Type != "LIMIT" && o.Type != "LIMIT_MAKER" {
+				// orders placed outside kelp can have any type, they should not stop us from seeing our own orders
+				log.Printf("skipping open order on binance with unsupported order type '%s': %+v\n", o.Type, o)
+				continue
+			}
+
+			openOrder, e := b.convertOpenOrder(pair, symbol, o)
+			if e != nil {
+				return nil, fmt.Errorf("cannot convertOpenOrder: %s", e)
+			}
+			openOrderList = append(openOrderList, *openOrder)
+		}
+		result[*pair] = openOrderList
+	}
+	return result, nil
+}
+
+func (b *binanceExchange) convertOpenOrder(pair *model.TradingPair, symbol string, o binanceOpenOrder) (*model.OpenOrder, error) {
+	if o.Symbol != symbol {
+		return nil, fmt.Errorf("expected '%s' for 'symbol' field, got: %s", symbol, o.Symbol)
+	}
+
+	orderConstraints := b.GetOrderConstraints(pair)
+	price, e := model.NumberFromString(o.Price, orderConstraints.PricePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse price '%s': %s", o.Price, e)
+	}
+	volume, e := model.NumberFromString(o.OrigQty, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse origQty '%s': %s", o.OrigQty, e)
+	}
+	volumeExecuted, e := model.NumberFromString(o.ExecutedQty, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse executedQty '%s': %s", o.ExecutedQty, e)
+	}
+
+	orderAction := model.OrderActionSell
+	if o.Side == "BUY" {
+		orderAction = model.OrderActionBuy
+	}
+	ts := model.MakeTimestamp(o.Time)
+
+	return &model.OpenOrder{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   ts,
+		},
+		ID:             strconv.FormatInt(o.OrderID, 10),
+		StartTime:      ts,
+		ExpireTime:     nil,
+		VolumeExecuted: volumeExecuted,
+	}, nil
+}
+
+// orderParams validates the order and makes the request params shared by new orders and cancel-replace orders
+func (b *binanceExchange) orderParams(symbol string, order *model.Order, submitMode api.SubmitMode) (url.Values, error) {
+	if !order.OrderType.IsLimit() {
+		return nil, fmt.Errorf("binance adapter only supports limit orders, got: %s", order.OrderType.String())
+	}
+
+	orderConstraints := b.GetOrderConstraints(order.Pair)
+	if order.Price.Precision() > orderConstraints.PricePrecision {
+		return nil, fmt.Errorf("binance price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.Price.Precision(), order.Price.AsFloat())
+	}
+	if order.Volume.Precision() > orderConstraints.VolumePrecision {
+		return nil, fmt.Errorf("binance volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
+	}
+
+	params := url.Values{
+		"symbol":   []string{symbol},
+		"side":     []string{strings.ToUpper(order.OrderAction.String())},
+		"quantity": []string{order.Volume.AsString()},
+		"price":    []string{order.Price.AsString()},
+	}
+	if submitMode == api.SubmitModeMakerOnly {
+		// binance rejects LIMIT_MAKER orders that would immediately match, which makes them post-only
+		params.Set("type", "LIMIT_MAKER")
+	} else {
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+	}
+	return params, nil
+}
+
+// AddOrder impl.
+func (b *binanceExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	symbol, e := b.symbol(order.Pair)
+	if e != nil {
+		return nil, e
+	}
+
+	if b.isSimulated {
+		log.Printf("not adding order to Binance in simulation mode, order=%s\n", *order)
+		return model.MakeTransactionID("simulated"), nil
+	}
+
+	params, e := b.orderParams(symbol, order, submitMode)
+	if e != nil {
+		return nil, e
+	}
+	params.Set("newOrderRespType", "ACK")
+
+	log.Printf("binance is submitting order: symbol=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
+		symbol, order.OrderAction.String(), params.Get("type"), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+	var resp struct {
+		OrderID int64 `json:"orderId"`
+	}
+	e = b.signedRequest("POST", "/api/v3/order", params, &resp)
+	if e != nil {
+		return nil, fmt.Errorf("error while adding order: %s", e)
+	}
+	if resp.OrderID == 0 {
+		return nil, fmt.Errorf("no orderId returned from order creation")
+	}
+	return model.MakeTransactionID(strconv.FormatInt(resp.OrderID, 10)), nil
+}
+
+// CancelOrder impl.
+func (b *binanceExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if b.isSimulated {
+		return model.CancelResultCancelSuccessful, nil
+	}
+
+	symbol, e := b.symbol(&pair)
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	log.Printf("binance is canceling order: ID=%s, tradingPair=%s\n", txID.String(), pair.String())
+
+	var resp struct {
+		Status string `json:"status"`
+	}
+	e = b.signedRequest("DELETE", "/api/v3/order", url.Values{
+		"symbol":  []string{symbol},
+		"orderId": []string{txID.String()},
+	}, &resp)
+	if e != nil {
+		return model.CancelResultFailed, fmt.Errorf("error while cancelling order: %s", e)
+	}
+
+	if resp.Status == "CANCELED" {
+		return model.CancelResultCancelSuccessful, nil
+	}
+	if resp.Status == "PENDING_CANCEL" {
+		return model.CancelResultPending, nil
+	}
+	return model.CancelResultFailed, nil
+}
+
+// AmendOrder impl, uses the cancel-replace endpoint which only places the new order if the cancel succeeds
+func (b *binanceExchange) AmendOrder(txID *model.TransactionID, order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	symbol, e := b.symbol(order.Pair)
+	if e != nil {
+		return nil, e
+	}
+
+	if b.isSimulated {
+		log.Printf("not amending order on Binance in simulation mode, ID=%s, order=%s\n", txID.String(), *order)
+		return model.MakeTransactionID("simulated"), nil
+	}
+
+	params, e := b.orderParams(symbol, order, submitMode)
+	if e != nil {
+		return nil, e
+	}
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE")
+	params.Set("cancelOrderId", txID.String())
+	params.Set("newOrderRespType", "ACK")
+
+	log.Printf("binance is amending order: ID=%s, symbol=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
+		txID.String(), symbol, order.OrderAction.String(), params.Get("type"), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+	var resp struct {
+		CancelResult     string `json:"cancelResult"`
+		NewOrderResult   string `json:"newOrderResult"`
+		NewOrderResponse struct {
+			OrderID int64 `json:"orderId"`
+		} `json:"newOrderResponse"`
+	}
+	e = b.signedRequest("POST", "/api/v3/order/cancelReplace", params, &resp)
+	if e != nil {
+		return nil, fmt.Errorf("error while amending order: %s", e)
+	}
+	if resp.CancelResult != "SUCCESS" || resp.NewOrderResult != "SUCCESS" {
+		return nil, fmt.Errorf("order was not amended: cancelResult=%s, newOrderResult=%s", resp.CancelResult, resp.NewOrderResult)
+	}
+	if resp.NewOrderResponse.OrderID == 0 {
+		return nil, fmt.Errorf("no orderId returned from order amendment")
+	}
+	return model.MakeTransactionID(strconv.FormatInt(resp.NewOrderResponse.OrderID, 10)), nil
+}
+
+// AddOrders impl, binance does not have a batch endpoint for adding orders so this is the generic fallback that makes one AddOrder request per order in parallel
+func (b *binanceExchange) AddOrders(orders []*model.Order, submitMode api.SubmitMode) ([]api.BatchAddResult, error) {
+	return addOrdersParallel(b, orders, submitMode), nil
+}
+
+// CancelOrders impl, binance does not have a batch endpoint for cancelling specific orders so this is the generic fallback that makes one CancelOrder request per order in parallel
+func (b *binanceExchange) CancelOrders(txIDs []*model.TransactionID, pair model.TradingPair) ([]api.BatchCancelResult, error) {
+	return cancelOrdersParallel(b, txIDs, pair), nil
+}
+
+// CancelAllOrders impl, this is the only call that is a single batch request on binance
+func (b *binanceExchange) CancelAllOrders(pair model.TradingPair) error {
+	if b.isSimulated {
+		return nil
+	}
+
+	symbol, e := b.symbol(&pair)
+	if e != nil {
+		return e
+	}
+	log.Printf("binance is canceling all orders: tradingPair=%s\n", pair.String())
+
+	e = b.signedRequest("DELETE", "/api/v3/openOrders", url.Values{"symbol": []string{symbol}}, nil)
+	if e != nil {
+		// binance responds with an "Unknown order sent." error when there are no open orders to cancel
+		if strings.Contains(e.Error(), "-2011") {
+			return nil
+		}
+		return fmt.Errorf("error while cancelling all orders: %s", e)
+	}
+	return nil
+}
+
+// GetWithdrawInfo impl.
+func (b *binanceExchange) GetWithdrawInfo(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawInfo, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the native binance adapter yet, use ccxt-binance instead")
+}
+
+// PrepareDeposit impl.
+func (b *binanceExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	return nil, fmt.Errorf("deposits are not supported by the native binance adapter yet, use ccxt-binance instead")
+}
+
+// WithdrawFunds impl.
+func (b *binanceExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the native binance adapter yet, use ccxt-binance instead")
+}
@@ -0,0 +1,420 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+var testBinancePair = model.MakeTradingPair(model.XLM, model.USDT)
+var testBinanceAPIKey = api.ExchangeAPIKey{Key: "testKey", Secret: "testSecret"}
+
+// binanceFixtureServer serves the recorded responses in testdata/binance and records the requests it receives
+type binanceFixtureServer struct {
+	t        *testing.T
+	routes   map[string]string // "METHOD /path" -> fixture file name
+	requests []*http.Request
+}
+
+func (s *binanceFixtureServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+
+	if signature := r.URL.Query().Get("signature"); signature != "" {
+		query := strings.TrimSuffix(r.URL.RawQuery, "&signature="+signature)
+		if r.Header.Get("X-MBX-APIKEY") != testBinanceAPIKey.Key || signature != binanceSignature(testBinanceAPIKey.Secret, query) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":-1022,"msg":"Signature for this request is not valid."}`))
+			return
+		}
+	}
+
+	fixture, ok := s.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":-1100,"msg":"no fixture for this request"}`))
+		return
+	}
+	body, e := ioutil.ReadFile(filepath.Join("testdata", "binance", fixture))
+	if e != nil {
+		s.t.Fatalf("could not read fixture '%s': %s", fixture, e)
+	}
+	if strings.HasPrefix(fixture, "error_") {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	w.Write(body)
+}
+
+func makeTestBinanceExchange(t *testing.T, routes map[string]string) (*binanceExchange, *binanceFixtureServer, func()) {
+	if _, ok := routes["GET /api/v3/exchangeInfo"]; !ok {
+		routes["GET /api/v3/exchangeInfo"] = "exchangeInfo.json"
+	}
+	fixtureServer := &binanceFixtureServer{t: t, routes: routes}
+	server := httptest.NewServer(fixtureServer)
+
+	b := &binanceExchange{
+		baseURL:            server.URL,
+		httpClient:         server.Client(),
+		apiKey:             testBinanceAPIKey,
+		assetConverter:     model.BinanceAssetConverter,
+		delimiter:          "",
+		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+		isSimulated:        false,
+	}
+	if e := b.loadAllOrderConstraints(); e != nil {
+		server.Close()
+		t.Fatalf("could not load order constraints: %s", e)
+	}
+	return b, fixtureServer, server.Close
+}
+
+func TestPrecisionFromStepSize(t *testing.T) {
+	testCases := []struct {
+		stepSize string
+		want     int8
+	}{
+		{"1.00000000", 0},
+		{"0.10000000", 1},
+		{"0.00001000", 5},
+		{"0.00000001", 8},
+		{"10", 0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.stepSize, func(t *testing.T) {
+			assert.Equal(t, k.want, precisionFromStepSize(k.stepSize))
+		})
+	}
+}
+
+func TestGetOrderConstraints_Binance(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{})
+	defer closeFn()
+
+	oc := b.GetOrderConstraints(testBinancePair)
+	assert.Equal(t, int8(5), oc.PricePrecision)
+	assert.Equal(t, int8(1), oc.VolumePrecision)
+	assert.Equal(t, 0.1, oc.MinBaseVolume.AsFloat())
+	if assert.NotNil(t, oc.MinQuoteVolume) {
+		assert.Equal(t, 10.0, oc.MinQuoteVolume.AsFloat())
+	}
+
+	// the constraints are preloaded so a pair that is not listed fails without making a request
+	assert.Panics(t, func() {
+		b.GetOrderConstraints(model.MakeTradingPair(model.BTC, model.USDT))
+	})
+}
+
+func TestGetOrderBook_Binance(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/depth": "depth.json"})
+	defer closeFn()
+
+	ob, e := b.GetOrderBook(testBinancePair, 2)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(ob.Asks())) || !assert.Equal(t, 2, len(ob.Bids())) {
+		return
+	}
+	assert.Equal(t, "0.10990", ob.Asks()[0].Price.AsString())
+	assert.Equal(t, "250.0", ob.Asks()[0].Volume.AsString())
+	assert.Equal(t, model.OrderActionSell, ob.Asks()[0].OrderAction)
+	assert.Equal(t, "0.10980", ob.Bids()[0].Price.AsString())
+	assert.Equal(t, "1500.3", ob.Bids()[0].Volume.AsString())
+	assert.Equal(t, model.OrderActionBuy, ob.Bids()[0].OrderAction)
+}
+
+func TestGetAccountBalances_Binance(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/account": "account.json"})
+	defer closeFn()
+
+	m, e := b.GetAccountBalances([]interface{}{model.XLM, model.USDT, model.BTC})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, 1500.5, m[model.XLM].AsFloat())
+	assert.Equal(t, 150.25, m[model.USDT].AsFloat())
+	assert.Equal(t, 0.0, m[model.BTC].AsFloat())
+}
+
+func TestSignedRequest_Binance_BadSecret(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/account": "account.json"})
+	defer closeFn()
+	b.apiKey = api.ExchangeAPIKey{Key: testBinanceAPIKey.Key, Secret: "wrongSecret"}
+
+	_, e := b.GetAccountBalances([]interface{}{model.XLM})
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "-1022")
+	}
+}
+
+func TestGetOpenOrders_Binance(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/openOrders": "openOrders.json"})
+	defer closeFn()
+
+	m, e := b.GetOpenOrders([]*model.TradingPair{testBinancePair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	openOrders := m[*testBinancePair]
+	// the STOP_LOSS_LIMIT order in the fixture is skipped
+	if !assert.Equal(t, 1, len(openOrders)) {
+		return
+	}
+	o := openOrders[0]
+	assert.Equal(t, "28", o.ID)
+	assert.Equal(t, model.OrderActionSell, o.OrderAction)
+	assert.Equal(t, model.OrderTypeLimit, o.OrderType)
+	assert.Equal(t, "0.11500", o.Price.AsString())
+	assert.Equal(t, "100.0", o.Volume.AsString())
+	assert.Equal(t, "20.0", o.VolumeExecuted.AsString())
+	assert.Equal(t, int64(1565246363776), o.Timestamp.AsInt64())
+}
+
+func TestGetTickerPrice_Binance(t *testing.T) {
+	b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{
+		"GET /api/v3/ticker/bookTicker": "bookTicker.json",
+		"GET /api/v3/ticker/price":      "tickerPrice.json",
+	})
+	defer closeFn()
+
+	m, e := b.GetTickerPrice([]model.TradingPair{*testBinancePair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	ticker, ok := m[*testBinancePair]
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "0.10990", ticker.AskPrice.AsString())
+	assert.Equal(t, "0.10980", ticker.BidPrice.AsString())
+	assert.Equal(t, "0.10985", ticker.LastPrice.AsString())
+	assert.Equal(t, "XLMUSDT", fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query().Get("symbol"))
+}
+
+func TestGetTrades_Binance(t *testing.T) {
+	b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/aggTrades": "aggTrades.json"})
+	defer closeFn()
+
+	testCases := []struct {
+		name       string
+		cursor     interface{}
+		wantFromID string
+	}{
+		{
+			name:       "no cursor",
+			cursor:     nil,
+			wantFromID: "",
+		}, {
+			name:       "cursor",
+			cursor:     "26129",
+			wantFromID: "26129",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			res, e := b.GetTrades(testBinancePair, k.cursor)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantFromID, fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query().Get("fromId"))
+			// the cursor is one past the last aggregate trade ID
+			assert.Equal(t, "26131", res.Cursor)
+			if !assert.Equal(t, 2, len(res.Trades)) {
+				return
+			}
+
+			first := res.Trades[0]
+			assert.Equal(t, "26129", first.TransactionID.String())
+			// the buyer was the maker so the taker sold
+			assert.Equal(t, model.OrderActionSell, first.OrderAction)
+			assert.Equal(t, "0.10985", first.Price.AsString())
+			assert.Equal(t, "120.0", first.Volume.AsString())
+			assert.Equal(t, int64(1565246363776), first.Timestamp.AsInt64())
+
+			second := res.Trades[1]
+			assert.Equal(t, "26130", second.TransactionID.String())
+			assert.Equal(t, model.OrderActionBuy, second.OrderAction)
+			assert.Equal(t, "35.5", second.Volume.AsString())
+		})
+	}
+}
+
+func TestGetTradeHistory_Binance(t *testing.T) {
+	b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"GET /api/v3/myTrades": "myTrades.json"})
+	defer closeFn()
+
+	testCases := []struct {
+		name        string
+		cursorStart interface{}
+		cursorEnd   interface{}
+		wantIDs     []string
+		wantCursor  interface{}
+	}{
+		{
+			name:        "all trades",
+			cursorStart: "1565246300000",
+			cursorEnd:   nil,
+			wantIDs:     []string{"28", "29"},
+			wantCursor:  "1565246400101",
+		}, {
+			name:        "end cursor excludes later trades",
+			cursorStart: "1565246300000",
+			cursorEnd:   "1565246363776",
+			wantIDs:     []string{"28"},
+			wantCursor:  "1565246363777",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			res, e := b.GetTradeHistory(*testBinancePair, k.cursorStart, k.cursorEnd)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.cursorStart, fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query().Get("startTime"))
+			assert.Equal(t, k.wantCursor, res.Cursor)
+
+			ids := []string{}
+			for _, trade := range res.Trades {
+				ids = append(ids, trade.TransactionID.String())
+			}
+			assert.Equal(t, k.wantIDs, ids)
+		})
+	}
+
+	res, e := b.GetTradeHistory(*testBinancePair, "1565246300000", nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	buy := res.Trades[0]
+	assert.Equal(t, model.OrderActionBuy, buy.OrderAction)
+	assert.Equal(t, "100234", buy.OrderID)
+	assert.Equal(t, "0.10980", buy.Price.AsString())
+	assert.Equal(t, "100.0", buy.Volume.AsString())
+	assert.Equal(t, "10.98000", buy.Cost.AsString())
+	assert.Equal(t, model.OrderActionSell, res.Trades[1].OrderAction)
+}
+
+func TestAddOrder_Binance(t *testing.T) {
+	testCases := []struct {
+		submitMode      api.SubmitMode
+		wantType        string
+		wantTimeInForce string
+	}{
+		{api.SubmitModeMakerOnly, "LIMIT_MAKER", ""},
+		{api.SubmitModeBoth, "LIMIT", "GTC"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.submitMode.String(), func(t *testing.T) {
+			b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"POST /api/v3/order": "order.json"})
+			defer closeFn()
+
+			txID, e := b.AddOrder(&model.Order{
+				Pair:        testBinancePair,
+				OrderAction: model.OrderActionSell,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(0.115, 5),
+				Volume:      model.NumberFromFloat(100.0, 1),
+			}, k.submitMode)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "28", txID.String())
+
+			query := fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query()
+			assert.Equal(t, "XLMUSDT", query.Get("symbol"))
+			assert.Equal(t, "SELL", query.Get("side"))
+			assert.Equal(t, k.wantType, query.Get("type"))
+			assert.Equal(t, k.wantTimeInForce, query.Get("timeInForce"))
+			assert.Equal(t, "0.11500", query.Get("price"))
+			assert.Equal(t, "100.0", query.Get("quantity"))
+		})
+	}
+}
+
+func TestAddOrder_Binance_PostOnlyRejected(t *testing.T) {
+	b, _, closeFn := makeTestBinanceExchange(t, map[string]string{"POST /api/v3/order": "error_wouldMatch.json"})
+	defer closeFn()
+
+	_, e := b.AddOrder(&model.Order{
+		Pair:        testBinancePair,
+		OrderAction: model.OrderActionBuy,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(0.12, 5),
+		Volume:      model.NumberFromFloat(100.0, 1),
+	}, api.SubmitModeMakerOnly)
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "-2010")
+	}
+}
+
+func TestCancelOrder_Binance(t *testing.T) {
+	b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"DELETE /api/v3/order": "cancelOrder.json"})
+	defer closeFn()
+
+	result, e := b.CancelOrder(model.MakeTransactionID("28"), *testBinancePair)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, model.CancelResultCancelSuccessful, result)
+	assert.Equal(t, "28", fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query().Get("orderId"))
+}
+
+func TestAmendOrder_Binance(t *testing.T) {
+	b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"POST /api/v3/order/cancelReplace": "cancelReplace.json"})
+	defer closeFn()
+
+	txID, e := b.AmendOrder(model.MakeTransactionID("28"), &model.Order{
+		Pair:        testBinancePair,
+		OrderAction: model.OrderActionSell,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(0.116, 5),
+		Volume:      model.NumberFromFloat(100.0, 1),
+	}, api.SubmitModeMakerOnly)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "30", txID.String())
+
+	query := fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query()
+	assert.Equal(t, "28", query.Get("cancelOrderId"))
+	assert.Equal(t, "STOP_ON_FAILURE", query.Get("cancelReplaceMode"))
+	assert.Equal(t, "LIMIT_MAKER", query.Get("type"))
+	assert.Equal(t, "0.11600", query.Get("price"))
+	assert.Equal(t, "100.0", query.Get("quantity"))
+}
+
+func TestCancelAllOrders_Binance(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fixture string
+	}{
+		{"open orders", "cancelOpenOrders.json"},
+		{"no open orders", "error_noOpenOrders.json"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			b, fixtureServer, closeFn := makeTestBinanceExchange(t, map[string]string{"DELETE /api/v3/openOrders": k.fixture})
+			defer closeFn()
+
+			e := b.CancelAllOrders(*testBinancePair)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "XLMUSDT", fixtureServer.requests[len(fixtureServer.requests)-1].URL.Query().Get("symbol"))
+		})
+	}
+}
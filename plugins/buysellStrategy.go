@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"log"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -11,17 +12,38 @@ import (
 
 // BuySellConfig contains the configuration params for this strategy
 type BuySellConfig struct {
-	PriceTolerance         float64       `valid:"-" toml:"PRICE_TOLERANCE" json:"price_tolerance"`
-	AmountTolerance        float64       `valid:"-" toml:"AMOUNT_TOLERANCE" json:"amount_tolerance"`
-	RateOffsetPercent      float64       `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
-	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
-	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
-	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"` // the size of order to keep on either side
-	DataTypeA              string        `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
-	DataFeedAURL           string        `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
-	DataTypeB              string        `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
-	DataFeedBURL           string        `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
-	Levels                 []StaticLevel `valid:"-" toml:"LEVELS" json:"levels"`
+	PriceTolerance         float64            `valid:"-" toml:"PRICE_TOLERANCE" json:"price_tolerance"`
+	AmountTolerance        float64            `valid:"-" toml:"AMOUNT_TOLERANCE" json:"amount_tolerance"`
+	RateOffsetPercent      float64            `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
+	RateOffset             float64            `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
+	RateOffsetPercentFirst bool               `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
+	AmountOfABase          float64            `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"` // the size of order to keep on either side
+	DataTypeA              string             `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
+	DataFeedAURL           string             `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
+	DataTypeB              string             `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
+	DataFeedBURL           string             `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
+	Levels                 []StaticLevel      `valid:"-" toml:"LEVELS" json:"levels"`
+	LevelGenerator         *LevelGenerator    `valid:"-" toml:"LEVEL_GENERATOR" json:"level_generator"`
+	Bids                   *BuySellSideConfig `valid:"-" toml:"BIDS" json:"bids"`
+	Asks                   *BuySellSideConfig `valid:"-" toml:"ASKS" json:"asks"`
+}
+
+// BuySellSideConfig overrides the config of one side of the orderbook, the fields that are not set use the values of the top level of the
+// BuySellConfig. Rate offsets are applied to the price of the base asset in units of the quote asset on both sides, as in BuySellConfig.
+type BuySellSideConfig struct {
+	RateOffsetPercent      *float64        `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
+	RateOffset             *float64        `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
+	RateOffsetPercentFirst *bool           `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
+	AmountOfABase          *float64        `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"`
+	Levels                 []StaticLevel   `valid:"-" toml:"LEVELS" json:"levels"`
+	LevelGenerator         *LevelGenerator `valid:"-" toml:"LEVEL_GENERATOR" json:"level_generator"`
+}
+
+// buySellSide is the config of one side of the orderbook after applying the overrides from BIDS or ASKS
+type buySellSide struct {
+	levels        []StaticLevel
+	amountOfABase float64
+	offset        rateOffset
 }
 
 // MakeBuysellConfig factory method
@@ -58,6 +80,78 @@ func (c BuySellConfig) String() string {
 	return utils.StructString(c, 0, nil)
 }
 
+// makeStaticLevels returns the levels, or the levels generated by the generator when it is set
+func makeStaticLevels(levels []StaticLevel, generator *LevelGenerator) ([]StaticLevel, error) {
+	if generator == nil {
+		return levels, nil
+	}
+	if len(levels) > 0 {
+		return nil, fmt.Errorf("only one of LEVELS and LEVEL_GENERATOR can be set")
+	}
+
+	generatedLevels, e := generator.Levels()
+	if e != nil {
+		return nil, fmt.Errorf("invalid LEVEL_GENERATOR: %s", e)
+	}
+	log.Printf("generated %d levels from the LEVEL_GENERATOR: %v\n", len(generatedLevels), generatedLevels)
+	return generatedLevels, nil
+}
+
+// side returns the config of one side of the orderbook, where override is the BIDS or ASKS of the config and can be nil.
+// The levels of the override replace the levels of the top level of the config when it sets either LEVELS or LEVEL_GENERATOR.
+func (c BuySellConfig) side(override *BuySellSideConfig, isBuySide bool) (*buySellSide, error) {
+	levels := c.Levels
+	generator := c.LevelGenerator
+	s := &buySellSide{
+		amountOfABase: c.AmountOfABase,
+		offset: rateOffset{
+			percent:      c.RateOffsetPercent,
+			absolute:     c.RateOffset,
+			percentFirst: c.RateOffsetPercentFirst,
+			invert:       isBuySide,
+		},
+	}
+
+	if override != nil {
+		if override.RateOffsetPercent != nil {
+			s.offset.percent = *override.RateOffsetPercent
+		}
+		if override.RateOffset != nil {
+			s.offset.absolute = *override.RateOffset
+		}
+		if override.RateOffsetPercentFirst != nil {
+			s.offset.percentFirst = *override.RateOffsetPercentFirst
+		}
+		if override.AmountOfABase != nil {
+			s.amountOfABase = *override.AmountOfABase
+		}
+		if len(override.Levels) > 0 || override.LevelGenerator != nil {
+			levels = override.Levels
+			generator = override.LevelGenerator
+		}
+	}
+
+	var e error
+	s.levels, e = makeStaticLevels(levels, generator)
+	if e != nil {
+		return nil, e
+	}
+	return s, nil
+}
+
+// SideLevels returns the levels of the bid and ask sides after applying the overrides from BIDS and ASKS
+func (c BuySellConfig) SideLevels() ([]StaticLevel, []StaticLevel, error) {
+	bidSide, e := c.side(c.Bids, true)
+	if e != nil {
+		return nil, nil, fmt.Errorf("error in the config of the bid side: %s", e)
+	}
+	askSide, e := c.side(c.Asks, false)
+	if e != nil {
+		return nil, nil, fmt.Errorf("error in the config of the ask side: %s", e)
+	}
+	return bidSide.levels, askSide.levels, nil
+}
+
 // makeBuySellStrategy is a factory method
 func makeBuySellStrategy(
 	sdex *SDEX,
@@ -67,11 +161,15 @@ func makeBuySellStrategy(
 	assetQuote *hProtocol.Asset,
 	config *BuySellConfig,
 ) (api.Strategy, error) {
-	offsetSell := rateOffset{
-		percent:      config.RateOffsetPercent,
-		absolute:     config.RateOffset,
-		percentFirst: config.RateOffsetPercentFirst,
+	askSide, e := config.side(config.Asks, false)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an error in the config of the ask side: %s", e)
 	}
+	bidSide, e := config.side(config.Bids, true)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an error in the config of the bid side: %s", e)
+	}
+
 	sellSideFeedPair, e := MakeFeedPair(
 		config.DataTypeA,
 		config.DataFeedAURL,
@@ -89,9 +187,9 @@ func makeBuySellStrategy(
 		assetBase,
 		assetQuote,
 		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetSell,
+			askSide.levels,
+			askSide.amountOfABase,
+			askSide.offset,
 			sellSideFeedPair,
 			orderConstraints,
 		),
@@ -100,12 +198,6 @@ func makeBuySellStrategy(
 		false,
 	)
 
-	offsetBuy := rateOffset{
-		percent:      config.RateOffsetPercent,
-		absolute:     config.RateOffset,
-		percentFirst: config.RateOffsetPercentFirst,
-		invert:       true,
-	}
 	buySideFeedPair, e := MakeFeedPair(
 		config.DataTypeB,
 		config.DataFeedBURL,
@@ -123,9 +215,9 @@ func makeBuySellStrategy(
 		assetQuote,
 		assetBase,
 		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetBuy,
+			bidSide.levels,
+			bidSide.amountOfABase,
+			bidSide.offset,
 			buySideFeedPair,
 			orderConstraints,
 		),
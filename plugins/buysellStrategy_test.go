@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuySellConfigSide(t *testing.T) {
+	listedLevels := []StaticLevel{{SPREAD: 0.001, AMOUNT: 100}}
+	sideLevels := []StaticLevel{{SPREAD: 0.002, AMOUNT: 50}, {SPREAD: 0.003, AMOUNT: 50}, {SPREAD: 0.004, AMOUNT: 50}}
+	generator := &LevelGenerator{NumLevels: 2, StartSpread: 0.001, SpreadStep: 0.001, AmountCurve: "linear", StartAmount: 100}
+	amount := 5.0
+	percent := -0.01
+	absolute := 0.0
+	percentFirst := false
+
+	testCases := []struct {
+		name          string
+		config        BuySellConfig
+		override      *BuySellSideConfig
+		isBuySide     bool
+		wantNumLevels int
+		wantAmount    float64
+		wantOffset    rateOffset
+		wantErr       bool
+	}{
+		{
+			name:          "listed levels",
+			config:        BuySellConfig{Levels: listedLevels, AmountOfABase: 10, RateOffsetPercent: 0.02, RateOffsetPercentFirst: true},
+			override:      nil,
+			isBuySide:     false,
+			wantNumLevels: 1,
+			wantAmount:    10,
+			wantOffset:    rateOffset{percent: 0.02, percentFirst: true},
+		}, {
+			name:          "generated levels on the buy side",
+			config:        BuySellConfig{LevelGenerator: generator, AmountOfABase: 10, RateOffset: 0.1},
+			override:      nil,
+			isBuySide:     true,
+			wantNumLevels: 2,
+			wantAmount:    10,
+			wantOffset:    rateOffset{absolute: 0.1, invert: true},
+		}, {
+			name:          "empty override uses the top level",
+			config:        BuySellConfig{Levels: listedLevels, AmountOfABase: 10},
+			override:      &BuySellSideConfig{},
+			isBuySide:     false,
+			wantNumLevels: 1,
+			wantAmount:    10,
+			wantOffset:    rateOffset{},
+		}, {
+			name:   "override everything",
+			config: BuySellConfig{Levels: listedLevels, AmountOfABase: 10, RateOffsetPercent: 0.02, RateOffset: 0.1, RateOffsetPercentFirst: true},
+			override: &BuySellSideConfig{
+				RateOffsetPercent:      &percent,
+				RateOffset:             &absolute,
+				RateOffsetPercentFirst: &percentFirst,
+				AmountOfABase:          &amount,
+				Levels:                 sideLevels,
+			},
+			isBuySide:     true,
+			wantNumLevels: 3,
+			wantAmount:    5,
+			wantOffset:    rateOffset{percent: -0.01, absolute: 0.0, percentFirst: false, invert: true},
+		}, {
+			name:          "override levels with a generator",
+			config:        BuySellConfig{Levels: listedLevels, AmountOfABase: 10},
+			override:      &BuySellSideConfig{LevelGenerator: generator},
+			isBuySide:     false,
+			wantNumLevels: 2,
+			wantAmount:    10,
+			wantOffset:    rateOffset{},
+		}, {
+			name:     "both levels and generator set",
+			config:   BuySellConfig{Levels: listedLevels, LevelGenerator: generator},
+			override: nil,
+			wantErr:  true,
+		}, {
+			name:     "both levels and generator set in the override",
+			config:   BuySellConfig{Levels: listedLevels},
+			override: &BuySellSideConfig{Levels: sideLevels, LevelGenerator: generator},
+			wantErr:  true,
+		}, {
+			name:     "invalid generator",
+			config:   BuySellConfig{LevelGenerator: &LevelGenerator{}},
+			override: nil,
+			wantErr:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			side, e := k.config.side(k.override, k.isBuySide)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNumLevels, len(side.levels))
+			assert.Equal(t, k.wantAmount, side.amountOfABase)
+			assert.Equal(t, k.wantOffset, side.offset)
+		})
+	}
+}
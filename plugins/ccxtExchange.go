@@ -17,6 +17,18 @@ const ccxtBalancePrecision = 10
 
 // ensure that ccxtExchange conforms to the Exchange interface
 var _ api.Exchange = ccxtExchange{}
+var _ serialTradeAPI = ccxtExchange{}
+var _ orderBookLatencyAPI = ccxtExchange{}
+var _ api.AmendOrderAPI = ccxtExchange{}
+var _ api.WithdrawTagAPI = ccxtExchange{}
+
+// ccxtSerialExchanges are the exchanges that sign private requests with a nonce that has to increase, so orders cannot be submitted in parallel
+var ccxtSerialExchanges = map[string]bool{
+	"kraken": true,
+}
+
+// ccxtStopLossLimitOrderType is the order type ccxt gives to stop-limit orders that it reads back from exchanges such as binance
+const ccxtStopLossLimitOrderType = "stop_loss_limit"
 
 // ccxtExchangeSpecificParamFactory knows how to create the exchange-specific params for each exchange
 type ccxtExchangeSpecificParamFactory interface {
@@ -25,10 +37,13 @@ type ccxtExchangeSpecificParamFactory interface {
 	getParamsForAddOrder(submitMode api.SubmitMode) interface{}
 	getParamsForGetTradeHistory() interface{}
 	useSignToDenoteSideForTrades() bool
+	// getParamsForStopLimitOrder returns the ccxt order type and the params needed to place the stop-limit order, or an error if the exchange does not support stop-limit orders
+	getParamsForStopLimitOrder(order *model.Order) (string, map[string]interface{}, error)
 }
 
 // ccxtExchange is the implementation for the CCXT REST library that supports many exchanges (https://github.com/franz-see/ccxt-rest, https://github.com/ccxt/ccxt/)
 type ccxtExchange struct {
+	exchangeName       string
 	assetConverter     model.AssetConverterInterface
 	delimiter          string
 	ocOverridesHandler *OrderConstraintsOverridesHandler
@@ -81,14 +96,24 @@ func makeCcxtExchange(
 		ocOverridesHandler = MakeOrderConstraintsOverridesHandler(orderConstraintOverrides)
 	}
 
-	return ccxtExchange{
+	x := ccxtExchange{
+		exchangeName:       exchangeName,
 		assetConverter:     model.CcxtAssetConverter,
 		delimiter:          "/",
 		ocOverridesHandler: ocOverridesHandler,
 		api:                c,
 		simMode:            simMode,
 		esParamFactory:     esParamFactory,
-	}, nil
+	}
+	if _, ok := ccxtClientOrderIDParams[exchangeName]; ok {
+		return ccxtClientOrderIDExchange{x}, nil
+	}
+	return x, nil
+}
+
+// requiresSerialRequests impl.
+func (c ccxtExchange) requiresSerialRequests() bool {
+	return ccxtSerialExchanges[c.exchangeName]
 }
 
 // GetTickerPrice impl.
@@ -465,8 +490,16 @@ func (c ccxtExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.Tradi
 
 func (c ccxtExchange) convertOpenOrderFromCcxt(pair *model.TradingPair, o sdk.CcxtOpenOrder) (*model.OpenOrder, error) {
 	// bitstamp does not use "limit" as the order type but has an empty string. this is reasonable general logic to support so added here instead of specifically for bitstamp
-	if o.Type != "limit" && o.Type != "" {
-		return nil, fmt.Errorf("we currently only support limit order types: %+v", o)
+	orderType := model.OrderTypeLimit
+	var stopPrice *model.Number
+	if o.Type == ccxtStopLossLimitOrderType {
+		orderType = model.OrderTypeStopLimit
+		stopPrice = model.NumberFromFloat(o.StopPrice, c.GetOrderConstraints(pair).PricePrecision)
+	} else if o.Type == "market" {
+		// market orders are never open but they are returned with the closed orders
+		orderType = model.OrderTypeMarket
+	} else if o.Type != "limit" && o.Type != "" {
+		return nil, fmt.Errorf("we currently only support limit, stop-limit and market order types: %+v", o)
 	}
 
 	orderAction := model.OrderActionSell
@@ -479,9 +512,10 @@ func (c ccxtExchange) convertOpenOrderFromCcxt(pair *model.TradingPair, o sdk.Cc
 		Order: model.Order{
 			Pair:        pair,
 			OrderAction: orderAction,
-			OrderType:   model.OrderTypeLimit,
+			OrderType:   orderType,
 			Price:       model.NumberFromFloat(o.Price, c.GetOrderConstraints(pair).PricePrecision),
 			Volume:      model.NumberFromFloat(o.Amount, c.GetOrderConstraints(pair).VolumePrecision),
+			StopPrice:   stopPrice,
 			Timestamp:   ts,
 		},
 		ID:             o.ID,
@@ -503,21 +537,210 @@ func (c ccxtExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*
 		side = "buy"
 	}
 
-	log.Printf("ccxt is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
-		pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+	log.Printf("ccxt is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s, clientOrderID=%s\n",
+		pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String(), order.ClientOrderID)
 
 	var maybeExchangeSpecificParams interface{}
 	if c.esParamFactory != nil {
 		maybeExchangeSpecificParams = c.esParamFactory.getParamsForAddOrder(submitMode)
 	}
-	ccxtOpenOrder, e := c.api.CreateLimitOrder(pairString, side, order.Volume.AsFloat(), order.Price.AsFloat(), maybeExchangeSpecificParams)
+	// the clientOrderID is only passed through to exchanges where we know the name and format of the param, it is ignored on other exchanges
+	if clientOrderIDParam, ok := ccxtClientOrderIDParams[c.exchangeName]; ok && order.ClientOrderID != "" {
+		maybeExchangeSpecificParams, e = withParam(maybeExchangeSpecificParams, clientOrderIDParam.name, clientOrderIDParam.format(order.ClientOrderID))
+		if e != nil {
+			return nil, fmt.Errorf("error while setting clientOrderID on order %s: %s", *order, e)
+		}
+	}
+
+	ccxtOrderType, maybePrice, maybeExchangeSpecificParams, e := ccxtOrderArgs(order, submitMode, c.esParamFactory, maybeExchangeSpecificParams)
 	if e != nil {
-		return nil, fmt.Errorf("error while creating limit order %s: %s", *order, e)
+		return nil, fmt.Errorf("error while converting order %s: %s", *order, e)
+	}
+	ccxtOpenOrder, e := c.api.CreateOrder(pairString, ccxtOrderType, side, order.Volume.AsFloat(), maybePrice, maybeExchangeSpecificParams)
+	if e != nil {
+		return nil, fmt.Errorf("error while creating %s order %s: %s", order.OrderType.String(), *order, e)
 	}
 
 	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
 }
 
+// orderBookLatency impl.
+func (c ccxtExchange) orderBookLatency(since time.Time) (time.Duration, bool) {
+	return c.api.FetchOrderBookLatency(since)
+}
+
+// ccxtOrderArgs returns ccxt's order type, the price, and the params with the unified ccxt params needed for the order type
+func ccxtOrderArgs(order *model.Order, submitMode api.SubmitMode, esParamFactory ccxtExchangeSpecificParamFactory, maybeExchangeSpecificParams interface{}) (string, *float64, interface{}, error) {
+	price := order.Price.AsFloat()
+	var e error
+	switch order.OrderType {
+	case model.OrderTypeMarket:
+		if submitMode == api.SubmitModeMakerOnly {
+			return "", nil, nil, fmt.Errorf("cannot submit a market order as maker only")
+		}
+		return "market", nil, maybeExchangeSpecificParams, nil
+	case model.OrderTypeLimit:
+		return "limit", &price, maybeExchangeSpecificParams, nil
+	case model.OrderTypeImmediateOrCancel:
+		if submitMode == api.SubmitModeMakerOnly {
+			return "", nil, nil, fmt.Errorf("cannot submit an ioc order as maker only")
+		}
+		maybeExchangeSpecificParams, e = withParam(maybeExchangeSpecificParams, "timeInForce", "IOC")
+		if e != nil {
+			return "", nil, nil, e
+		}
+		return "limit", &price, maybeExchangeSpecificParams, nil
+	case model.OrderTypeStopLimit:
+		// ccxt does not have a unified stop-limit order type so the order type and stop params come from the exchange specific param factory
+		if esParamFactory == nil {
+			return "", nil, nil, fmt.Errorf("stop-limit orders are not supported on this exchange via ccxt")
+		}
+		if order.StopPrice == nil {
+			return "", nil, nil, fmt.Errorf("stop-limit order needs a stop price")
+		}
+		ccxtOrderType, stopParams, e := esParamFactory.getParamsForStopLimitOrder(order)
+		if e != nil {
+			return "", nil, nil, e
+		}
+		for k, v := range stopParams {
+			maybeExchangeSpecificParams, e = withParam(maybeExchangeSpecificParams, k, v)
+			if e != nil {
+				return "", nil, nil, e
+			}
+		}
+		return ccxtOrderType, &price, maybeExchangeSpecificParams, nil
+	}
+	return "", nil, nil, fmt.Errorf("unsupported order type: %s", order.OrderType.String())
+}
+
+// withParam adds a param to the exchange specific params, which is either a unified ccxt param (e.g. timeInForce) or a param that
+// ccxt passes through to the exchange unchanged
+func withParam(maybeExchangeSpecificParams interface{}, key string, value interface{}) (interface{}, error) {
+	params := map[string]interface{}{}
+	if maybeExchangeSpecificParams != nil {
+		m, ok := maybeExchangeSpecificParams.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to add %s to exchange specific params that are not a map[string]interface{}: %+v (type=%T)", key, maybeExchangeSpecificParams, maybeExchangeSpecificParams)
+		}
+		for k, v := range m {
+			params[k] = v
+		}
+	}
+	params[key] = value
+	return params, nil
+}
+
+// ccxtClientOrderIDParam is how a clientOrderID is passed through ccxt to an exchange, ccxt does not have a unified param for it
+type ccxtClientOrderIDParam struct {
+	name      string                            // the exchange's own name for the param when placing an order
+	infoKey   string                            // the exchange's own name for the field in the orders it returns
+	format    func(clientOrderID string) string // converts our clientOrderID to the format needed by the exchange
+	maxDigits int                               // the number of decimal digits of our clientOrderID that fit in the exchange's format
+}
+
+// ccxtClientOrderIDParams are the exchanges that we have verified to accept a clientOrderID and return it with open orders,
+// clientOrderIDs are not used on other exchanges
+var ccxtClientOrderIDParams = map[string]ccxtClientOrderIDParam{
+	"binance": {
+		name:    "newClientOrderId",
+		infoKey: "clientOrderId",
+		// binance accepts up to 36 alphanumeric characters so our decimal clientOrderID can be used as-is
+		format:    func(clientOrderID string) string { return clientOrderID },
+		maxDigits: 20,
+	},
+	"coinbasepro": {
+		name:      "client_oid",
+		infoKey:   "client_oid",
+		format:    clientOrderID2UUID,
+		maxDigits: 20,
+	},
+}
+
+// clientOrderID2UUID converts our clientOrderID (a decimal number with at most 20 digits, see model.MakeClientOrderID) to a UUID,
+// decimal digits are valid hex digits so the zero-padded clientOrderID is split over the first and last groups of a version 4 UUID.
+// A clientOrderID with at most 12 digits is only in the last group.
+func clientOrderID2UUID(clientOrderID string) string {
+	padded := fmt.Sprintf("%020s", clientOrderID)
+	return fmt.Sprintf("%s-0000-4000-8000-%s", padded[:8], padded[8:])
+}
+
+// ccxtClientOrderIDExchange is a ccxtExchange on an exchange that is in ccxtClientOrderIDParams so orders can be looked up by their clientOrderID
+type ccxtClientOrderIDExchange struct {
+	ccxtExchange
+}
+
+// ensure that ccxtClientOrderIDExchange conforms to the Exchange, ClientOrderIDTradeAPI, ClosedOrderByClientIDAPI and LongClientOrderIDAPI interfaces
+var _ api.Exchange = ccxtClientOrderIDExchange{}
+var _ api.ClientOrderIDTradeAPI = ccxtClientOrderIDExchange{}
+var _ api.ClosedOrderByClientIDAPI = ccxtClientOrderIDExchange{}
+var _ api.LongClientOrderIDAPI = ccxtClientOrderIDExchange{}
+
+// ccxtClosedOrdersLimit is the number of the latest closed orders that are searched for a clientOrderID
+const ccxtClosedOrdersLimit = 100
+
+// MaxClientOrderIDDigits impl
+func (c ccxtClientOrderIDExchange) MaxClientOrderIDDigits() int {
+	return ccxtClientOrderIDParams[c.exchangeName].maxDigits
+}
+
+// GetOpenOrderByClientID impl
+func (c ccxtClientOrderIDExchange) GetOpenOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error) {
+	clientOrderIDParam := ccxtClientOrderIDParams[c.exchangeName]
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	openOrdersMap, e := c.api.FetchOpenOrders([]string{pairString})
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching open orders to look up clientOrderID '%s': %s", clientOrderID, e)
+	}
+
+	wantID := clientOrderIDParam.format(clientOrderID)
+	for _, o := range openOrdersMap[pairString] {
+		if id, ok := o.Info[clientOrderIDParam.infoKey].(string); !ok || id != wantID {
+			continue
+		}
+
+		openOrder, e := c.convertOpenOrderFromCcxt(pair, o)
+		if e != nil {
+			return nil, fmt.Errorf("cannot convert open order with clientOrderID '%s': %s", clientOrderID, e)
+		}
+		openOrder.ClientOrderID = clientOrderID
+		return openOrder, nil
+	}
+	return nil, nil
+}
+
+// GetClosedOrderByClientID impl, only the latest closed orders of the pair are searched
+func (c ccxtClientOrderIDExchange) GetClosedOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error) {
+	clientOrderIDParam := ccxtClientOrderIDParams[c.exchangeName]
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	closedOrders, e := c.api.FetchClosedOrders(pairString, ccxtClosedOrdersLimit)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching closed orders to look up clientOrderID '%s': %s", clientOrderID, e)
+	}
+
+	wantID := clientOrderIDParam.format(clientOrderID)
+	for _, o := range closedOrders {
+		if id, ok := o.Info[clientOrderIDParam.infoKey].(string); !ok || id != wantID {
+			continue
+		}
+
+		closedOrder, e := c.convertOpenOrderFromCcxt(pair, o)
+		if e != nil {
+			return nil, fmt.Errorf("cannot convert closed order with clientOrderID '%s': %s", clientOrderID, e)
+		}
+		closedOrder.ClientOrderID = clientOrderID
+		return closedOrder, nil
+	}
+	return nil, nil
+}
+
 // CancelOrder impl
 func (c ccxtExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
 	log.Printf("ccxt is canceling order: ID=%s, tradingPair: %s\n", txID.String(), pair.String())
@@ -533,24 +756,140 @@ func (c ccxtExchange) CancelOrder(txID *model.TransactionID, pair model.TradingP
 	return model.CancelResultCancelSuccessful, nil
 }
 
+// AmendOrder impl, uses ccxt's editOrder which is only native on some exchanges so it is only used on exchanges with the NativeAmendOrder flag in the factory
+func (c ccxtExchange) AmendOrder(txID *model.TransactionID, order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	pairString, e := order.Pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return nil, fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	side := "sell"
+	if order.OrderAction.IsBuy() {
+		side = "buy"
+	}
+
+	log.Printf("ccxt is amending order: ID=%s, pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
+		txID.String(), pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+
+	var maybeExchangeSpecificParams interface{}
+	if c.esParamFactory != nil {
+		maybeExchangeSpecificParams = c.esParamFactory.getParamsForAddOrder(submitMode)
+	}
+	ccxtOpenOrder, e := c.api.EditLimitOrder(txID.String(), pairString, side, order.Volume.AsFloat(), order.Price.AsFloat(), maybeExchangeSpecificParams)
+	if e != nil {
+		return nil, fmt.Errorf("error while amending order '%s' to %s: %s", txID.String(), *order, e)
+	}
+
+	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
+}
+
 // PrepareDeposit impl
 func (c ccxtExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
-	// TODO implement
-	return nil, nil
+	code, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, e
+	}
+
+	currency, e := c.api.FetchCurrency(code)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching currency info for deposit of %s: %s", code, e)
+	}
+	if currency.Limits.Deposit.Max != nil && *currency.Limits.Deposit.Max < amount.AsFloat() {
+		return nil, api.MakeErrDepositAmountAboveLimit(amount, model.NumberFromFloat(*currency.Limits.Deposit.Max, amount.Precision()))
+	}
+
+	depositAddress, e := c.api.FetchDepositAddress(code)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching deposit address for %s: %s", code, e)
+	}
+
+	// ccxt does not give us deposit fees or an expiry for deposit addresses
+	return &api.PrepareDepositResult{
+		Fee:      nil,
+		Address:  depositAddress.Address,
+		Memo:     depositAddress.Tag,
+		ExpireTs: 0,
+	}, nil
 }
 
 // GetWithdrawInfo impl
 func (c ccxtExchange) GetWithdrawInfo(asset model.Asset, amountToWithdraw *model.Number, address string) (*api.WithdrawInfo, error) {
-	// TODO implement
-	return nil, nil
+	code, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, e
+	}
+
+	currency, e := c.api.FetchCurrency(code)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching currency info for withdrawal of %s: %s", code, e)
+	}
+	return makeCcxtWithdrawInfo(currency, amountToWithdraw)
 }
 
-// WithdrawFunds impl
+// makeCcxtWithdrawInfo checks the withdrawal against the limits and fee of the currency, with the same semantics as Kraken
+func makeCcxtWithdrawInfo(currency *sdk.CcxtCurrency, amountToWithdraw *model.Number) (*api.WithdrawInfo, error) {
+	precision := amountToWithdraw.Precision()
+	if currency.Active != nil && !*currency.Active {
+		return nil, fmt.Errorf("currency '%s' is not active on the exchange", currency.Code)
+	}
+
+	limits := currency.Limits.Withdraw
+	if limits.Max != nil && *limits.Max < amountToWithdraw.AsFloat() {
+		return nil, api.MakeErrWithdrawAmountAboveLimit(amountToWithdraw, model.NumberFromFloat(*limits.Max, precision))
+	}
+	if limits.Min != nil && *limits.Min > amountToWithdraw.AsFloat() {
+		return nil, fmt.Errorf("withdraw amount (%s) is less than the minimum (%s)", amountToWithdraw.AsString(), model.NumberFromFloat(*limits.Min, precision).AsString())
+	}
+
+	if currency.Fee == nil {
+		// fee is unknown so the best estimate we have is that we receive the full amount
+		return &api.WithdrawInfo{AmountToReceive: amountToWithdraw}, nil
+	}
+	fee := model.NumberFromFloat(*currency.Fee, precision)
+	if fee.AsFloat() >= amountToWithdraw.AsFloat() {
+		return nil, api.MakeErrWithdrawAmountInvalid(amountToWithdraw, fee)
+	}
+	return &api.WithdrawInfo{AmountToReceive: amountToWithdraw.Subtract(*fee)}, nil
+}
+
+// WithdrawFunds impl, use WithdrawFundsWithTag for assets that need a tag or memo
 func (c ccxtExchange) WithdrawFunds(
 	asset model.Asset,
 	amountToWithdraw *model.Number,
 	address string,
 ) (*api.WithdrawFunds, error) {
-	// TODO implement
-	return nil, nil
+	return c.WithdrawFundsWithTag(asset, amountToWithdraw, address, "")
+}
+
+// WithdrawFundsWithTag impl
+func (c ccxtExchange) WithdrawFundsWithTag(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+	tag string,
+) (*api.WithdrawFunds, error) {
+	if c.simMode {
+		return nil, fmt.Errorf("cannot withdraw funds when running in simulation mode")
+	}
+
+	code, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, e
+	}
+
+	// check limits before withdrawing since ccxt does not check them for most exchanges
+	_, e = c.GetWithdrawInfo(asset, amountToWithdraw, address)
+	if e != nil {
+		return nil, e
+	}
+
+	log.Printf("ccxt is withdrawing funds: asset=%s, amount=%s, address=%s, tag=%s\n", code, amountToWithdraw.AsString(), address, tag)
+	withdrawal, e := c.api.Withdraw(code, amountToWithdraw.AsFloat(), address, tag)
+	if e != nil {
+		return nil, fmt.Errorf("error while withdrawing %s %s: %s", amountToWithdraw.AsString(), code, e)
+	}
+
+	return &api.WithdrawFunds{
+		WithdrawalID: withdrawal.ID,
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
 )
 
 /****************************** COINBASE PRO ******************************/
@@ -35,6 +36,19 @@ func (f *ccxtExchangeSpecificParamFactoryCoinbasepro) useSignToDenoteSideForTrad
 	return false
 }
 
+// coinbasepro places stop orders as limit orders with a stop param, which is "loss" for sell orders that trigger when the price falls
+// to the stop price and "entry" for buy orders that trigger when the price rises to the stop price
+func (f *ccxtExchangeSpecificParamFactoryCoinbasepro) getParamsForStopLimitOrder(order *model.Order) (string, map[string]interface{}, error) {
+	stop := "loss"
+	if order.OrderAction.IsBuy() {
+		stop = "entry"
+	}
+	return "limit", map[string]interface{}{
+		"stop":       stop,
+		"stop_price": order.StopPrice.AsString(),
+	}, nil
+}
+
 var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryCoinbasepro{}
 
 /****************************** BINANCE ******************************/
@@ -110,6 +124,13 @@ func (f *ccxtExchangeSpecificParamFactoryBinance) useSignToDenoteSideForTrades()
 	return false
 }
 
+// binance has a native STOP_LOSS_LIMIT order type that takes the trigger price in the stopPrice param
+func (f *ccxtExchangeSpecificParamFactoryBinance) getParamsForStopLimitOrder(order *model.Order) (string, map[string]interface{}, error) {
+	return "STOP_LOSS_LIMIT", map[string]interface{}{
+		"stopPrice": order.StopPrice.AsFloat(),
+	}, nil
+}
+
 var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryBinance{}
 
 /****************************** BITSTAMP ******************************/
@@ -138,4 +159,8 @@ func (f *ccxtExchangeSpecificParamFactoryBitstamp) useSignToDenoteSideForTrades(
 	return true
 }
 
+func (f *ccxtExchangeSpecificParamFactoryBitstamp) getParamsForStopLimitOrder(order *model.Order) (string, map[string]interface{}, error) {
+	return "", nil, fmt.Errorf("stop-limit orders are not supported on bitstamp via ccxt")
+}
+
 var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryBitstamp{}
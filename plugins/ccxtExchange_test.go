@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
 )
 
 type exchangeAuthData struct {
@@ -612,3 +614,169 @@ func TestGetOrderConstraints_Ccxt_Precision(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeCcxtWithdrawInfo(t *testing.T) {
+	float64Ptr := func(f float64) *float64 { return &f }
+	makeCurrency := func(fee *float64, min *float64, max *float64) *sdk.CcxtCurrency {
+		c := &sdk.CcxtCurrency{Code: "XLM", Fee: fee}
+		c.Limits.Withdraw.Min = min
+		c.Limits.Withdraw.Max = max
+		return c
+	}
+	inactive := false
+
+	testCases := []struct {
+		name                string
+		currency            *sdk.CcxtCurrency
+		amount              *model.Number
+		wantAmountToReceive float64
+		wantErrPrefix       string
+	}{
+		{
+			name:                "no fee and no limits",
+			currency:            makeCurrency(nil, nil, nil),
+			amount:              model.NumberFromFloat(100.0, 7),
+			wantAmountToReceive: 100.0,
+		}, {
+			name:                "fee is deducted",
+			currency:            makeCurrency(float64Ptr(0.01), float64Ptr(1.0), float64Ptr(1000.0)),
+			amount:              model.NumberFromFloat(100.0, 7),
+			wantAmountToReceive: 99.99,
+		}, {
+			name:          "above limit",
+			currency:      makeCurrency(float64Ptr(0.01), nil, float64Ptr(50.0)),
+			amount:        model.NumberFromFloat(100.0, 7),
+			wantErrPrefix: "withdraw amount (100.0000000) is greater than limit",
+		}, {
+			name:          "below minimum",
+			currency:      makeCurrency(nil, float64Ptr(200.0), nil),
+			amount:        model.NumberFromFloat(100.0, 7),
+			wantErrPrefix: "withdraw amount (100.0000000) is less than the minimum",
+		}, {
+			name:          "fee greater than amount",
+			currency:      makeCurrency(float64Ptr(0.5), nil, nil),
+			amount:        model.NumberFromFloat(0.5, 7),
+			wantErrPrefix: "amountToWithdraw is invalid",
+		}, {
+			name: "inactive currency",
+			currency: &sdk.CcxtCurrency{
+				Code:   "XLM",
+				Active: &inactive,
+			},
+			amount:        model.NumberFromFloat(100.0, 7),
+			wantErrPrefix: "currency 'XLM' is not active",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			withdrawInfo, e := makeCcxtWithdrawInfo(k.currency, k.amount)
+			if k.wantErrPrefix != "" {
+				if assert.Error(t, e) {
+					assert.True(t, strings.HasPrefix(e.Error(), k.wantErrPrefix), e.Error())
+				}
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantAmountToReceive, withdrawInfo.AmountToReceive.AsFloat(), 0.0000001)
+		})
+	}
+}
+
+func TestWithParam(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params interface{}
+		want   interface{}
+	}{
+		{
+			name:   "nil params",
+			params: nil,
+			want:   map[string]interface{}{"client_oid": "42"},
+		}, {
+			name:   "existing params",
+			params: map[string]interface{}{"post_only": true},
+			want:   map[string]interface{}{"post_only": true, "client_oid": "42"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			params, e := withParam(k.params, "client_oid", "42")
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, params)
+		})
+	}
+
+	// params that are not a map cannot hold the clientOrderId
+	_, e := withParam([]string{"post_only"}, "client_oid", "42")
+	assert.Error(t, e)
+}
+
+func TestClientOrderID2UUID(t *testing.T) {
+	for _, k := range []struct {
+		clientOrderID string
+		want          string
+	}{
+		{"1", "00000000-0000-4000-8000-000000000001"},
+		{"2147483647", "00000000-0000-4000-8000-002147483647"},
+		{"18446744073709551615", "18446744-0000-4000-8000-073709551615"},
+	} {
+		t.Run(k.clientOrderID, func(t *testing.T) {
+			assert.Equal(t, k.want, clientOrderID2UUID(k.clientOrderID))
+		})
+	}
+}
+
+func TestCcxtOrderArgs(t *testing.T) {
+	price := model.NumberFromFloat(0.12, 5)
+	stopPrice := model.NumberFromFloat(0.11, 5)
+	testCases := []struct {
+		name           string
+		orderType      model.OrderType
+		orderAction    model.OrderAction
+		submitMode     api.SubmitMode
+		esParamFactory ccxtExchangeSpecificParamFactory
+		stopPrice      *model.Number
+		wantOrderType  string
+		wantPrice      *float64
+		wantParams     interface{}
+		wantErr        bool
+	}{
+		{"market", model.OrderTypeMarket, model.OrderActionBuy, api.SubmitModeBoth, nil, nil, "market", nil, nil, false},
+		{"market maker only", model.OrderTypeMarket, model.OrderActionBuy, api.SubmitModeMakerOnly, nil, nil, "", nil, nil, true},
+		{"limit", model.OrderTypeLimit, model.OrderActionBuy, api.SubmitModeBoth, nil, nil, "limit", &[]float64{0.12}[0], nil, false},
+		{"ioc", model.OrderTypeImmediateOrCancel, model.OrderActionBuy, api.SubmitModeBoth, nil, nil, "limit", &[]float64{0.12}[0], map[string]interface{}{"timeInForce": "IOC"}, false},
+		{"stop-limit binance", model.OrderTypeStopLimit, model.OrderActionSell, api.SubmitModeBoth, makeCcxtExchangeSpecificParamFactoryBinance(), stopPrice, "STOP_LOSS_LIMIT", &[]float64{0.12}[0], map[string]interface{}{"stopPrice": 0.11}, false},
+		{"stop-limit coinbasepro sell", model.OrderTypeStopLimit, model.OrderActionSell, api.SubmitModeBoth, &ccxtExchangeSpecificParamFactoryCoinbasepro{}, stopPrice, "limit", &[]float64{0.12}[0], map[string]interface{}{"stop": "loss", "stop_price": "0.11000"}, false},
+		{"stop-limit coinbasepro buy", model.OrderTypeStopLimit, model.OrderActionBuy, api.SubmitModeBoth, &ccxtExchangeSpecificParamFactoryCoinbasepro{}, stopPrice, "limit", &[]float64{0.12}[0], map[string]interface{}{"stop": "entry", "stop_price": "0.11000"}, false},
+		{"stop-limit bitstamp", model.OrderTypeStopLimit, model.OrderActionSell, api.SubmitModeBoth, &ccxtExchangeSpecificParamFactoryBitstamp{}, stopPrice, "", nil, nil, true},
+		{"stop-limit without param factory", model.OrderTypeStopLimit, model.OrderActionSell, api.SubmitModeBoth, nil, stopPrice, "", nil, nil, true},
+		{"stop-limit without stop price", model.OrderTypeStopLimit, model.OrderActionSell, api.SubmitModeBoth, makeCcxtExchangeSpecificParamFactoryBinance(), nil, "", nil, nil, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			orderType, maybePrice, params, e := ccxtOrderArgs(&model.Order{
+				OrderAction: k.orderAction,
+				OrderType:   k.orderType,
+				Price:       price,
+				StopPrice:   k.stopPrice,
+			}, k.submitMode, k.esParamFactory, nil)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOrderType, orderType)
+			assert.Equal(t, k.wantPrice, maybePrice)
+			assert.Equal(t, k.wantParams, params)
+		})
+	}
+}
@@ -0,0 +1,909 @@
+package plugins
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// ensure that coinbaseproExchange conforms to the Exchange and BatchTradeAPI interfaces
+var _ api.Exchange = &coinbaseproExchange{}
+var _ api.BatchTradeAPI = &coinbaseproExchange{}
+
+const coinbaseproBaseURL = "https://api.exchange.coinbase.com"
+const coinbaseproPageLimit = 100
+const coinbaseproOpenOrdersLimit = 1000
+
+// coinbaseproExchange is the implementation for the Coinbase Pro (Coinbase Exchange) that talks to its REST API directly instead of via ccxt-rest
+type coinbaseproExchange struct {
+	baseURL            string
+	httpClient         *http.Client
+	apiKey             string
+	secret             []byte // base64-decoded API secret
+	passphrase         string
+	assetConverter     model.AssetConverterInterface
+	delimiter          string
+	ocOverridesHandler *OrderConstraintsOverridesHandler
+	isSimulated        bool // will simulate add and cancel orders if this is true
+
+	// order constraints of all products are loaded from the products endpoint when the exchange is made and not modified afterwards
+	ocByProductID map[string]*model.OrderConstraints
+}
+
+// makeCoinbaseproExchange is a factory method to make the coinbasepro exchange
+// the API passphrase is passed in the "password" exchange param, which is the same param used by ccxt-coinbasepro
+func makeCoinbaseproExchange(apiKeys []api.ExchangeAPIKey, exchangeParams []api.ExchangeParam, isSimulated bool) (api.Exchange, error) {
+	if len(apiKeys) != 1 {
+		return nil, fmt.Errorf("need exactly 1 ExchangeAPIKey, even if it is an empty key")
+	}
+
+	secret, e := base64.StdEncoding.DecodeString(apiKeys[0].Secret)
+	if e != nil {
+		return nil, fmt.Errorf("coinbasepro API secret should be base64-encoded: %s", e)
+	}
+
+	baseURL := coinbaseproBaseURL
+	passphrase := ""
+	for _, p := range exchangeParams {
+		v, ok := p.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("exchange param '%s' for coinbasepro needs to be a string, was of type %T", p.Param, p.Value)
+		}
+
+		switch p.Param {
+		case "password":
+			passphrase = v
+		case "base_url":
+			baseURL = strings.TrimSuffix(v, "/")
+		default:
+			return nil, fmt.Errorf("unsupported exchange param for coinbasepro: %s", p.Param)
+		}
+	}
+
+	c := &coinbaseproExchange{
+		baseURL:            baseURL,
+		httpClient:         http.DefaultClient,
+		apiKey:             apiKeys[0].Key,
+		secret:             secret,
+		passphrase:         passphrase,
+		assetConverter:     model.CoinbaseproAssetConverter,
+		delimiter:          "-",
+		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+		isSimulated:        isSimulated,
+	}
+	e = c.loadAllOrderConstraints()
+	if e != nil {
+		return nil, fmt.Errorf("could not load the order constraints for coinbasepro: %s", e)
+	}
+	return c, nil
+}
+
+// coinbaseproSignature is the base64-encoded HMAC-SHA256 of the prehash string using the decoded API secret
+func coinbaseproSignature(secret []byte, prehash string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(prehash))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// request makes a request to the Coinbase Pro API and returns the response headers, which hold the pagination cursors
+func (c *coinbaseproExchange) request(method string, path string, params url.Values, body interface{}, signed bool, responseData interface{}) (http.Header, error) {
+	requestPath := path
+	if len(params) > 0 {
+		requestPath = requestPath + "?" + params.Encode()
+	}
+
+	bodyString := ""
+	if body != nil {
+		bodyBytes, e := json.Marshal(body)
+		if e != nil {
+			return nil, fmt.Errorf("could not marshal request body: %s", e)
+		}
+		bodyString = string(bodyBytes)
+	}
+
+	req, e := http.NewRequest(method, c.baseURL+requestPath, strings.NewReader(bodyString))
+	if e != nil {
+		return nil, fmt.Errorf("could not create http request: %s", e)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signed {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("CB-ACCESS-KEY", c.apiKey)
+		req.Header.Set("CB-ACCESS-SIGN", coinbaseproSignature(c.secret, timestamp+method+requestPath+bodyString))
+		req.Header.Set("CB-ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("CB-ACCESS-PASSPHRASE", c.passphrase)
+	}
+
+	resp, e := c.httpClient.Do(req)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute http request: %s", e)
+	}
+	defer resp.Body.Close()
+
+	respBody, e := ioutil.ReadAll(resp.Body)
+	if e != nil {
+		return nil, fmt.Errorf("could not read http response: %s", e)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error in response (status=%d): %s", resp.StatusCode, string(respBody))
+	}
+
+	if responseData != nil {
+		e = json.Unmarshal(respBody, responseData)
+		if e != nil {
+			return nil, fmt.Errorf("could not unmarshall response body into json: %s | response body: %s", e, string(respBody))
+		}
+	}
+	return resp.Header, nil
+}
+
+func (c *coinbaseproExchange) productID(pair *model.TradingPair) (string, error) {
+	productID, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return "", fmt.Errorf("error converting pair to string: %s", e)
+	}
+	return productID, nil
+}
+
+// GetOrderConstraints impl
+func (c *coinbaseproExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	if c.ocOverridesHandler.IsCompletelyOverriden(pair) {
+		override := c.ocOverridesHandler.Get(pair)
+		return model.MakeOrderConstraintsFromOverride(override)
+	}
+
+	productID, e := c.productID(pair)
+	if e != nil {
+		// this should never really panic because we would have converted this trading pair to a string previously
+		panic(e)
+	}
+	oc, ok := c.ocByProductID[productID]
+	if !ok {
+		panic(fmt.Sprintf("coinbaseproExchange could not find orderConstraints for trading pair %v, the product '%s' was not listed by the products endpoint", pair, productID))
+	}
+	return c.ocOverridesHandler.Apply(pair, oc)
+}
+
+type coinbaseproProduct struct {
+	ID             string `json:"id"`
+	BaseIncrement  string `json:"base_increment"`
+	QuoteIncrement string `json:"quote_increment"`
+	BaseMinSize    string `json:"base_min_size"`
+	MinMarketFunds string `json:"min_market_funds"`
+}
+
+// loadAllOrderConstraints fetches the order constraints of all products in one request so we never need to make this request (and
+// handle its failure) when GetOrderConstraints is called during an update cycle
+func (c *coinbaseproExchange) loadAllOrderConstraints() error {
+	var products []coinbaseproProduct
+	_, e := c.request("GET", "/products", nil, nil, false, &products)
+	if e != nil {
+		return fmt.Errorf("error fetching products: %s", e)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("products endpoint did not list any products")
+	}
+
+	ocByProductID := map[string]*model.OrderConstraints{}
+	for _, product := range products {
+		oc, e := parseCoinbaseproOrderConstraints(product)
+		if e != nil {
+			return e
+		}
+		ocByProductID[product.ID] = oc
+	}
+	c.ocByProductID = ocByProductID
+	return nil
+}
+
+func parseCoinbaseproOrderConstraints(product coinbaseproProduct) (*model.OrderConstraints, error) {
+	productID := product.ID
+	var e error
+	// the min size fields are not always present, in which case there is no minimum
+	minBaseVolume := 0.0
+	if product.BaseMinSize != "" {
+		minBaseVolume, e = strconv.ParseFloat(product.BaseMinSize, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse base_min_size '%s' for product '%s': %s", product.BaseMinSize, productID, e)
+		}
+	}
+	minQuoteVolume := 0.0
+	if product.MinMarketFunds != "" {
+		minQuoteVolume, e = strconv.ParseFloat(product.MinMarketFunds, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse min_market_funds '%s' for product '%s': %s", product.MinMarketFunds, productID, e)
+		}
+	}
+
+	return model.MakeOrderConstraintsWithCost(
+		precisionFromStepSize(product.QuoteIncrement),
+		precisionFromStepSize(product.BaseIncrement),
+		minBaseVolume,
+		minQuoteVolume,
+	), nil
+}
+
+// OverrideOrderConstraints impl, can partially override values for specific pairs
+func (c *coinbaseproExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	c.ocOverridesHandler.Upsert(pair, override)
+}
+
+// GetAssetConverter impl.
+func (c *coinbaseproExchange) GetAssetConverter() model.AssetConverterInterface {
+	return c.assetConverter
+}
+
+// GetAccountBalances impl.
+func (c *coinbaseproExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	var accounts []struct {
+		Currency string `json:"currency"`
+		Balance  string `json:"balance"`
+	}
+	_, e := c.request("GET", "/accounts", nil, nil, true, &accounts)
+	if e != nil {
+		return nil, fmt.Errorf("error fetching accounts from coinbasepro: %s", e)
+	}
+
+	balances := map[string]float64{}
+	for _, a := range accounts {
+		bal, e := strconv.ParseFloat(a.Balance, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse balance '%s' for currency %s: %s", a.Balance, a.Currency, e)
+		}
+		balances[a.Currency] = bal
+	}
+
+	m := map[interface{}]model.Number{}
+	for _, elem := range assetList {
+		var asset model.Asset
+		if v, ok := elem.(model.Asset); ok {
+			asset = v
+		} else {
+			return nil, fmt.Errorf("invalid type of asset passed in, only model.Asset accepted")
+		}
+
+		coinbaseproAssetString, e := c.assetConverter.ToString(asset)
+		if e != nil {
+			return nil, e
+		}
+		m[asset] = *model.NumberFromFloat(balances[coinbaseproAssetString], precisionBalances)
+	}
+	return m, nil
+}
+
+// GetOrderBook impl.
+func (c *coinbaseproExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	productID, e := c.productID(pair)
+	if e != nil {
+		return nil, e
+	}
+
+	// levels are [price, size, num_orders] where num_orders is a number and the rest are strings
+	var book struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+	_, e = c.request("GET", "/products/"+productID+"/book", url.Values{"level": []string{"2"}}, nil, false, &book)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching orderbook for trading pair '%s': %s", productID, e)
+	}
+
+	asks, e := c.readOrders(book.Asks, int(maxCount), pair, model.OrderActionSell)
+	if e != nil {
+		return nil, fmt.Errorf("error reading asks: %s", e)
+	}
+	bids, e := c.readOrders(book.Bids, int(maxCount), pair, model.OrderActionBuy)
+	if e != nil {
+		return nil, fmt.Errorf("error reading bids: %s", e)
+	}
+	return model.MakeOrderBook(pair, asks, bids), nil
+}
+
+func (c *coinbaseproExchange) readOrders(levels [][]interface{}, maxCount int, pair *model.TradingPair, orderAction model.OrderAction) ([]model.Order, error) {
+	if len(levels) > maxCount {
+		levels = levels[:maxCount]
+	}
+
+	orderConstraints := c.GetOrderConstraints(pair)
+	orders := []model.Order{}
+	for _, level := range levels {
+		if len(level) < 2 {
+			return nil, fmt.Errorf("orderbook level should have a price and a size: %v", level)
+		}
+		priceString, ok := level[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("price should be a string: %v", level)
+		}
+		sizeString, ok := level[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("size should be a string: %v", level)
+		}
+
+		price, e := model.NumberFromString(priceString, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", priceString, e)
+		}
+		volume, e := model.NumberFromString(sizeString, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse size '%s': %s", sizeString, e)
+		}
+
+		orders = append(orders, model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   nil,
+		})
+	}
+	return orders, nil
+}
+
+// GetTickerPrice impl.
+func (c *coinbaseproExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	priceResult := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		pair := p
+		productID, e := c.productID(&pair)
+		if e != nil {
+			return nil, e
+		}
+
+		var ticker struct {
+			Price string `json:"price"`
+			Bid   string `json:"bid"`
+			Ask   string `json:"ask"`
+		}
+		_, e = c.request("GET", "/products/"+productID+"/ticker", nil, nil, false, &ticker)
+		if e != nil {
+			return nil, fmt.Errorf("error fetching ticker for product '%s': %s", productID, e)
+		}
+
+		pricePrecision := c.GetOrderConstraints(&pair).PricePrecision
+		askPrice, e := model.NumberFromString(ticker.Ask, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse ask '%s': %s", ticker.Ask, e)
+		}
+		bidPrice, e := model.NumberFromString(ticker.Bid, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse bid '%s': %s", ticker.Bid, e)
+		}
+		lastPrice, e := model.NumberFromString(ticker.Price, pricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", ticker.Price, e)
+		}
+
+		priceResult[pair] = api.Ticker{
+			AskPrice:  askPrice,
+			BidPrice:  bidPrice,
+			LastPrice: lastPrice,
+		}
+	}
+	return priceResult, nil
+}
+
+// coinbaseproTrade is either a fill from the /fills endpoint or a public trade from the /products/<id>/trades endpoint
+type coinbaseproTrade struct {
+	TradeID   int64  `json:"trade_id"`
+	ProductID string `json:"product_id"` // fills only
+	OrderID   string `json:"order_id"`   // fills only
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	Fee       string `json:"fee"` // fills only
+	Side      string `json:"side"`
+	CreatedAt string `json:"created_at"` // fills only
+	Time      string `json:"time"`       // public trades only
+}
+
+func (t coinbaseproTrade) timestampMillis() (int64, error) {
+	ts := t.CreatedAt
+	if ts == "" {
+		ts = t.Time
+	}
+	parsed, e := time.Parse(time.RFC3339Nano, ts)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse time '%s' of trade %d: %s", ts, t.TradeID, e)
+	}
+	return parsed.UnixNano() / int64(time.Millisecond), nil
+}
+
+// coinbaseproTimeCursorPrefix marks a cursor that holds a unix time in milliseconds instead of a trade_id
+const coinbaseproTimeCursorPrefix = "time:"
+
+// coinbaseproCursor is a position in the trades of a product. Trades are paged by their trade_id, which increases monotonically within a
+// product, so trades that happen in the same millisecond are neither skipped nor repeated. GetLatestTradeCursor is not given a product
+// so it cannot know a trade_id, it makes a time cursor (prefixed with "time:") that is replaced by a trade_id cursor once trades are fetched.
+type coinbaseproCursor struct {
+	tradeID *int64
+	millis  *int64
+}
+
+func parseCoinbaseproCursor(maybeCursor interface{}) (*coinbaseproCursor, error) {
+	if maybeCursor == nil {
+		return nil, nil
+	}
+	s, ok := maybeCursor.(string)
+	if !ok {
+		return nil, fmt.Errorf("cursor needs to be a string but was of type %T", maybeCursor)
+	}
+
+	if strings.HasPrefix(s, coinbaseproTimeCursorPrefix) {
+		millis, e := strconv.ParseInt(strings.TrimPrefix(s, coinbaseproTimeCursorPrefix), 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse time cursor '%s': %s", s, e)
+		}
+		return &coinbaseproCursor{millis: &millis}, nil
+	}
+
+	tradeID, e := strconv.ParseInt(s, 10, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse trade_id cursor '%s': %s", s, e)
+	}
+	return &coinbaseproCursor{tradeID: &tradeID}, nil
+}
+
+// isAfterStart returns true if the trade should be included when this cursor is the start cursor, a trade_id cursor excludes the
+// trade it points to since that is the last trade that was already returned
+func (cursor *coinbaseproCursor) isAfterStart(t coinbaseproTrade) (bool, error) {
+	if cursor.tradeID != nil {
+		return t.TradeID > *cursor.tradeID, nil
+	}
+	tsMillis, e := t.timestampMillis()
+	if e != nil {
+		return false, e
+	}
+	return tsMillis >= *cursor.millis, nil
+}
+
+// isBeforeEnd returns true if the trade should be included when this cursor is the end cursor
+func (cursor *coinbaseproCursor) isBeforeEnd(t coinbaseproTrade) (bool, error) {
+	if cursor.tradeID != nil {
+		return t.TradeID <= *cursor.tradeID, nil
+	}
+	tsMillis, e := t.timestampMillis()
+	if e != nil {
+		return false, e
+	}
+	return tsMillis <= *cursor.millis, nil
+}
+
+// fetchTradesSince pages through trades from newest to oldest by following the CB-AFTER cursor until it reaches trades before maybeStart.
+// Only the most recent page is fetched when maybeStart is nil.
+func (c *coinbaseproExchange) fetchTradesSince(path string, params url.Values, signed bool, maybeStart *coinbaseproCursor) ([]coinbaseproTrade, error) {
+	params.Set("limit", strconv.Itoa(coinbaseproPageLimit))
+
+	result := []coinbaseproTrade{}
+	for {
+		var page []coinbaseproTrade
+		headers, e := c.request("GET", path, params, nil, signed, &page)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, page...)
+
+		if maybeStart == nil || len(page) < coinbaseproPageLimit {
+			return result, nil
+		}
+		isOldestAfterStart, e := maybeStart.isAfterStart(page[len(page)-1])
+		if e != nil {
+			return nil, e
+		}
+		if !isOldestAfterStart {
+			return result, nil
+		}
+
+		after := headers.Get("CB-AFTER")
+		if after == "" {
+			return result, nil
+		}
+		params.Set("after", after)
+	}
+}
+
+// readTrades converts the raw trades that lie within the passed in cursors, the side of public trades is the side of the maker so it is inverted
+func (c *coinbaseproExchange) readTrades(pair *model.TradingPair, rawTrades []coinbaseproTrade, isPublic bool, maybeStart *coinbaseproCursor, maybeEnd *coinbaseproCursor) ([]model.Trade, error) {
+	orderConstraints := c.GetOrderConstraints(pair)
+	// use bigger precision for fee and cost since they are logically derived from amount and price
+	feeCostPrecision := orderConstraints.PricePrecision
+	if orderConstraints.VolumePrecision > feeCostPrecision {
+		feeCostPrecision = orderConstraints.VolumePrecision
+	}
+
+	trades := []model.Trade{}
+	for _, raw := range rawTrades {
+		tsMillis, e := raw.timestampMillis()
+		if e != nil {
+			return nil, e
+		}
+		if maybeStart != nil {
+			isAfterStart, e := maybeStart.isAfterStart(raw)
+			if e != nil {
+				return nil, e
+			}
+			if !isAfterStart {
+				continue
+			}
+		}
+		if maybeEnd != nil {
+			isBeforeEnd, e := maybeEnd.isBeforeEnd(raw)
+			if e != nil {
+				return nil, e
+			}
+			if !isBeforeEnd {
+				continue
+			}
+		}
+
+		price, e := model.NumberFromString(raw.Price, orderConstraints.PricePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price '%s': %s", raw.Price, e)
+		}
+		volume, e := model.NumberFromString(raw.Size, orderConstraints.VolumePrecision)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse size '%s': %s", raw.Size, e)
+		}
+		fee := model.NumberFromFloat(0, feeCostPrecision)
+		if raw.Fee != "" {
+			fee, e = model.NumberFromString(raw.Fee, feeCostPrecision)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse fee '%s': %s", raw.Fee, e)
+			}
+		}
+
+		var orderAction model.OrderAction
+		if raw.Side == "buy" {
+			orderAction = model.OrderActionBuy
+		} else if raw.Side == "sell" {
+			orderAction = model.OrderActionSell
+		} else {
+			return nil, fmt.Errorf("unrecognized value for 'side' field: %s (raw = %+v)", raw.Side, raw)
+		}
+		if isPublic {
+			orderAction = orderAction.Reverse()
+		}
+
+		trades = append(trades, model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: orderAction,
+				OrderType:   model.OrderTypeLimit,
+				Price:       price,
+				Volume:      volume,
+				Timestamp:   model.MakeTimestamp(tsMillis),
+			},
+			TransactionID: model.MakeTransactionID(strconv.FormatInt(raw.TradeID, 10)),
+			Cost:          model.NumberFromFloat(price.AsFloat()*volume.AsFloat(), feeCostPrecision),
+			Fee:           fee,
+			OrderID:       raw.OrderID,
+		})
+	}
+
+	sort.Sort(model.TradesByTsID(trades))
+	return trades, nil
+}
+
+// nextTradeIDCursor is the trade_id of the newest trade, or the passed in cursor when there were no trades
+func nextTradeIDCursor(trades []model.Trade, maybeCursor interface{}) (interface{}, error) {
+	if len(trades) == 0 {
+		return maybeCursor, nil
+	}
+
+	// the TransactionID of the converted trades is the trade_id
+	var maxTradeID int64
+	for _, t := range trades {
+		tradeID, e := strconv.ParseInt(t.TransactionID.String(), 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse trade_id from TransactionID '%s': %s", t.TransactionID.String(), e)
+		}
+		if tradeID > maxTradeID {
+			maxTradeID = tradeID
+		}
+	}
+	return strconv.FormatInt(maxTradeID, 10), nil
+}
+
+// GetTradeHistory impl, the cursor is the trade_id of the last fill that was returned (see coinbaseproCursor)
+func (c *coinbaseproExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	productID, e := c.productID(&pair)
+	if e != nil {
+		return nil, e
+	}
+	start, e := parseCoinbaseproCursor(maybeCursorStart)
+	if e != nil {
+		return nil, fmt.Errorf("invalid start cursor: %s", e)
+	}
+	end, e := parseCoinbaseproCursor(maybeCursorEnd)
+	if e != nil {
+		return nil, fmt.Errorf("invalid end cursor: %s", e)
+	}
+
+	rawFills, e := c.fetchTradesSince("/fills", url.Values{"product_id": []string{productID}}, true, start)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching trade history for trading pair '%s': %s", productID, e)
+	}
+	for _, f := range rawFills {
+		if f.ProductID != productID {
+			return nil, fmt.Errorf("expected '%s' for 'product_id' field, got: %s", productID, f.ProductID)
+		}
+	}
+
+	trades, e := c.readTrades(&pair, rawFills, false, start, end)
+	if e != nil {
+		return nil, fmt.Errorf("error while reading fills: %s", e)
+	}
+	nextCursor, e := nextTradeIDCursor(trades, maybeCursorStart)
+	if e != nil {
+		return nil, fmt.Errorf("error while making the next cursor: %s", e)
+	}
+
+	return &api.TradeHistoryResult{
+		Cursor: nextCursor,
+		Trades: trades,
+	}, nil
+}
+
+// GetLatestTradeCursor impl., this is a time cursor since we do not know the product here (see coinbaseproCursor)
+func (c *coinbaseproExchange) GetLatestTradeCursor() (interface{}, error) {
+	timeNowMillis := time.Now().UnixNano() / int64(time.Millisecond)
+	latestTradeCursor := fmt.Sprintf("%s%d", coinbaseproTimeCursorPrefix, timeNowMillis)
+	return latestTradeCursor, nil
+}
+
+// GetTrades impl, the cursor is the trade_id of the last public trade that was returned (see coinbaseproCursor)
+func (c *coinbaseproExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	productID, e := c.productID(pair)
+	if e != nil {
+		return nil, e
+	}
+	start, e := parseCoinbaseproCursor(maybeCursor)
+	if e != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", e)
+	}
+
+	rawTrades, e := c.fetchTradesSince("/products/"+productID+"/trades", url.Values{}, false, start)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching trades for trading pair '%s': %s", productID, e)
+	}
+
+	trades, e := c.readTrades(pair, rawTrades, true, start, nil)
+	if e != nil {
+		return nil, fmt.Errorf("error while reading trades: %s", e)
+	}
+	nextCursor, e := nextTradeIDCursor(trades, maybeCursor)
+	if e != nil {
+		return nil, fmt.Errorf("error while making the next cursor: %s", e)
+	}
+
+	return &api.TradesResult{
+		Cursor: nextCursor,
+		Trades: trades,
+	}, nil
+}
+
+type coinbaseproOrder struct {
+	ID         string `json:"id"`
+	Price      string `json:"price"`
+	Size       string `json:"size"`
+	ProductID  string `json:"product_id"`
+	Side       string `json:"side"`
+	Type       string `json:"type"`
+	CreatedAt  string `json:"created_at"`
+	FilledSize string `json:"filled_size"`
+	Status     string `json:"status"`
+}
+
+// GetOpenOrders impl.
+func (c *coinbaseproExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	result := map[model.TradingPair][]model.OpenOrder{}
+	for _, pair := range pairs {
+		productID, e := c.productID(pair)
+		if e != nil {
+			return nil, e
+		}
+
+		var rawOrders []coinbaseproOrder
+		_, e = c.request("GET", "/orders", url.Values{
+			"status":     []string{"open"},
+			"product_id": []string{productID},
+			"limit":      []string{strconv.Itoa(coinbaseproOpenOrdersLimit)},
+		}, nil, true, &rawOrders)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching open orders for trading pair '%s': %s", productID, e)
+		}
+
+		openOrderList := []model.OpenOrder{}
+		for _, o := range rawOrders {
+			openOrder, e := c.convertOpenOrder(pair, productID, o)
+			if e != nil {
+				return nil, fmt.Errorf("cannot convertOpenOrder: %s", e)
+			}
+			openOrderList = append(openOrderList, *openOrder)
+		}
+		result[*pair] = openOrderList
+	}
+	return result, nil
+}
+
+func (c *coinbaseproExchange) convertOpenOrder(pair *model.TradingPair, productID string, o coinbaseproOrder) (*model.OpenOrder, error) {
+	if o.ProductID != productID {
+		return nil, fmt.Errorf("expected '%s' for 'product_id' field, got: %s", productID, o.ProductID)
+	}
+	if o.Type != "limit" {
+		return nil, fmt.Errorf("we currently only support limit order types: %+v", o)
+	}
+
+	orderConstraints := c.GetOrderConstraints(pair)
+	price, e := model.NumberFromString(o.Price, orderConstraints.PricePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse price '%s': %s", o.Price, e)
+	}
+	volume, e := model.NumberFromString(o.Size, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse size '%s': %s", o.Size, e)
+	}
+	volumeExecuted, e := model.NumberFromString(o.FilledSize, orderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse filled_size '%s': %s", o.FilledSize, e)
+	}
+	createdAt, e := time.Parse(time.RFC3339Nano, o.CreatedAt)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse created_at '%s': %s", o.CreatedAt, e)
+	}
+
+	orderAction := model.OrderActionSell
+	if o.Side == "buy" {
+		orderAction = model.OrderActionBuy
+	}
+	ts := model.MakeTimestamp(createdAt.UnixNano() / int64(time.Millisecond))
+
+	return &model.OpenOrder{
+		Order: model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       price,
+			Volume:      volume,
+			Timestamp:   ts,
+		},
+		ID:             o.ID,
+		StartTime:      ts,
+		ExpireTime:     nil,
+		VolumeExecuted: volumeExecuted,
+	}, nil
+}
+
+// AddOrder impl.
+func (c *coinbaseproExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	productID, e := c.productID(order.Pair)
+	if e != nil {
+		return nil, e
+	}
+
+	if c.isSimulated {
+		log.Printf("not adding order to Coinbase Pro in simulation mode, order=%s\n", *order)
+		return model.MakeTransactionID("simulated"), nil
+	}
+
+	if !order.OrderType.IsLimit() {
+		return nil, fmt.Errorf("coinbasepro adapter only supports limit orders, got: %s", order.OrderType.String())
+	}
+
+	orderConstraints := c.GetOrderConstraints(order.Pair)
+	if order.Price.Precision() > orderConstraints.PricePrecision {
+		return nil, fmt.Errorf("coinbasepro price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.Price.Precision(), order.Price.AsFloat())
+	}
+	if order.Volume.Precision() > orderConstraints.VolumePrecision {
+		return nil, fmt.Errorf("coinbasepro volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
+	}
+
+	body := map[string]interface{}{
+		"product_id": productID,
+		"side":       order.OrderAction.String(),
+		"type":       "limit",
+		"price":      order.Price.AsString(),
+		"size":       order.Volume.AsString(),
+		"post_only":  submitMode == api.SubmitModeMakerOnly,
+	}
+	log.Printf("coinbasepro is submitting order: productID=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
+		productID, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+
+	var resp coinbaseproOrder
+	_, e = c.request("POST", "/orders", nil, body, true, &resp)
+	if e != nil {
+		return nil, fmt.Errorf("error while adding order: %s", e)
+	}
+	if resp.Status == "rejected" {
+		return nil, fmt.Errorf("order was rejected: %+v", resp)
+	}
+	if resp.ID == "" {
+		return nil, fmt.Errorf("no id returned from order creation")
+	}
+	return model.MakeTransactionID(resp.ID), nil
+}
+
+// CancelOrder impl.
+func (c *coinbaseproExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if c.isSimulated {
+		return model.CancelResultCancelSuccessful, nil
+	}
+
+	productID, e := c.productID(&pair)
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	log.Printf("coinbasepro is canceling order: ID=%s, tradingPair=%s\n", txID.String(), pair.String())
+
+	_, e = c.request("DELETE", "/orders/"+url.PathEscape(txID.String()), url.Values{"product_id": []string{productID}}, nil, true, nil)
+	if e != nil {
+		return model.CancelResultFailed, fmt.Errorf("error while cancelling order: %s", e)
+	}
+	return model.CancelResultCancelSuccessful, nil
+}
+
+// AddOrders impl, coinbasepro does not have a batch endpoint for adding orders so this is the generic fallback that makes one AddOrder request per order in parallel
+func (c *coinbaseproExchange) AddOrders(orders []*model.Order, submitMode api.SubmitMode) ([]api.BatchAddResult, error) {
+	return addOrdersParallel(c, orders, submitMode), nil
+}
+
+// CancelOrders impl, coinbasepro does not have a batch endpoint for cancelling specific orders so this is the generic fallback that makes one CancelOrder request per order in parallel
+func (c *coinbaseproExchange) CancelOrders(txIDs []*model.TransactionID, pair model.TradingPair) ([]api.BatchCancelResult, error) {
+	return cancelOrdersParallel(c, txIDs, pair), nil
+}
+
+// CancelAllOrders impl, this is the only call that is a single batch request on coinbasepro
+func (c *coinbaseproExchange) CancelAllOrders(pair model.TradingPair) error {
+	if c.isSimulated {
+		return nil
+	}
+
+	productID, e := c.productID(&pair)
+	if e != nil {
+		return e
+	}
+	log.Printf("coinbasepro is canceling all orders: tradingPair=%s\n", pair.String())
+
+	var cancelledIDs []string
+	_, e = c.request("DELETE", "/orders", url.Values{"product_id": []string{productID}}, nil, true, &cancelledIDs)
+	if e != nil {
+		return fmt.Errorf("error while cancelling all orders: %s", e)
+	}
+	log.Printf("coinbasepro cancelled %d orders\n", len(cancelledIDs))
+	return nil
+}
+
+// GetWithdrawInfo impl.
+func (c *coinbaseproExchange) GetWithdrawInfo(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawInfo, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the native coinbasepro adapter yet, use ccxt-coinbasepro instead")
+}
+
+// PrepareDeposit impl.
+func (c *coinbaseproExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	return nil, fmt.Errorf("deposits are not supported by the native coinbasepro adapter yet, use ccxt-coinbasepro instead")
+}
+
+// WithdrawFunds impl.
+func (c *coinbaseproExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	return nil, fmt.Errorf("withdrawals are not supported by the native coinbasepro adapter yet, use ccxt-coinbasepro instead")
+}
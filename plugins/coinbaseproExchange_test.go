@@ -0,0 +1,642 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+var testCoinbaseproSecret = []byte("testSecret")
+var testCoinbaseproPassphrase = "testPassphrase"
+
+// coinbaseproStandIn is a local stand-in for the Coinbase Pro REST API that keeps its orders in memory
+type coinbaseproStandIn struct {
+	t           *testing.T
+	products    map[string]string // product ID -> product json
+	trades      []coinbaseproTrade
+	fills       []coinbaseproTrade
+	orders      map[string]coinbaseproOrder
+	nextOrderID int
+	mutex       *sync.Mutex
+}
+
+func makeCoinbaseproStandIn(t *testing.T) *coinbaseproStandIn {
+	s := &coinbaseproStandIn{
+		t: t,
+		products: map[string]string{
+			"XLM-BTC": `{"id":"XLM-BTC","base_currency":"XLM","quote_currency":"BTC","base_min_size":"1","base_increment":"1","quote_increment":"0.00000001","min_market_funds":"0.001"}`,
+			"XLM-USD": `{"id":"XLM-USD","base_currency":"XLM","quote_currency":"USD","base_min_size":"1","base_increment":"1","quote_increment":"0.000001","min_market_funds":"1"}`,
+		},
+		orders: map[string]coinbaseproOrder{
+			"d0c5340b-6d6c-49d9-b567-48c4bfca13d2": {
+				ID:         "d0c5340b-6d6c-49d9-b567-48c4bfca13d2",
+				Price:      "0.00001200",
+				Size:       "100.00000000",
+				ProductID:  "XLM-BTC",
+				Side:       "sell",
+				Type:       "limit",
+				CreatedAt:  "2019-11-20T09:15:42.123456Z",
+				FilledSize: "25.00000000",
+				Status:     "open",
+			},
+		},
+		nextOrderID: 1,
+		mutex:       &sync.Mutex{},
+	}
+
+	// 150 public trades, one per second with the newest first, so GetTrades has to page through them
+	newest := time.Date(2019, 11, 20, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		side := "buy"
+		if i%2 == 0 {
+			side = "sell"
+		}
+		s.trades = append(s.trades, coinbaseproTrade{
+			TradeID: int64(1000 - i),
+			Price:   fmt.Sprintf("0.0000%d", 1100+i),
+			Size:    "25.00000000",
+			Side:    side,
+			Time:    newest.Add(-time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+		})
+	}
+	s.fills = []coinbaseproTrade{
+		{TradeID: 74, ProductID: "XLM-BTC", OrderID: "a9625b04-fc66-4999-a876-543c3684d702", Price: "0.00001100", Size: "40.00000000", Fee: "0.00000000", Side: "buy", CreatedAt: "2019-11-20T09:30:00.000Z"},
+		{TradeID: 73, ProductID: "XLM-BTC", OrderID: "d0c5340b-6d6c-49d9-b567-48c4bfca13d2", Price: "0.00001200", Size: "25.00000000", Fee: "0.00000150", Side: "sell", CreatedAt: "2019-11-20T09:20:00.000Z"},
+	}
+	return s
+}
+
+func (s *coinbaseproStandIn) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if raw, ok := v.(string); ok {
+		w.Write([]byte(raw))
+		return
+	}
+	e := json.NewEncoder(w).Encode(v)
+	if e != nil {
+		s.t.Fatalf("could not encode response: %s", e)
+	}
+}
+
+// page serves trades newest first, paginated with the CB-AFTER cursor holding the last trade ID of the page
+func (s *coinbaseproStandIn) page(w http.ResponseWriter, r *http.Request, trades []coinbaseproTrade) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 100
+	}
+	after, e := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	if e != nil {
+		after = -1
+	}
+
+	page := []coinbaseproTrade{}
+	for _, t := range trades {
+		if after != -1 && t.TradeID >= after {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, t)
+	}
+	if len(page) > 0 {
+		w.Header().Set("CB-AFTER", strconv.FormatInt(page[len(page)-1].TradeID, 10))
+	}
+	s.writeJSON(w, http.StatusOK, page)
+}
+
+func (s *coinbaseproStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.t.Fatalf("could not read request body: %s", e)
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// every endpoint other than /products needs to be signed
+	if parts[0] != "products" {
+		prehash := r.Header.Get("CB-ACCESS-TIMESTAMP") + r.Method + r.URL.RequestURI() + string(body)
+		if r.Header.Get("CB-ACCESS-PASSPHRASE") != testCoinbaseproPassphrase || r.Header.Get("CB-ACCESS-SIGN") != coinbaseproSignature(testCoinbaseproSecret, prehash) {
+			s.writeJSON(w, http.StatusUnauthorized, `{"message":"invalid signature"}`)
+			return
+		}
+	}
+
+	switch {
+	case r.Method == "GET" && parts[0] == "products" && len(parts) == 1:
+		products := []string{}
+		for _, product := range s.products {
+			products = append(products, product)
+		}
+		s.writeJSON(w, http.StatusOK, "["+strings.Join(products, ",")+"]")
+		return
+	case r.Method == "GET" && parts[0] == "products" && len(parts) == 2:
+		if product, ok := s.products[parts[1]]; ok {
+			s.writeJSON(w, http.StatusOK, product)
+			return
+		}
+	case r.Method == "GET" && parts[0] == "products" && len(parts) == 3 && parts[2] == "book":
+		s.writeJSON(w, http.StatusOK, `{"sequence":3,"bids":[["0.00001150","520.00000000",2],["0.00001140","1000.00000000",1],["0.00001130","80.00000000",3]],"asks":[["0.00001160","300.00000000",1],["0.00001170","75.00000000",1],["0.00001180","2000.00000000",4]]}`)
+		return
+	case r.Method == "GET" && parts[0] == "products" && len(parts) == 3 && parts[2] == "ticker":
+		s.writeJSON(w, http.StatusOK, `{"trade_id":1000,"price":"0.00001155","size":"25.00000000","bid":"0.00001150","ask":"0.00001160","volume":"5957.11914015","time":"2019-11-20T10:00:00.000000Z"}`)
+		return
+	case r.Method == "GET" && parts[0] == "products" && len(parts) == 3 && parts[2] == "trades":
+		s.page(w, r, s.trades)
+		return
+	case r.Method == "GET" && parts[0] == "accounts":
+		s.writeJSON(w, http.StatusOK, `[{"id":"71452118-efc7-4cc4-8780-a5e22d4baa53","currency":"XLM","balance":"20.0000000000000000","available":"20.0000000000000000","hold":"0.0000000000000000"},{"id":"e316cb9a-0808-4fd7-8914-97829c1925de","currency":"BTC","balance":"0.0000000000000000","available":"0","hold":"0.0000000000000000"}]`)
+		return
+	case r.Method == "GET" && parts[0] == "fills":
+		s.page(w, r, s.fills)
+		return
+	case r.Method == "GET" && parts[0] == "orders":
+		orders := []coinbaseproOrder{}
+		for _, o := range s.orders {
+			if o.ProductID == r.URL.Query().Get("product_id") {
+				orders = append(orders, o)
+			}
+		}
+		s.writeJSON(w, http.StatusOK, orders)
+		return
+	case r.Method == "POST" && parts[0] == "orders":
+		var req struct {
+			ProductID string `json:"product_id"`
+			Side      string `json:"side"`
+			Type      string `json:"type"`
+			Price     string `json:"price"`
+			Size      string `json:"size"`
+			PostOnly  bool   `json:"post_only"`
+		}
+		e = json.Unmarshal(body, &req)
+		if e != nil {
+			s.writeJSON(w, http.StatusBadRequest, `{"message":"invalid body"}`)
+			return
+		}
+		price, _ := strconv.ParseFloat(req.Price, 64)
+		// the best ask in the stand-in's book is 0.0000116 and the best bid is 0.0000115
+		if req.PostOnly && ((req.Side == "buy" && price >= 0.0000116) || (req.Side == "sell" && price <= 0.0000115)) {
+			s.writeJSON(w, http.StatusBadRequest, `{"message":"Post only mode"}`)
+			return
+		}
+
+		o := coinbaseproOrder{
+			ID:         fmt.Sprintf("order-%d", s.nextOrderID),
+			Price:      req.Price,
+			Size:       req.Size,
+			ProductID:  req.ProductID,
+			Side:       req.Side,
+			Type:       req.Type,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+			FilledSize: "0",
+			Status:     "pending",
+		}
+		s.nextOrderID++
+		s.orders[o.ID] = o
+		s.writeJSON(w, http.StatusOK, o)
+		return
+	case r.Method == "DELETE" && parts[0] == "orders" && len(parts) == 1:
+		cancelledIDs := []string{}
+		for id, o := range s.orders {
+			if o.ProductID == r.URL.Query().Get("product_id") {
+				delete(s.orders, id)
+				cancelledIDs = append(cancelledIDs, id)
+			}
+		}
+		s.writeJSON(w, http.StatusOK, cancelledIDs)
+		return
+	case r.Method == "DELETE" && parts[0] == "orders" && len(parts) == 2:
+		if _, ok := s.orders[parts[1]]; ok {
+			delete(s.orders, parts[1])
+			s.writeJSON(w, http.StatusOK, fmt.Sprintf("%q", parts[1]))
+			return
+		}
+	}
+	s.writeJSON(w, http.StatusNotFound, `{"message":"NotFound"}`)
+}
+
+func makeTestCoinbaseproExchange(t *testing.T) (*coinbaseproExchange, *coinbaseproStandIn, func()) {
+	standIn := makeCoinbaseproStandIn(t)
+	server := httptest.NewServer(standIn)
+
+	x, e := makeCoinbaseproExchange(
+		[]api.ExchangeAPIKey{{Key: "testKey", Secret: base64.StdEncoding.EncodeToString(testCoinbaseproSecret)}},
+		[]api.ExchangeParam{
+			{Param: "password", Value: testCoinbaseproPassphrase},
+			{Param: "base_url", Value: server.URL},
+		},
+		false,
+	)
+	if e != nil {
+		t.Fatalf("could not make coinbasepro exchange: %s", e)
+	}
+	c := x.(*coinbaseproExchange)
+	c.httpClient = server.Client()
+	return c, standIn, server.Close
+}
+
+func TestGetTickerPrice_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	m, e := c.GetTickerPrice([]model.TradingPair{pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1, len(m))
+
+	ticker := m[pair]
+	assert.Equal(t, "0.00001160", ticker.AskPrice.AsString())
+	assert.Equal(t, "0.00001150", ticker.BidPrice.AsString())
+	assert.Equal(t, "0.00001155", ticker.LastPrice.AsString())
+}
+
+func TestGetOrderBook_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	for _, obDepth := range []int32{1, 2, 3, 20} {
+		t.Run(fmt.Sprintf("%d", obDepth), func(t *testing.T) {
+			pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			ob, e := c.GetOrderBook(&pair, obDepth)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, ob.Pair(), &pair)
+
+			wantLen := int(obDepth)
+			if wantLen > 3 {
+				wantLen = 3
+			}
+			if !assert.Equal(t, wantLen, len(ob.Asks())) || !assert.Equal(t, wantLen, len(ob.Bids())) {
+				return
+			}
+			assert.True(t, ob.Asks()[0].OrderAction.IsSell())
+			assert.True(t, ob.Asks()[0].OrderType.IsLimit())
+			assert.True(t, ob.Bids()[0].OrderAction.IsBuy())
+			assert.True(t, ob.Bids()[0].OrderType.IsLimit())
+			assert.Equal(t, "0.00001160", ob.Asks()[0].Price.AsString())
+			assert.Equal(t, "300", ob.Asks()[0].Volume.AsString())
+			assert.Equal(t, "0.00001150", ob.Bids()[0].Price.AsString())
+			assert.Equal(t, "520", ob.Bids()[0].Volume.AsString())
+		})
+	}
+}
+
+func TestGetTrades_Coinbasepro(t *testing.T) {
+	c, standIn, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	tradeResult, e := c.GetTrades(&pair, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// only the most recent page is fetched without a cursor
+	if !assert.Equal(t, 100, len(tradeResult.Trades)) {
+		return
+	}
+	// the cursor is the trade_id of the newest trade
+	assert.Equal(t, "1000", tradeResult.Cursor)
+	validateTrades(t, pair, tradeResult.Trades)
+	// the side of public trades is the maker's side so it is reversed for the taker
+	assert.Equal(t, model.OrderActionBuy, tradeResult.Trades[len(tradeResult.Trades)-1].OrderAction)
+
+	// a cursor older than the first page makes us follow CB-AFTER to the next page, the trade the cursor points to is excluded
+	tradeResult, e = c.GetTrades(&pair, "851")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 149, len(tradeResult.Trades))
+	assert.Equal(t, "1000", tradeResult.Cursor)
+	validateTrades(t, pair, tradeResult.Trades)
+
+	// a time cursor from GetLatestTradeCursor includes trades at or after that time and is replaced by a trade_id cursor
+	oldestMillis, e := standIn.trades[len(standIn.trades)-1].timestampMillis()
+	if !assert.NoError(t, e) {
+		return
+	}
+	tradeResult, e = c.GetTrades(&pair, fmt.Sprintf("time:%d", oldestMillis+1000))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 149, len(tradeResult.Trades))
+	assert.Equal(t, "1000", tradeResult.Cursor)
+	validateTrades(t, pair, tradeResult.Trades)
+
+	// no new trades keeps the cursor
+	tradeResult, e = c.GetTrades(&pair, "1000")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(tradeResult.Trades))
+	assert.Equal(t, "1000", tradeResult.Cursor)
+}
+
+func TestGetTradeHistory_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	testCases := []struct {
+		name        string
+		cursorStart interface{}
+		cursorEnd   interface{}
+		wantIDs     []string
+		wantCursor  interface{}
+	}{
+		{
+			name:        "no cursor",
+			cursorStart: nil,
+			cursorEnd:   nil,
+			wantIDs:     []string{"73", "74"},
+			wantCursor:  "74",
+		}, {
+			name:        "start cursor excludes the fill it points to",
+			cursorStart: "73",
+			cursorEnd:   nil,
+			wantIDs:     []string{"74"},
+			wantCursor:  "74",
+		}, {
+			name:        "end cursor excludes newer fills",
+			cursorStart: "72",
+			cursorEnd:   "73",
+			wantIDs:     []string{"73"},
+			wantCursor:  "73",
+		}, {
+			name:        "no fills after cursor",
+			cursorStart: "74",
+			cursorEnd:   nil,
+			wantIDs:     []string{},
+			wantCursor:  "74",
+		}, {
+			name:        "time cursor includes fills at or after that time",
+			cursorStart: "time:1574241600001",
+			cursorEnd:   nil,
+			wantIDs:     []string{"74"},
+			wantCursor:  "74",
+		}, {
+			name:        "time end cursor",
+			cursorStart: "time:1574241000000",
+			cursorEnd:   "time:1574241600000",
+			wantIDs:     []string{"73"},
+			wantCursor:  "73",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			tradeHistoryResult, e := c.GetTradeHistory(pair, k.cursorStart, k.cursorEnd)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantCursor, tradeHistoryResult.Cursor)
+
+			ids := []string{}
+			for _, trade := range tradeHistoryResult.Trades {
+				ids = append(ids, trade.TransactionID.String())
+			}
+			assert.Equal(t, k.wantIDs, ids)
+			validateTrades(t, pair, tradeHistoryResult.Trades)
+		})
+	}
+
+	tradeHistoryResult, e := c.GetTradeHistory(pair, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	sell := tradeHistoryResult.Trades[0]
+	assert.Equal(t, model.OrderActionSell, sell.OrderAction)
+	assert.Equal(t, "d0c5340b-6d6c-49d9-b567-48c4bfca13d2", sell.OrderID)
+	assert.Equal(t, "0.00000150", sell.Fee.AsString())
+	assert.Equal(t, "0.00030000", sell.Cost.AsString())
+}
+
+func TestGetLatestTradeCursor_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	startIntervalMillis := time.Now().UnixNano() / int64(time.Millisecond)
+	cursor, e := c.GetLatestTradeCursor()
+	if !assert.NoError(t, e) {
+		return
+	}
+	endIntervalMillis := time.Now().UnixNano() / int64(time.Millisecond)
+
+	if !assert.IsType(t, "string", cursor) {
+		return
+	}
+	// we do not know the product here so the latest cursor is a time cursor
+	if !assert.True(t, strings.HasPrefix(cursor.(string), "time:"), cursor) {
+		return
+	}
+	cursorInt, e := strconv.ParseInt(strings.TrimPrefix(cursor.(string), "time:"), 10, 64)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, startIntervalMillis <= cursorInt, fmt.Sprintf("returned cursor (%d) should gte the start time of the function call in milliseconds (%d)", cursorInt, startIntervalMillis))
+	assert.True(t, endIntervalMillis >= cursorInt, fmt.Sprintf("returned cursor (%d) should lte the end time of the function call in milliseconds (%d)", cursorInt, endIntervalMillis))
+}
+
+func TestGetAccountBalances_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	balances, e := c.GetAccountBalances([]interface{}{
+		model.XLM,
+		model.BTC,
+		model.USD,
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 20.0, balances[model.XLM].AsFloat())
+	assert.Equal(t, 0.0, balances[model.BTC].AsFloat())
+	assert.Equal(t, 0.0, balances[model.USD].AsFloat())
+}
+
+func TestGetAccountBalances_Coinbasepro_BadPassphrase(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+	c.passphrase = "wrongPassphrase"
+
+	_, e := c.GetAccountBalances([]interface{}{model.XLM})
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "invalid signature")
+	}
+}
+
+func TestGetOpenOrders_Coinbasepro(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	m, e := c.GetOpenOrders([]*model.TradingPair{&pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(m)) {
+		return
+	}
+
+	openOrders := m[pair]
+	if !assert.Equal(t, 1, len(openOrders)) {
+		return
+	}
+	o := openOrders[0]
+	if !validateOpenOrder(t, &pair, o) {
+		return
+	}
+	assert.Equal(t, "d0c5340b-6d6c-49d9-b567-48c4bfca13d2", o.ID)
+	assert.Equal(t, model.OrderActionSell, o.OrderAction)
+	assert.Equal(t, "25", o.VolumeExecuted.AsString())
+}
+
+func TestAddOrder_Coinbasepro(t *testing.T) {
+	for _, kase := range []struct {
+		name        string
+		orderAction model.OrderAction
+		price       *model.Number
+		submitMode  api.SubmitMode
+		wantErr     bool
+	}{
+		{
+			name:        "sell",
+			orderAction: model.OrderActionSell,
+			price:       model.NumberFromFloat(0.000041, 8),
+			submitMode:  api.SubmitModeBoth,
+		}, {
+			name:        "buy maker only",
+			orderAction: model.OrderActionBuy,
+			price:       model.NumberFromFloat(0.000011, 8),
+			submitMode:  api.SubmitModeMakerOnly,
+		}, {
+			name:        "buy maker only would take",
+			orderAction: model.OrderActionBuy,
+			price:       model.NumberFromFloat(0.000012, 8),
+			submitMode:  api.SubmitModeMakerOnly,
+			wantErr:     true,
+		},
+	} {
+		t.Run(kase.name, func(t *testing.T) {
+			c, standIn, closeFn := makeTestCoinbaseproExchange(t)
+			defer closeFn()
+
+			pair := &model.TradingPair{Base: model.XLM, Quote: model.BTC}
+			txID, e := c.AddOrder(
+				&model.Order{
+					Pair:        pair,
+					OrderAction: kase.orderAction,
+					OrderType:   model.OrderTypeLimit,
+					Price:       kase.price,
+					Volume:      model.NumberFromFloat(60, 0),
+				},
+				kase.submitMode,
+			)
+			if kase.wantErr {
+				if assert.Error(t, e) {
+					assert.Contains(t, e.Error(), "Post only mode")
+				}
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.NotNil(t, txID) {
+				return
+			}
+
+			o, ok := standIn.orders[txID.String()]
+			if !assert.True(t, ok, txID.String()) {
+				return
+			}
+			assert.Equal(t, "XLM-BTC", o.ProductID)
+			assert.Equal(t, kase.orderAction.String(), o.Side)
+			assert.Equal(t, kase.price.AsString(), o.Price)
+			assert.Equal(t, "60", o.Size)
+		})
+	}
+}
+
+func TestCancelOrder_Coinbasepro(t *testing.T) {
+	c, standIn, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	result, e := c.CancelOrder(model.MakeTransactionID("d0c5340b-6d6c-49d9-b567-48c4bfca13d2"), pair)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, model.CancelResultCancelSuccessful, result)
+	assert.Equal(t, 0, len(standIn.orders))
+
+	result, e = c.CancelOrder(model.MakeTransactionID("d0c5340b-6d6c-49d9-b567-48c4bfca13d2"), pair)
+	assert.Error(t, e)
+	assert.Equal(t, model.CancelResultFailed, result)
+}
+
+func TestCancelAllOrders_Coinbasepro(t *testing.T) {
+	c, standIn, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
+	e := c.CancelAllOrders(pair)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(standIn.orders))
+
+	// cancelling when there are no open orders is not an error
+	e = c.CancelAllOrders(pair)
+	assert.NoError(t, e)
+}
+
+func TestGetOrderConstraints_Coinbasepro_Precision(t *testing.T) {
+	c, _, closeFn := makeTestCoinbaseproExchange(t)
+	defer closeFn()
+
+	testCases := []struct {
+		pair               *model.TradingPair
+		wantPricePrecision int8
+		wantVolPrecision   int8
+		wantMinBaseVolume  float64
+	}{
+		{
+			pair:               &model.TradingPair{Base: model.XLM, Quote: model.BTC},
+			wantPricePrecision: 8,
+			wantVolPrecision:   0,
+			wantMinBaseVolume:  1.0,
+		}, {
+			pair:               &model.TradingPair{Base: model.XLM, Quote: model.USD},
+			wantPricePrecision: 6,
+			wantVolPrecision:   0,
+			wantMinBaseVolume:  1.0,
+		},
+	}
+
+	for _, kase := range testCases {
+		t.Run(kase.pair.String(), func(t *testing.T) {
+			result := c.GetOrderConstraints(kase.pair)
+			assert.Equal(t, kase.wantPricePrecision, result.PricePrecision)
+			assert.Equal(t, kase.wantVolPrecision, result.VolumePrecision)
+			assert.Equal(t, kase.wantMinBaseVolume, result.MinBaseVolume.AsFloat())
+		})
+	}
+}
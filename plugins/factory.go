@@ -28,6 +28,7 @@ type strategyFactoryData struct {
 	isTradingSdex   bool
 	filterFactory   *FilterFactory
 	db              *sql.DB
+	alert           api.Alert
 }
 
 // StrategyContainer contains the strategy factory method along with some metadata
@@ -74,7 +75,7 @@ var strategies = map[string]StrategyContainer{
 			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeMirrorStrategy(strategyFactoryData.sdex, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, &cfg, strategyFactoryData.db, strategyFactoryData.simMode)
+			s, e := makeMirrorStrategy(strategyFactoryData.sdex, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, &cfg, strategyFactoryData.db, strategyFactoryData.alert, strategyFactoryData.simMode)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -168,6 +169,55 @@ var strategies = map[string]StrategyContainer{
 			return s, nil
 		},
 	},
+	"remote": {
+		SortOrder:   7,
+		Description: "Gets the buy and sell levels from an external process over a local socket so pricing logic can be written in any language",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg remoteConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeRemoteStrategy(
+				strategyFactoryData.sdex,
+				strategyFactoryData.exchangeShim,
+				strategyFactoryData.tradingPair,
+				strategyFactoryData.ieif,
+				strategyFactoryData.assetBase,
+				strategyFactoryData.assetQuote,
+				&cfg,
+			)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"scripted": {
+		SortOrder:   8,
+		Description: "Creates buy and sell offers with levels computed by a Starlark script that can be changed without recompiling",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg scriptedConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeScriptedStrategy(
+				strategyFactoryData.sdex,
+				strategyFactoryData.tradingPair,
+				strategyFactoryData.ieif,
+				strategyFactoryData.assetBase,
+				strategyFactoryData.assetQuote,
+				&cfg,
+			)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
 }
 
 // MakeStrategy makes a strategy
@@ -186,6 +236,7 @@ func MakeStrategy(
 	isTradingSdex bool,
 	filterFactory *FilterFactory,
 	db *sql.DB,
+	alert api.Alert,
 ) (api.Strategy, error) {
 	log.Printf("Making strategy: %s\n", strategy)
 	if s, ok := strategies[strategy]; ok {
@@ -207,6 +258,7 @@ func MakeStrategy(
 			isTradingSdex:   isTradingSdex,
 			filterFactory:   filterFactory,
 			db:              db,
+			alert:           alert,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("cannot make '%s' strategy: %s", strategy, e)
@@ -232,13 +284,14 @@ type exchangeFactoryData struct {
 
 // ExchangeContainer contains the exchange factory method along with some metadata
 type ExchangeContainer struct {
-	SortOrder       uint16
-	Description     string
-	TradeEnabled    bool
-	Tested          bool
-	AtomicPostOnly  bool
-	TradeHasOrderId bool
-	makeFn          func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error)
+	SortOrder        uint16
+	Description      string
+	TradeEnabled     bool
+	Tested           bool
+	AtomicPostOnly   bool
+	TradeHasOrderId  bool
+	NativeAmendOrder bool
+	makeFn           func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error)
 }
 
 // exchanges is a map of all the exchange integrations available
@@ -272,16 +325,45 @@ func loadExchanges() {
 		"binance": true,
 	}
 
+	// marked as nativeAmendOrder if key exists in this map (regardless of bool value)
+	// ccxt emulates editOrder with a cancel and a create on most exchanges (including binance and kraken), which is no better than what the
+	// BatchedExchange does itself, so only add exchanges here once ccxt's editOrder has been verified to be a single request on the exchange
+	nativeAmendOrderCcxtExchanges := map[string]bool{}
+
 	exchanges = &map[string]ExchangeContainer{
 		"kraken": {
-			SortOrder:    0,
-			Description:  "Kraken is a popular centralized cryptocurrency exchange",
-			TradeEnabled: true,
-			Tested:       true,
+			SortOrder:       0,
+			Description:     "Kraken is a popular centralized cryptocurrency exchange",
+			TradeEnabled:    true,
+			Tested:          true,
+			TradeHasOrderId: true,
 			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
 				return makeKrakenExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.simMode)
 			},
 		},
+		"binance": {
+			SortOrder:        1,
+			Description:      "Binance is a popular centralized cryptocurrency exchange (native integration, does not need ccxt-rest)",
+			TradeEnabled:     true,
+			Tested:           false,
+			AtomicPostOnly:   true,
+			TradeHasOrderId:  true,
+			NativeAmendOrder: true,
+			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
+				return makeBinanceExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.exchangeParams, exchangeFactoryData.simMode)
+			},
+		},
+		"coinbasepro": {
+			SortOrder:       2,
+			Description:     "Coinbase Pro is a popular centralized cryptocurrency exchange (native integration, does not need ccxt-rest)",
+			TradeEnabled:    true,
+			Tested:          false,
+			AtomicPostOnly:  true,
+			TradeHasOrderId: true,
+			makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
+				return makeCoinbaseproExchange(exchangeFactoryData.apiKeys, exchangeFactoryData.exchangeParams, exchangeFactoryData.simMode)
+			},
+		},
 	}
 
 	// add all CCXT exchanges (tested exchanges first)
@@ -297,15 +379,17 @@ func loadExchanges() {
 
 			_, atomicPostOnly := atomicPostOnlyCcxtExchanges[exchangeName]
 			_, tradeHasOrderId := tradeHasOrderIdCcxtExchanges[exchangeName]
+			_, nativeAmendOrder := nativeAmendOrderCcxtExchanges[exchangeName]
 			// maybeEsParamFactory can be nil
 			maybeEsParamFactory := ccxtExchangeSpecificParamFactoryMap[key]
 			(*exchanges)[key] = ExchangeContainer{
-				SortOrder:       uint16(sortOrderIndex),
-				Description:     exchangeName + " is automatically added via ccxt-rest",
-				TradeEnabled:    true,
-				Tested:          tested,
-				AtomicPostOnly:  atomicPostOnly,
-				TradeHasOrderId: tradeHasOrderId,
+				SortOrder:        uint16(sortOrderIndex),
+				Description:      exchangeName + " is automatically added via ccxt-rest",
+				TradeEnabled:     true,
+				Tested:           tested,
+				AtomicPostOnly:   atomicPostOnly,
+				TradeHasOrderId:  tradeHasOrderId,
+				NativeAmendOrder: nativeAmendOrder,
 				makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
 					return makeCcxtExchange(
 						boundExchangeName,
@@ -321,6 +405,12 @@ func loadExchanges() {
 			sortOrderIndex++
 		}
 	}
+
+	// add exchanges registered from outside this package last
+	e := addRegisteredExchanges(*exchanges)
+	if e != nil {
+		log.Fatalf("cannot load the exchanges, rename the registered exchanges that conflict with an existing exchange: %s\n", e)
+	}
 }
 
 // MakeExchange is a factory method to make an exchange based on a given type
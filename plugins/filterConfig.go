@@ -0,0 +1,359 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/kelp/queries"
+)
+
+// FilterConfig is the structured representation of a single [[FILTER]] table in the trader config file.
+// Only the keys that belong to the TYPE of the filter can be set, this is checked by Validate.
+type FilterConfig struct {
+	Type    string `valid:"-" toml:"TYPE" json:"type"`
+	Enabled *bool  `valid:"-" toml:"ENABLED" json:"enabled"` // defaults to true when not set
+
+	// used by "volume" filters
+	Window     string   `valid:"-" toml:"WINDOW" json:"window"`
+	Action     string   `valid:"-" toml:"ACTION" json:"action"`
+	BaseCap    *float64 `valid:"-" toml:"BASE_CAP" json:"base_cap"`
+	QuoteCap   *float64 `valid:"-" toml:"QUOTE_CAP" json:"quote_cap"`
+	Mode       string   `valid:"-" toml:"MODE" json:"mode"`
+	MarketIDs  []string `valid:"-" toml:"MARKET_IDS" json:"market_ids"`
+	AccountIDs []string `valid:"-" toml:"ACCOUNT_IDS" json:"account_ids"`
+
+	// used by "price" filters
+	MinPrice *float64 `valid:"-" toml:"MIN_PRICE" json:"min_price"`
+	MaxPrice *float64 `valid:"-" toml:"MAX_PRICE" json:"max_price"`
+
+	// used by "priceFeed" filters
+	ComparisonMode string `valid:"-" toml:"COMPARISON_MODE" json:"comparison_mode"`
+	FeedType       string `valid:"-" toml:"FEED_TYPE" json:"feed_type"`
+	FeedURL        string `valid:"-" toml:"FEED_URL" json:"feed_url"`
+
+	// used by "maxOrders" filters
+	MaxOffersPerSide   *uint16  `valid:"-" toml:"MAX_OFFERS_PER_SIDE" json:"max_offers_per_side"`
+	MaxOrderBaseAmount *float64 `valid:"-" toml:"MAX_ORDER_BASE_AMOUNT" json:"max_order_base_amount"`
+
+	// used by "schedule" filters
+	Schedule []string `valid:"-" toml:"SCHEDULE" json:"schedule"`
+
+	// used by filters added with RegisterFilter, it is passed to the registered factory method as the part of the filter string after the TYPE
+	Config string `valid:"-" toml:"CONFIG" json:"config"`
+}
+
+// filterConfigKeys lists the keys that are allowed for each TYPE of filter
+var filterConfigKeys = map[string][]string{
+	"volume":    {"WINDOW", "ACTION", "BASE_CAP", "QUOTE_CAP", "MODE", "MARKET_IDS", "ACCOUNT_IDS"},
+	"price":     {"MIN_PRICE", "MAX_PRICE"},
+	"priceFeed": {"COMPARISON_MODE", "FEED_TYPE", "FEED_URL"},
+	"maxOrders": {"MAX_OFFERS_PER_SIDE", "MAX_ORDER_BASE_AMOUNT"},
+	"schedule":  {"SCHEDULE"},
+}
+
+// registeredFilterConfigKeys are the keys that are allowed for a TYPE of filter that was added with RegisterFilter
+var registeredFilterConfigKeys = []string{"CONFIG"}
+
+// filterConfigTables is used to decode only the [[FILTER]] tables of a config file
+type filterConfigTables struct {
+	FilterConfigs []FilterConfig `toml:"FILTER"`
+}
+
+// CheckFilterConfigKeys returns an error when a [[FILTER]] table in the TOML config file has a key that is not known, so a misspelt
+// key is not silently ignored. The file is decoded again because reading the bot config does not give us the TOML metadata.
+func CheckFilterConfigKeys(configPath string) error {
+	var tables filterConfigTables
+	md, e := toml.DecodeFile(configPath, &tables)
+	if e != nil {
+		return fmt.Errorf("could not decode the [[FILTER]] tables in config file '%s': %s", configPath, e)
+	}
+	return checkUndecodedFilterKeys(md)
+}
+
+// checkUndecodedFilterKeys returns an error for the first undecoded key that belongs to a [[FILTER]] table
+func checkUndecodedFilterKeys(md toml.MetaData) error {
+	for _, k := range md.Undecoded() {
+		if len(k) < 2 || k[0] != "FILTER" {
+			continue
+		}
+		return keyErrorf(k[1], "unknown key in [[FILTER]] table, allowed keys are TYPE, ENABLED and %v", allFilterConfigKeys())
+	}
+	return nil
+}
+
+// allFilterConfigKeys returns the sorted keys that are allowed for any TYPE of filter
+func allFilterConfigKeys() []string {
+	keys := append([]string{}, registeredFilterConfigKeys...)
+	for _, typeKeys := range filterConfigKeys {
+		keys = append(keys, typeKeys...)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FilterConfigKeyError is returned when a key in a FilterConfig has an invalid value
+type FilterConfigKeyError struct {
+	Key     string
+	Message string
+}
+
+// Error impl.
+func (e *FilterConfigKeyError) Error() string {
+	return fmt.Sprintf("invalid value for key '%s': %s", e.Key, e.Message)
+}
+
+func keyErrorf(key string, format string, args ...interface{}) error {
+	return &FilterConfigKeyError{
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// IsEnabled returns false only if the filter was explicitly disabled
+func (c *FilterConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// setKeys returns the keys that have a value in this config, excluding TYPE and ENABLED
+func (c *FilterConfig) setKeys() []string {
+	isSet := []struct {
+		key string
+		set bool
+	}{
+		{"WINDOW", c.Window != ""},
+		{"ACTION", c.Action != ""},
+		{"BASE_CAP", c.BaseCap != nil},
+		{"QUOTE_CAP", c.QuoteCap != nil},
+		{"MODE", c.Mode != ""},
+		{"MARKET_IDS", c.MarketIDs != nil},
+		{"ACCOUNT_IDS", c.AccountIDs != nil},
+		{"MIN_PRICE", c.MinPrice != nil},
+		{"MAX_PRICE", c.MaxPrice != nil},
+		{"COMPARISON_MODE", c.ComparisonMode != ""},
+		{"FEED_TYPE", c.FeedType != ""},
+		{"FEED_URL", c.FeedURL != ""},
+		{"MAX_OFFERS_PER_SIDE", c.MaxOffersPerSide != nil},
+		{"MAX_ORDER_BASE_AMOUNT", c.MaxOrderBaseAmount != nil},
+		{"SCHEDULE", c.Schedule != nil},
+		{"CONFIG", c.Config != ""},
+	}
+
+	keys := []string{}
+	for _, s := range isSet {
+		if s.set {
+			keys = append(keys, s.key)
+		}
+	}
+	return keys
+}
+
+// Validate ensures validity, errors for a specific key are returned as a *FilterConfigKeyError
+func (c *FilterConfig) Validate() error {
+	allowedKeys, ok := filterConfigKeys[c.Type]
+	if !ok {
+		if !isRegisteredFilterType(c.Type) {
+			return keyErrorf("TYPE", "unknown filter type '%s'", c.Type)
+		}
+		// the CONFIG of a registered filter is only checked by its factory method when the filter is made
+		allowedKeys = registeredFilterConfigKeys
+	}
+
+	allowedKeySet := map[string]bool{}
+	for _, k := range allowedKeys {
+		allowedKeySet[k] = true
+	}
+	for _, k := range c.setKeys() {
+		if !allowedKeySet[k] {
+			return keyErrorf(k, "key cannot be used with a filter of TYPE '%s', allowed keys are %v", c.Type, allowedKeys)
+		}
+	}
+
+	switch c.Type {
+	case "volume":
+		return c.validateVolume()
+	case "price":
+		return c.validatePrice()
+	case "priceFeed":
+		return c.validatePriceFeed()
+	case "maxOrders":
+		return c.validateMaxOrders()
+	case "schedule":
+		return c.validateSchedule()
+	}
+	if isRegisteredFilterType(c.Type) {
+		return nil
+	}
+	return fmt.Errorf("programmer error? unhandled filter type '%s'", c.Type)
+}
+
+// isRegisteredFilterType returns true for a TYPE of filter that was added with RegisterFilter
+func isRegisteredFilterType(filterType string) bool {
+	if _, ok := filterConfigKeys[filterType]; ok {
+		return false
+	}
+	_, ok := filterMap[filterType]
+	return ok
+}
+
+func (c *FilterConfig) validateVolume() error {
+	if c.Window != "" && c.Window != "daily" {
+		return keyErrorf("WINDOW", "only \"daily\" is supported but was '%s'", c.Window)
+	}
+
+	if c.Action == "" {
+		return keyErrorf("ACTION", "required for a volume filter")
+	}
+	if _, e := queries.ParseDailyVolumeAction(c.Action); e != nil {
+		return keyErrorf("ACTION", "%s", e)
+	}
+
+	if c.BaseCap == nil && c.QuoteCap == nil {
+		return keyErrorf("BASE_CAP", "exactly one of BASE_CAP or QUOTE_CAP is required for a volume filter")
+	}
+	if c.BaseCap != nil && c.QuoteCap != nil {
+		return keyErrorf("QUOTE_CAP", "cannot be set together with BASE_CAP, exactly one of BASE_CAP or QUOTE_CAP is required")
+	}
+	if c.BaseCap != nil && *c.BaseCap < 0 {
+		return keyErrorf("BASE_CAP", "cannot be negative but was %f", *c.BaseCap)
+	}
+	if c.QuoteCap != nil && *c.QuoteCap < 0 {
+		return keyErrorf("QUOTE_CAP", "cannot be negative but was %f", *c.QuoteCap)
+	}
+
+	if c.Mode == "" {
+		return keyErrorf("MODE", "required for a volume filter, needs to be \"%s\" or \"%s\"", volumeFilterModeExact, volumeFilterModeIgnore)
+	}
+	if _, e := parseVolumeFilterMode(c.Mode); e != nil {
+		return keyErrorf("MODE", "%s", e)
+	}
+
+	if c.MarketIDs != nil && len(c.MarketIDs) == 0 {
+		return keyErrorf("MARKET_IDS", "array length required to be greater than 0 when set")
+	}
+	for _, id := range c.MarketIDs {
+		if !filterIDRegex.MatchString(id) {
+			return keyErrorf("MARKET_IDS", "invalid id entry '%s'", id)
+		}
+	}
+	for _, id := range c.AccountIDs {
+		if strings.ContainsAny(id, ",[]/:") {
+			return keyErrorf("ACCOUNT_IDS", "invalid id entry '%s'", id)
+		}
+	}
+	return nil
+}
+
+func (c *FilterConfig) validatePrice() error {
+	if c.MinPrice == nil && c.MaxPrice == nil {
+		return keyErrorf("MIN_PRICE", "exactly one of MIN_PRICE or MAX_PRICE is required for a price filter")
+	}
+	if c.MinPrice != nil && c.MaxPrice != nil {
+		return keyErrorf("MAX_PRICE", "cannot be set together with MIN_PRICE, use two separate price filters instead")
+	}
+	return nil
+}
+
+func (c *FilterConfig) validatePriceFeed() error {
+	if c.ComparisonMode != "outside-exclude" && c.ComparisonMode != "outside-include" {
+		return keyErrorf("COMPARISON_MODE", "needs to be \"outside-exclude\" or \"outside-include\" but was '%s'", c.ComparisonMode)
+	}
+	if c.FeedType == "" {
+		return keyErrorf("FEED_TYPE", "required for a priceFeed filter")
+	}
+	if c.FeedURL == "" {
+		return keyErrorf("FEED_URL", "required for a priceFeed filter")
+	}
+	return nil
+}
+
+func (c *FilterConfig) validateMaxOrders() error {
+	config := &MaxOrdersFilterConfig{}
+	if c.MaxOffersPerSide != nil {
+		config.MaxOffersPerSide = *c.MaxOffersPerSide
+	}
+	if c.MaxOrderBaseAmount != nil {
+		if *c.MaxOrderBaseAmount < 0 {
+			return keyErrorf("MAX_ORDER_BASE_AMOUNT", "cannot be negative but was %f", *c.MaxOrderBaseAmount)
+		}
+		config.MaxOrderBaseAmount = *c.MaxOrderBaseAmount
+	}
+
+	if e := config.Validate(); e != nil {
+		return keyErrorf("MAX_OFFERS_PER_SIDE", "%s", e)
+	}
+	return nil
+}
+
+func (c *FilterConfig) validateSchedule() error {
+	if len(c.Schedule) == 0 {
+		return keyErrorf("SCHEDULE", "needs at least one entry like \"Mo,Tu,We,Th,Fr=08:00-16:00\"")
+	}
+
+	config := &ScheduleFilterConfig{}
+	for i, daySpec := range c.Schedule {
+		if e := parseScheduleDaySpec(config, daySpec); e != nil {
+			return keyErrorf("SCHEDULE", "entry at index %d ('%s'): %s", i, daySpec, e)
+		}
+	}
+	if e := config.Validate(); e != nil {
+		return keyErrorf("SCHEDULE", "%s", e)
+	}
+	return nil
+}
+
+// FilterString converts the validated config to the equivalent string form used in the FILTERS list so both forms are
+// handled by FilterFactory.MakeFilter in the same way
+func (c *FilterConfig) FilterString() (string, error) {
+	if e := c.Validate(); e != nil {
+		return "", e
+	}
+
+	switch c.Type {
+	case "volume":
+		window := "daily"
+		if c.MarketIDs != nil {
+			window += fmt.Sprintf(":market_ids=[%s]", strings.Join(c.MarketIDs, ","))
+		}
+		if c.AccountIDs != nil {
+			window += fmt.Sprintf(":account_ids=[%s]", strings.Join(c.AccountIDs, ","))
+		}
+		capType, capValue := "base", c.BaseCap
+		if c.QuoteCap != nil {
+			capType, capValue = "quote", c.QuoteCap
+		}
+		return fmt.Sprintf("volume/%s/%s/%s/%s/%s", window, c.Action, capType, formatFilterFloat(*capValue), c.Mode), nil
+	case "price":
+		if c.MinPrice != nil {
+			return fmt.Sprintf("price/min/%s", formatFilterFloat(*c.MinPrice)), nil
+		}
+		return fmt.Sprintf("price/max/%s", formatFilterFloat(*c.MaxPrice)), nil
+	case "priceFeed":
+		return fmt.Sprintf("priceFeed/%s/%s/%s", c.ComparisonMode, c.FeedType, c.FeedURL), nil
+	case "maxOrders":
+		maxOffersPerSide := uint16(0)
+		if c.MaxOffersPerSide != nil {
+			maxOffersPerSide = *c.MaxOffersPerSide
+		}
+		maxOrderBaseAmount := 0.0
+		if c.MaxOrderBaseAmount != nil {
+			maxOrderBaseAmount = *c.MaxOrderBaseAmount
+		}
+		return fmt.Sprintf("maxOrders/%d/%s", maxOffersPerSide, formatFilterFloat(maxOrderBaseAmount)), nil
+	case "schedule":
+		return fmt.Sprintf("schedule/%s", strings.Join(c.Schedule, "/")), nil
+	}
+	if isRegisteredFilterType(c.Type) {
+		if c.Config == "" {
+			return c.Type, nil
+		}
+		return fmt.Sprintf("%s/%s", c.Type, c.Config), nil
+	}
+	return "", fmt.Errorf("programmer error? unhandled filter type '%s'", c.Type)
+}
+
+func formatFilterFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
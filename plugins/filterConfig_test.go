@@ -0,0 +1,207 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterConfigFilterString(t *testing.T) {
+	maxOffersPerSide := uint16(10)
+	testCases := []struct {
+		name       string
+		config     FilterConfig
+		wantString string
+	}{
+		{
+			name: "volume base cap",
+			config: FilterConfig{
+				Type:    "volume",
+				Action:  "sell",
+				BaseCap: pointy.Float64(3500.0),
+				Mode:    "exact",
+			},
+			wantString: "volume/daily/sell/base/3500/exact",
+		}, {
+			name: "volume quote cap with market and account ids",
+			config: FilterConfig{
+				Type:       "volume",
+				Window:     "daily",
+				Action:     "buy",
+				QuoteCap:   pointy.Float64(1000.5),
+				Mode:       "ignore",
+				MarketIDs:  []string{"4c19915f47", "db4531d586"},
+				AccountIDs: []string{"account1"},
+			},
+			wantString: "volume/daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1]/buy/quote/1000.5/ignore",
+		}, {
+			name:       "min price",
+			config:     FilterConfig{Type: "price", MinPrice: pointy.Float64(0.04)},
+			wantString: "price/min/0.04",
+		}, {
+			name:       "max price",
+			config:     FilterConfig{Type: "price", MaxPrice: pointy.Float64(1.0)},
+			wantString: "price/max/1",
+		}, {
+			name: "price feed",
+			config: FilterConfig{
+				Type:           "priceFeed",
+				ComparisonMode: "outside-exclude",
+				FeedType:       "exchange",
+				FeedURL:        "kraken/XXLM/ZUSD/mid",
+			},
+			wantString: "priceFeed/outside-exclude/exchange/kraken/XXLM/ZUSD/mid",
+		}, {
+			name:       "max orders",
+			config:     FilterConfig{Type: "maxOrders", MaxOffersPerSide: &maxOffersPerSide},
+			wantString: "maxOrders/10/0",
+		}, {
+			name:       "schedule",
+			config:     FilterConfig{Type: "schedule", Schedule: []string{"Mo,Tu=08:00-16:00", "Sa=10:00-12:00"}},
+			wantString: "schedule/Mo,Tu=08:00-16:00/Sa=10:00-12:00",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := k.config.FilterString()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantString, actual)
+
+			// the string form needs to be parseable by the existing string parsers
+			switch k.config.Type {
+			case "volume":
+				_, e = makeVolumeFilterConfig(actual)
+			case "maxOrders":
+				_, e = makeMaxOrdersFilterConfig(actual)
+			case "schedule":
+				_, e = makeScheduleFilterConfig(actual)
+			}
+			assert.NoError(t, e)
+		})
+	}
+}
+
+func TestFilterConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  FilterConfig
+		wantKey string
+	}{
+		{
+			name:    "unknown type",
+			config:  FilterConfig{Type: "foo"},
+			wantKey: "TYPE",
+		}, {
+			name:    "key from another type",
+			config:  FilterConfig{Type: "price", MinPrice: pointy.Float64(1.0), Mode: "exact"},
+			wantKey: "MODE",
+		}, {
+			name:    "volume missing action",
+			config:  FilterConfig{Type: "volume", BaseCap: pointy.Float64(1.0), Mode: "exact"},
+			wantKey: "ACTION",
+		}, {
+			name:    "volume invalid mode",
+			config:  FilterConfig{Type: "volume", Action: "sell", BaseCap: pointy.Float64(1.0), Mode: "foo"},
+			wantKey: "MODE",
+		}, {
+			name:    "volume both caps",
+			config:  FilterConfig{Type: "volume", Action: "sell", BaseCap: pointy.Float64(1.0), QuoteCap: pointy.Float64(1.0), Mode: "exact"},
+			wantKey: "QUOTE_CAP",
+		}, {
+			name:    "volume invalid market id",
+			config:  FilterConfig{Type: "volume", Action: "sell", BaseCap: pointy.Float64(1.0), Mode: "exact", MarketIDs: []string{"abc"}},
+			wantKey: "MARKET_IDS",
+		}, {
+			name:    "volume invalid window",
+			config:  FilterConfig{Type: "volume", Window: "weekly", Action: "sell", BaseCap: pointy.Float64(1.0), Mode: "exact"},
+			wantKey: "WINDOW",
+		}, {
+			name:    "price both limits",
+			config:  FilterConfig{Type: "price", MinPrice: pointy.Float64(1.0), MaxPrice: pointy.Float64(2.0)},
+			wantKey: "MAX_PRICE",
+		}, {
+			name:    "price feed invalid comparison mode",
+			config:  FilterConfig{Type: "priceFeed", ComparisonMode: "inside", FeedType: "exchange", FeedURL: "kraken/XXLM/ZUSD/mid"},
+			wantKey: "COMPARISON_MODE",
+		}, {
+			name:    "price feed missing url",
+			config:  FilterConfig{Type: "priceFeed", ComparisonMode: "outside-include", FeedType: "exchange"},
+			wantKey: "FEED_URL",
+		}, {
+			name:    "max orders negative amount",
+			config:  FilterConfig{Type: "maxOrders", MaxOrderBaseAmount: pointy.Float64(-1.0)},
+			wantKey: "MAX_ORDER_BASE_AMOUNT",
+		}, {
+			name:    "schedule invalid window",
+			config:  FilterConfig{Type: "schedule", Schedule: []string{"Mo=16:00-08:00"}},
+			wantKey: "SCHEDULE",
+		}, {
+			name:    "config on builtin type",
+			config:  FilterConfig{Type: "schedule", Schedule: []string{"Mo=08:00-16:00"}, Config: "Mo=08:00-16:00"},
+			wantKey: "CONFIG",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := k.config.Validate()
+			if !assert.Error(t, e) {
+				return
+			}
+
+			keyError, ok := e.(*FilterConfigKeyError)
+			if !assert.True(t, ok, "error was not a *FilterConfigKeyError: %s", e) {
+				return
+			}
+			assert.Equal(t, k.wantKey, keyError.Key)
+		})
+	}
+}
+
+func TestCheckUndecodedFilterKeys(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		wantKey string
+	}{
+		{
+			name:    "known keys",
+			content: "SOURCE_SECRET_SEED = \"abc\"\n[[FILTER]]\nTYPE = \"price\"\nENABLED = false\nMIN_PRICE = 0.04\n",
+			wantKey: "",
+		}, {
+			name:    "misspelt key",
+			content: "[[FILTER]]\nTYPE = \"price\"\nMIN_PRCE = 0.04\n",
+			wantKey: "MIN_PRCE",
+		}, {
+			name:    "unknown key in second table",
+			content: "[[FILTER]]\nTYPE = \"price\"\nMIN_PRICE = 0.04\n[[FILTER]]\nTYPE = \"maxOrders\"\nMAX_OFFERS = 3\n",
+			wantKey: "MAX_OFFERS",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var tables filterConfigTables
+			md, e := toml.Decode(k.content, &tables)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			e = checkUndecodedFilterKeys(md)
+			if k.wantKey == "" {
+				assert.NoError(t, e)
+				return
+			}
+			keyError, ok := e.(*FilterConfigKeyError)
+			if !assert.True(t, ok, "error was not a *FilterConfigKeyError: %s", e) {
+				return
+			}
+			assert.Equal(t, k.wantKey, keyError.Key)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// action values used in FilterOpExplanation
+const (
+	filterActionDropped     = "dropped"
+	filterActionDeleted     = "deleted"
+	filterActionTransformed = "transformed"
+)
+
+// FilterOpExplanation describes a change that a single filter made to an operation or to an existing offer
+type FilterOpExplanation struct {
+	Filter       string `json:"filter"`
+	Action       string `json:"action"`
+	OfferID      int64  `json:"offer_id"`
+	Selling      string `json:"selling"`
+	Buying       string `json:"buying"`
+	PriceBefore  string `json:"price_before"`
+	AmountBefore string `json:"amount_before"`
+	PriceAfter   string `json:"price_after,omitempty"`
+	AmountAfter  string `json:"amount_after,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+// String is the Stringer method
+func (x FilterOpExplanation) String() string {
+	return fmt.Sprintf("filter=%s, action=%s, offerID=%d, selling=%s, buying=%s: %s", x.Filter, x.Action, x.OfferID, x.Selling, x.Buying, x.Reason)
+}
+
+// FilterChainExplanation is the structured explanation of all the changes the filter chain made in a single update cycle
+type FilterChainExplanation struct {
+	UpdateTime time.Time             `json:"update_time"`
+	NumOpsIn   int                   `json:"num_ops_in"`
+	NumOpsOut  int                   `json:"num_ops_out"`
+	Error      string                `json:"error,omitempty"`
+	Ops        []FilterOpExplanation `json:"ops"`
+}
+
+// FilterExplainer collects the changes made by the submit filters in each update cycle.
+// It is passed to the filters when they are made, a nil *FilterExplainer is valid and records nothing.
+type FilterExplainer struct {
+	logExplanations bool
+
+	// uninitialized
+	mutex   sync.Mutex
+	current *FilterChainExplanation
+	last    *FilterChainExplanation
+}
+
+// MakeFilterExplainer is a factory method
+func MakeFilterExplainer(logExplanations bool) *FilterExplainer {
+	return &FilterExplainer{
+		logExplanations: logExplanations,
+	}
+}
+
+// StartCycle begins a new explanation for the ops that are about to be passed through the filter chain
+func (fe *FilterExplainer) StartCycle(updateTime time.Time, numOpsIn int) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	fe.current = &FilterChainExplanation{
+		UpdateTime: updateTime,
+		NumOpsIn:   numOpsIn,
+		Ops:        []FilterOpExplanation{},
+	}
+}
+
+// EndCycle completes the explanation for the current cycle, filterError should be nil if the filter chain ran successfully
+func (fe *FilterExplainer) EndCycle(numOpsOut int, filterError error) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	if fe.current == nil {
+		return
+	}
+	fe.current.NumOpsOut = numOpsOut
+	if filterError != nil {
+		fe.current.Error = filterError.Error()
+	}
+	fe.last = fe.current
+	fe.current = nil
+
+	if fe.logExplanations {
+		log.Printf("filter chain explanation: %d ops in, %d ops out, %d changes made by filters\n", fe.last.NumOpsIn, fe.last.NumOpsOut, len(fe.last.Ops))
+		for i, x := range fe.last.Ops {
+			log.Printf("    filter chain explanation %d: %s\n", i+1, x)
+		}
+		if fe.last.Error != "" {
+			log.Printf("    filter chain explanation error: %s\n", fe.last.Error)
+		}
+	}
+}
+
+// LastExplanation returns the explanation from the last completed cycle, nil if no cycle has completed yet
+func (fe *FilterExplainer) LastExplanation() *FilterChainExplanation {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	return fe.last
+}
+
+// MarshalJSON gives the JSON representation of the last explanation
+func (fe *FilterExplainer) MarshalJSON() ([]byte, error) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	return json.Marshal(map[string]interface{}{
+		"last_update": fe.last,
+	})
+}
+
+func (fe *FilterExplainer) record(x FilterOpExplanation) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	if fe.current == nil {
+		// not inside an update cycle, for example when filters are run by a strategy
+		return
+	}
+	fe.current.Ops = append(fe.current.Ops, x)
+}
+
+// recordFilterExplanation records the change made by a filter, it is a no-op on a nil explainer so filters can call it unconditionally
+func (fe *FilterExplainer) recordFilterExplanation(filterName string, action string, before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer, reason string) {
+	if fe == nil {
+		return
+	}
+	fe.record(makeFilterOpExplanation(filterName, action, before, after, reason))
+}
+
+func makeFilterOpExplanation(filterName string, action string, before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer, reason string) FilterOpExplanation {
+	x := FilterOpExplanation{
+		Filter:       filterName,
+		Action:       action,
+		OfferID:      before.OfferID,
+		Selling:      txnAsset2String(before.Selling),
+		Buying:       txnAsset2String(before.Buying),
+		PriceBefore:  before.Price,
+		AmountBefore: before.Amount,
+		Reason:       reason,
+	}
+	if after != nil {
+		x.PriceAfter = after.Price
+		x.AmountAfter = after.Amount
+	}
+	return x
+}
+
+// explainTransformation describes how the op was changed by a filter
+func explainTransformation(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+	changes := []string{}
+	if before.Price != after.Price {
+		changes = append(changes, fmt.Sprintf("price changed from %s to %s", before.Price, after.Price))
+	}
+	if before.Amount != after.Amount {
+		changes = append(changes, fmt.Sprintf("amount changed from %s to %s", before.Amount, after.Amount))
+	}
+	if len(changes) == 0 {
+		return "existing offer updated to match the operation"
+	}
+	return strings.Join(changes, ", ")
+}
+
+func txnAsset2String(asset txnbuild.Asset) string {
+	if asset == nil {
+		return ""
+	}
+	return utils.Asset2String(utils.Asset2Asset2(asset))
+}
@@ -0,0 +1,104 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterExplainer(t *testing.T) {
+	dropFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return nil, nil
+	}
+	halveFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		op.Amount = "5.0000000"
+		return op, nil
+	}
+	keepFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return op, nil
+	}
+
+	testCases := []struct {
+		name       string
+		fn         filterFn
+		wantAction string
+		wantAfter  string
+		wantReason string
+	}{
+		{
+			name:       "dropped",
+			fn:         dropFn,
+			wantAction: filterActionDropped,
+			wantAfter:  "",
+			wantReason: "price is too high so the new operation is dropped",
+		}, {
+			name:       "transformed",
+			fn:         halveFn,
+			wantAction: filterActionTransformed,
+			wantAfter:  "5.0000000",
+			wantReason: "amount is too large: amount changed from 10.0000000 to 5.0000000",
+		}, {
+			name: "kept",
+			fn:   keepFn,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			fe := MakeFilterExplainer(false)
+			reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+				if after == nil {
+					return "price is too high"
+				}
+				return "amount is too large"
+			}
+
+			ops := []txnbuild.Operation{makeSellOpAmtPrice(10.0, 2.0)}
+			fe.StartCycle(time.Unix(0, 0), len(ops))
+			ops, e := filterOps("testFilter", fe, utils.NativeAsset, utils.NativeAsset, []hProtocol.Offer{}, []hProtocol.Offer{}, ops, k.fn, reasonFn)
+			if !assert.NoError(t, e) {
+				return
+			}
+			fe.EndCycle(len(ops), nil)
+
+			explanation := fe.LastExplanation()
+			if !assert.NotNil(t, explanation) {
+				return
+			}
+			assert.Equal(t, 1, explanation.NumOpsIn)
+			if k.wantAction == "" {
+				assert.Equal(t, 0, len(explanation.Ops), fmt.Sprintf("%v", explanation.Ops))
+				return
+			}
+
+			if !assert.Equal(t, 1, len(explanation.Ops)) {
+				return
+			}
+			x := explanation.Ops[0]
+			assert.Equal(t, "testFilter", x.Filter)
+			assert.Equal(t, k.wantAction, x.Action)
+			assert.Equal(t, "10.0000000", x.AmountBefore)
+			assert.Equal(t, k.wantAfter, x.AmountAfter)
+			assert.Equal(t, k.wantReason, x.Reason)
+		})
+	}
+}
+
+func TestFilterExplainerNil(t *testing.T) {
+	dropFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return nil, nil
+	}
+
+	// filters are made with a nil explainer when explanations are disabled
+	var fe *FilterExplainer
+	ops := []txnbuild.Operation{makeSellOpAmtPrice(10.0, 2.0)}
+	ops, e := filterOps("testFilter", fe, utils.NativeAsset, utils.NativeAsset, []hProtocol.Offer{}, []hProtocol.Offer{}, ops, dropFn, nil)
+	if assert.NoError(t, e) {
+		assert.Equal(t, 0, len(ops))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/model"
@@ -26,6 +27,20 @@ var filterMap = map[string]func(f *FilterFactory, configInput string) (SubmitFil
 	"volume":    filterVolume,
 	"price":     filterPrice,
 	"priceFeed": filterPriceFeed,
+	"maxOrders": filterMaxOrders,
+	"schedule":  filterSchedule,
+}
+
+// filtersSupportedOnAllStrategies are the filters that treat buy and sell offers the same way so they can be used with any strategy,
+// the other filters can only be used with the sell, sell_twap and delete strategies
+var filtersSupportedOnAllStrategies = map[string]bool{
+	"maxOrders": true,
+	"schedule":  true,
+}
+
+// FilterSupportsAllStrategies returns true if the filter with the name can be used with any strategy
+func FilterSupportsAllStrategies(filterName string) bool {
+	return filtersSupportedOnAllStrategies[filterName]
 }
 
 // FilterFactory is a struct that handles creating all the filters
@@ -36,6 +51,8 @@ type FilterFactory struct {
 	BaseAsset      hProtocol.Asset
 	QuoteAsset     hProtocol.Asset
 	DB             *sql.DB
+	// FilterExplainer records the changes made by the filters, it can be nil
+	FilterExplainer *FilterExplainer
 }
 
 // MakeFilter is the function that makes the required filters
@@ -69,6 +86,7 @@ func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
 		f.QuoteAsset,
 		f.DB,
 		config,
+		f.FilterExplainer,
 	)
 }
 
@@ -235,10 +253,10 @@ func filterPrice(f *FilterFactory, configInput string) (SubmitFilter, error) {
 	}
 	if parts[1] == "min" {
 		config := MinPriceFilterConfig{MinPrice: &limit}
-		return MakeFilterMinPrice(f.BaseAsset, f.QuoteAsset, &config)
+		return MakeFilterMinPrice(f.BaseAsset, f.QuoteAsset, &config, f.FilterExplainer)
 	} else if parts[1] == "max" {
 		config := MaxPriceFilterConfig{MaxPrice: &limit}
-		return MakeFilterMaxPrice(f.BaseAsset, f.QuoteAsset, &config)
+		return MakeFilterMaxPrice(f.BaseAsset, f.QuoteAsset, &config, f.FilterExplainer)
 	}
 	return nil, fmt.Errorf("invalid price filter type in second argument (%s)", configInput)
 }
@@ -258,10 +276,157 @@ func filterPriceFeed(f *FilterFactory, configInput string) (SubmitFilter, error)
 		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
 	}
 
-	filter, e := MakeFilterPriceFeed(f.BaseAsset, f.QuoteAsset, cmString, pf)
+	filter, e := MakeFilterPriceFeed(f.BaseAsset, f.QuoteAsset, cmString, pf, f.FilterExplainer)
 	if e != nil {
 		return nil, fmt.Errorf("could not make price feed filter for config input string '%s': %s", configInput, e)
 	}
 
 	return filter, nil
 }
+
+func filterMaxOrders(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	config, e := makeMaxOrdersFilterConfig(configInput)
+	if e != nil {
+		return nil, fmt.Errorf("could not make MaxOrdersFilterConfig for configInput (%s): %s", configInput, e)
+	}
+
+	return MakeFilterMaxOrders(f.BaseAsset, f.QuoteAsset, config, f.FilterExplainer)
+}
+
+func makeMaxOrdersFilterConfig(configInput string) (*MaxOrdersFilterConfig, error) {
+	// parts[0] = "maxOrders", parts[1] = maxOffersPerSide, parts[2] = maxOrderBaseAmount
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid input (%s), needs 3 parts separated by the delimiter (/)", configInput)
+	}
+
+	maxOffersPerSide, e := strconv.ParseUint(parts[1], 10, 16)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a non-negative integer value from config value (%s): %s", configInput, e)
+	}
+
+	maxOrderBaseAmount, e := strconv.ParseFloat(parts[2], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a float value from config value (%s): %s", configInput, e)
+	}
+
+	config := &MaxOrdersFilterConfig{
+		MaxOffersPerSide:   uint16(maxOffersPerSide),
+		MaxOrderBaseAmount: maxOrderBaseAmount,
+	}
+	if e = config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid input (%s), did not pass validation: %s", configInput, e)
+	}
+	return config, nil
+}
+
+func filterSchedule(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	config, e := makeScheduleFilterConfig(configInput)
+	if e != nil {
+		return nil, fmt.Errorf("could not make ScheduleFilterConfig for configInput (%s): %s", configInput, e)
+	}
+
+	return MakeFilterSchedule(f.BaseAsset, f.QuoteAsset, config, f.FilterExplainer)
+}
+
+func makeScheduleFilterConfig(configInput string) (*ScheduleFilterConfig, error) {
+	// parts[0] = "schedule", parts[1:] = day specs like "Mo,Tu,We,Th,Fr=08:00-16:00,17:00-22:00"
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid input (%s), needs at least 2 parts separated by the delimiter (/)", configInput)
+	}
+
+	config := &ScheduleFilterConfig{}
+	for _, daySpec := range parts[1:] {
+		e := parseScheduleDaySpec(config, daySpec)
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not parse day spec '%s': %s", configInput, daySpec, e)
+		}
+	}
+
+	if e := config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid input (%s), did not pass validation: %s", configInput, e)
+	}
+	return config, nil
+}
+
+// parseScheduleDaySpec parses a spec like "Mo,Tu,We=08:00-12:00,13:00-17:00" into the config, "*" can be used to select all days
+func parseScheduleDaySpec(config *ScheduleFilterConfig, daySpec string) error {
+	specParts := strings.Split(daySpec, "=")
+	if len(specParts) != 2 {
+		return fmt.Errorf("day spec '%s' needs to be of the form <days>=<windows>", daySpec)
+	}
+
+	days := []time.Weekday{}
+	if specParts[0] == "*" {
+		for dow := time.Sunday; dow <= time.Saturday; dow++ {
+			days = append(days, dow)
+		}
+	} else {
+		for _, dayCode := range strings.Split(specParts[0], ",") {
+			dow, ok := dayOfWeekCodes[strings.TrimSpace(dayCode)]
+			if !ok {
+				return fmt.Errorf("invalid day '%s', needs to be one of Mo, Tu, We, Th, Fr, Sa, Su or '*'", dayCode)
+			}
+			days = append(days, dow)
+		}
+	}
+
+	windows := []ScheduleWindow{}
+	for _, windowString := range strings.Split(specParts[1], ",") {
+		w, e := parseScheduleWindow(strings.TrimSpace(windowString))
+		if e != nil {
+			return fmt.Errorf("could not parse window '%s': %s", windowString, e)
+		}
+		windows = append(windows, w)
+	}
+
+	for _, dow := range days {
+		config.Windows[dow] = append(config.Windows[dow], windows...)
+	}
+	return nil
+}
+
+// parseScheduleWindow parses a window of the form "HH:MM-HH:MM", the end time can be "24:00" to indicate the end of the day
+func parseScheduleWindow(windowString string) (ScheduleWindow, error) {
+	timeParts := strings.Split(windowString, "-")
+	if len(timeParts) != 2 {
+		return ScheduleWindow{}, fmt.Errorf("window needs to be of the form HH:MM-HH:MM")
+	}
+
+	start, e := parseMinuteOfDay(timeParts[0])
+	if e != nil {
+		return ScheduleWindow{}, fmt.Errorf("could not parse start time: %s", e)
+	}
+	end, e := parseMinuteOfDay(timeParts[1])
+	if e != nil {
+		return ScheduleWindow{}, fmt.Errorf("could not parse end time: %s", e)
+	}
+
+	w := ScheduleWindow{Start: start, End: end}
+	if start >= end {
+		return ScheduleWindow{}, fmt.Errorf("start time needs to be before end time in window '%s'", w)
+	}
+	return w, nil
+}
+
+func parseMinuteOfDay(hhmm string) (int, error) {
+	parts := strings.Split(hhmm, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("time '%s' needs to be of the form HH:MM", hhmm)
+	}
+
+	hours, e := strconv.Atoi(parts[0])
+	if e != nil {
+		return 0, fmt.Errorf("could not parse hours in time '%s': %s", hhmm, e)
+	}
+	minutes, e := strconv.Atoi(parts[1])
+	if e != nil {
+		return 0, fmt.Errorf("could not parse minutes in time '%s': %s", hhmm, e)
+	}
+
+	if minutes < 0 || minutes > 59 || hours < 0 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("time '%s' is out of range, needs to be between 00:00 and 24:00", hhmm)
+	}
+	return hours*60 + minutes, nil
+}
@@ -237,3 +237,107 @@ func assertVolumeFilterConfigEqual(t *testing.T, want *VolumeFilterConfig, actua
 		assert.Equal(t, want.optionalAccountIDs, actual.optionalAccountIDs)
 	}
 }
+
+func TestMakeMaxOrdersFilterConfig(t *testing.T) {
+	testCases := []struct {
+		configInput string
+		wantConfig  *MaxOrdersFilterConfig
+		wantError   bool
+	}{
+		{
+			configInput: "maxOrders/10/0",
+			wantConfig:  &MaxOrdersFilterConfig{MaxOffersPerSide: 10, MaxOrderBaseAmount: 0},
+		}, {
+			configInput: "maxOrders/0/500.5",
+			wantConfig:  &MaxOrdersFilterConfig{MaxOffersPerSide: 0, MaxOrderBaseAmount: 500.5},
+		}, {
+			configInput: "maxOrders/5/100",
+			wantConfig:  &MaxOrdersFilterConfig{MaxOffersPerSide: 5, MaxOrderBaseAmount: 100},
+		}, {
+			configInput: "maxOrders/0/0",
+			wantError:   true,
+		}, {
+			configInput: "maxOrders/-1/100",
+			wantError:   true,
+		}, {
+			configInput: "maxOrders/5/-100",
+			wantError:   true,
+		}, {
+			configInput: "maxOrders/5",
+			wantError:   true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			actual, e := makeMaxOrdersFilterConfig(k.configInput)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantConfig, actual)
+		})
+	}
+}
+
+func TestMakeScheduleFilterConfig(t *testing.T) {
+	weekdays := []ScheduleWindow{{Start: 8 * 60, End: 16 * 60}, {Start: 17 * 60, End: 22*60 + 30}}
+	testCases := []struct {
+		configInput string
+		wantConfig  *ScheduleFilterConfig
+		wantError   bool
+	}{
+		{
+			configInput: "schedule/Mo,Tu,We,Th,Fr=08:00-16:00,17:00-22:30",
+			wantConfig: &ScheduleFilterConfig{Windows: [7][]ScheduleWindow{
+				nil, weekdays, weekdays, weekdays, weekdays, weekdays, nil,
+			}},
+		}, {
+			configInput: "schedule/*=00:00-24:00",
+			wantConfig: &ScheduleFilterConfig{Windows: [7][]ScheduleWindow{
+				{{Start: 0, End: 1440}}, {{Start: 0, End: 1440}}, {{Start: 0, End: 1440}}, {{Start: 0, End: 1440}},
+				{{Start: 0, End: 1440}}, {{Start: 0, End: 1440}}, {{Start: 0, End: 1440}},
+			}},
+		}, {
+			configInput: "schedule/Sa=10:00-12:00/Su=13:15-14:45",
+			wantConfig: &ScheduleFilterConfig{Windows: [7][]ScheduleWindow{
+				{{Start: 13*60 + 15, End: 14*60 + 45}}, nil, nil, nil, nil, nil, {{Start: 10 * 60, End: 12 * 60}},
+			}},
+		}, {
+			configInput: "schedule",
+			wantError:   true,
+		}, {
+			configInput: "schedule/Xx=08:00-16:00",
+			wantError:   true,
+		}, {
+			configInput: "schedule/Mo=16:00-08:00",
+			wantError:   true,
+		}, {
+			configInput: "schedule/Mo=08:00-24:01",
+			wantError:   true,
+		}, {
+			configInput: "schedule/Mo=0800-1600",
+			wantError:   true,
+		}, {
+			configInput: "schedule/Mo",
+			wantError:   true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			actual, e := makeScheduleFilterConfig(k.configInput)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantConfig, actual)
+		})
+	}
+}
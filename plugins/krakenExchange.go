@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"net/http"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Beldur/kraken-go-api-client"
@@ -15,11 +18,22 @@ import (
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/support/networking"
+	"github.com/stellar/kelp/support/utils"
 )
 
 // ensure that krakenExchange conforms to the Exchange interface
 var _ api.Exchange = &krakenExchange{}
 
+// ensure that krakenExchange can look up open and closed orders by their clientOrderID
+var _ api.ClientOrderIDTradeAPI = &krakenExchange{}
+var _ api.ClosedOrderByClientIDAPI = &krakenExchange{}
+
+// ensure that krakenExchange can measure the latency of its orderbook requests without the rate limiter
+var _ orderBookLatencyAPI = &krakenExchange{}
+
+// ensure that orders are submitted to krakenExchange one at a time
+var _ serialTradeAPI = &krakenExchange{}
+
 const precisionBalances = 10
 const tradesFetchSleepTimeSeconds = 60
 
@@ -28,7 +42,9 @@ type krakenExchange struct {
 	assetConverter           *model.AssetConverter
 	assetConverterOpenOrders *model.AssetConverter // kraken uses different symbols when fetching open orders!
 	apis                     []*krakenapi.KrakenApi
+	rateLimiters             []*networking.RateLimiter // rate limiters of the API keys
 	apiNextIndex             uint8
+	apiMutex                 sync.Mutex // guards apiNextIndex since orders can be submitted in parallel
 	delimiter                string
 	ocOverridesHandler       *OrderConstraintsOverridesHandler
 	withdrawKeys             asset2Address2Key
@@ -51,6 +67,32 @@ func (m asset2Address2Key) getKey(asset model.Asset, address string) (string, er
 	return key, nil
 }
 
+// kraken's API counter for the starter tier, see https://docs.kraken.com/rest/#section/Rate-Limits
+const krakenRateLimitCapacity = 15.0
+const krakenRateLimitRefillPerSecond = 0.33
+
+// krakenThrottleMarker is part of the error message that kraken responds with when the rate limit is exceeded
+const krakenThrottleMarker = "Rate limit exceeded"
+
+// krakenEndpointWeights are the costs of calls to the API counter, placing and cancelling orders is limited separately by the matching engine
+// and public endpoints are limited per IP address instead of counting towards the API counter of the key
+var krakenEndpointWeights = map[string]float64{
+	"TradesHistory": 2,
+	"Ledgers":       2,
+	"QueryTrades":   2,
+	"QueryLedgers":  2,
+	"AddOrder":      0,
+	"CancelOrder":   0,
+	"Time":          0,
+	"Assets":        0,
+	"AssetPairs":    0,
+	"Ticker":        0,
+	"Depth":         0,
+	"Trades":        0,
+	"Spread":        0,
+	"OHLC":          0,
+}
+
 // makeKrakenExchange is a factory method to make the kraken exchange
 // TODO 2, should take in config file for withdrawalKeys mapping
 func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exchange, error) {
@@ -59,15 +101,27 @@ func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exc
 	}
 
 	krakenAPIs := []*krakenapi.KrakenApi{}
+	rateLimiters := []*networking.RateLimiter{}
 	for _, apiKey := range apiKeys {
-		krakenAPIClient := krakenapi.New(apiKey.Key, apiKey.Secret)
+		// kraken's API counter is tracked per API key so each key gets its own rate limiter, shared with other bots in this process that use the same key.
+		// Bots using the same key in other processes are not accounted for.
+		keyHash, e := utils.HashString(apiKey.Key)
+		if e != nil {
+			// do not include the error since it contains the key
+			return nil, fmt.Errorf("could not hash kraken API key")
+		}
+		rateLimiter := networking.GetRateLimiter(fmt.Sprintf("kraken_%d", keyHash), krakenRateLimitCapacity, krakenRateLimitRefillPerSecond, krakenEndpointWeights)
+		httpClient := rateLimiter.WrapClient(http.DefaultClient, krakenThrottleMarker)
+		krakenAPIClient := krakenapi.NewWithClient(apiKey.Key, apiKey.Secret, httpClient)
 		krakenAPIs = append(krakenAPIs, krakenAPIClient)
+		rateLimiters = append(rateLimiters, rateLimiter)
 	}
 
 	return &krakenExchange{
 		assetConverter:           model.KrakenAssetConverter,
 		assetConverterOpenOrders: model.KrakenAssetConverterOpenOrders,
 		apis:                     krakenAPIs,
+		rateLimiters:             rateLimiters,
 		apiNextIndex:             0,
 		delimiter:                "",
 		ocOverridesHandler:       MakeEmptyOrderConstraintsOverridesHandler(),
@@ -76,8 +130,29 @@ func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exc
 	}, nil
 }
 
+// requiresSerialRequests impl., kraken signs private requests with a nonce that has to increase for each API key so they cannot be made in parallel
+func (k *krakenExchange) requiresSerialRequests() bool {
+	return true
+}
+
+// orderBookLatency impl., returns the network time of a Depth request sent since the given time on any of the API keys
+func (k *krakenExchange) orderBookLatency(since time.Time) (time.Duration, bool) {
+	found := false
+	var latency time.Duration
+	for _, r := range k.rateLimiters {
+		if l, ok := r.LastLatency("Depth", since); ok {
+			latency = l
+			found = true
+		}
+	}
+	return latency, found
+}
+
 // nextAPI rotates the API key being used so we can overcome rate limit issues
 func (k *krakenExchange) nextAPI() *krakenapi.KrakenApi {
+	k.apiMutex.Lock()
+	defer k.apiMutex.Unlock()
+
 	log.Printf("returning kraken API key at index %d", k.apiNextIndex)
 	api := k.apis[k.apiNextIndex]
 	// rotate key for the next call
@@ -105,18 +180,33 @@ func (k *krakenExchange) AddOrder(order *model.Order, submitMode api.SubmitMode)
 		return nil, fmt.Errorf("kraken volume precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.VolumePrecision, order.Volume.Precision(), order.Volume.AsFloat())
 	}
 
-	args := map[string]string{
-		"price": order.Price.AsString(),
+	if order.StopPrice != nil && order.StopPrice.Precision() > orderConstraints.PricePrecision {
+		return nil, fmt.Errorf("kraken stop price precision can be a maximum of %d, got %d, value = %.12f", orderConstraints.PricePrecision, order.StopPrice.Precision(), order.StopPrice.AsFloat())
+	}
+
+	krakenOrderType, args, e := krakenOrderArgs(order)
+	if e != nil {
+		return nil, e
 	}
 	if submitMode == api.SubmitModeMakerOnly {
+		if order.OrderType.IsMarket() || order.OrderType.IsImmediateOrCancel() {
+			return nil, fmt.Errorf("kraken cannot submit a %s order as maker only", order.OrderType.String())
+		}
 		args["oflags"] = "post" // csv list as a string for multiple flags
 	}
-	log.Printf("kraken is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
-		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+	if order.ClientOrderID != "" {
+		// kraken's userref is a 32-bit integer
+		if _, e := strconv.ParseInt(order.ClientOrderID, 10, 32); e != nil {
+			return nil, fmt.Errorf("kraken needs the clientOrderID to be a 32-bit integer so it can be used as the userref, got '%s'", order.ClientOrderID)
+		}
+		args["userref"] = order.ClientOrderID
+	}
+	log.Printf("kraken is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s, clientOrderID=%s\n",
+		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String(), order.ClientOrderID)
 	resp, e := k.nextAPI().AddOrder(
 		pairStr,
 		order.OrderAction.String(),
-		order.OrderType.String(),
+		krakenOrderType,
 		order.Volume.AsString(),
 		args,
 	)
@@ -136,6 +226,39 @@ func (k *krakenExchange) AddOrder(order *model.Order, submitMode api.SubmitMode)
 	return nil, fmt.Errorf("no transactionIds returned from order creation")
 }
 
+// krakenOrderArgs returns kraken's ordertype and the price args for the order
+func krakenOrderArgs(order *model.Order) (string, map[string]string, error) {
+	switch order.OrderType {
+	case model.OrderTypeMarket:
+		return "market", map[string]string{}, nil
+	case model.OrderTypeLimit:
+		return "limit", map[string]string{"price": order.Price.AsString()}, nil
+	case model.OrderTypeImmediateOrCancel:
+		return "limit", map[string]string{
+			"price":       order.Price.AsString(),
+			"timeinforce": "IOC",
+		}, nil
+	case model.OrderTypeStopLimit:
+		if order.StopPrice == nil {
+			return "", nil, fmt.Errorf("stop-limit order needs a stop price")
+		}
+		// for stop-loss-limit orders kraken's price is the trigger price and price2 is the limit price
+		return "stop-loss-limit", map[string]string{
+			"price":  order.StopPrice.AsString(),
+			"price2": order.Price.AsString(),
+		}, nil
+	}
+	return "", nil, fmt.Errorf("kraken does not support the order type: %s", order.OrderType.String())
+}
+
+// krakenOrderTypeFromString handles kraken's name for stop-limit orders in addition to the common order types
+func krakenOrderTypeFromString(s string) model.OrderType {
+	if s == "stop-loss-limit" {
+		return model.OrderTypeStopLimit
+	}
+	return model.OrderTypeFromString(s)
+}
+
 // CancelOrder impl.
 func (k *krakenExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
 	if k.isSimulated {
@@ -224,11 +347,69 @@ func (k *krakenExchange) GetAssetConverter() model.AssetConverterInterface {
 
 // GetOpenOrders impl.
 func (k *krakenExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
-	openOrdersResponse, e := k.nextAPI().OpenOrders(map[string]string{})
+	return k.getOpenOrders(pairs, map[string]string{})
+}
+
+// GetOpenOrderByClientID impl, looks up the order using the userref that was set from the clientOrderID in AddOrder
+func (k *krakenExchange) GetOpenOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error) {
+	if _, e := strconv.ParseInt(clientOrderID, 10, 32); e != nil {
+		return nil, fmt.Errorf("kraken needs the clientOrderID to be a 32-bit integer since it is used as the userref, got '%s'", clientOrderID)
+	}
+
+	m, e := k.getOpenOrders([]*model.TradingPair{pair}, map[string]string{"userref": clientOrderID})
+	if e != nil {
+		return nil, fmt.Errorf("cannot load open orders for clientOrderID '%s': %s", clientOrderID, e)
+	}
+
+	openOrders := m[*pair]
+	if len(openOrders) == 0 {
+		return nil, nil
+	}
+	if len(openOrders) > 1 {
+		return nil, fmt.Errorf("there was more than 1 open order with clientOrderID '%s': %v", clientOrderID, openOrders)
+	}
+	openOrder := openOrders[0]
+	openOrder.ClientOrderID = clientOrderID
+	return &openOrder, nil
+}
+
+// GetClosedOrderByClientID impl, looks up a closed order using the userref that was set from the clientOrderID in AddOrder
+func (k *krakenExchange) GetClosedOrderByClientID(pair *model.TradingPair, clientOrderID string) (*model.OpenOrder, error) {
+	if _, e := strconv.ParseInt(clientOrderID, 10, 32); e != nil {
+		return nil, fmt.Errorf("kraken needs the clientOrderID to be a 32-bit integer since it is used as the userref, got '%s'", clientOrderID)
+	}
+
+	closedOrdersResponse, e := k.nextAPI().ClosedOrders(map[string]string{"userref": clientOrderID})
+	if e != nil {
+		return nil, fmt.Errorf("cannot load closed orders for clientOrderID '%s': %s", clientOrderID, e)
+	}
+	m, e := k.convertOrders([]*model.TradingPair{pair}, closedOrdersResponse.Closed)
+	if e != nil {
+		return nil, fmt.Errorf("cannot convert closed orders for clientOrderID '%s': %s", clientOrderID, e)
+	}
+
+	closedOrders := m[*pair]
+	if len(closedOrders) == 0 {
+		return nil, nil
+	}
+	if len(closedOrders) > 1 {
+		return nil, fmt.Errorf("there was more than 1 closed order with clientOrderID '%s': %v", clientOrderID, closedOrders)
+	}
+	closedOrder := closedOrders[0]
+	closedOrder.ClientOrderID = clientOrderID
+	return &closedOrder, nil
+}
+
+func (k *krakenExchange) getOpenOrders(pairs []*model.TradingPair, args map[string]string) (map[model.TradingPair][]model.OpenOrder, error) {
+	openOrdersResponse, e := k.nextAPI().OpenOrders(args)
 	if e != nil {
 		return nil, fmt.Errorf("cannot load open orders for Kraken: %s", e)
 	}
+	return k.convertOrders(pairs, openOrdersResponse.Open)
+}
 
+// convertOrders converts the open or closed orders returned by kraken for the requested pairs
+func (k *krakenExchange) convertOrders(pairs []*model.TradingPair, orders map[string]krakenapi.Order) (map[model.TradingPair][]model.OpenOrder, error) {
 	// convert to a map so we can easily search for the existence of a trading pair
 	// kraken uses different symbols when fetching open orders!
 	pairsMap, e := model.TradingPairs2Strings2(k.assetConverterOpenOrders, "", pairs)
@@ -238,7 +419,7 @@ func (k *krakenExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.Tr
 
 	assetConverters := []model.AssetConverterInterface{*k.assetConverterOpenOrders, model.Display}
 	m := map[model.TradingPair][]model.OpenOrder{}
-	for ID, o := range openOrdersResponse.Open {
+	for ID, o := range orders {
 		// kraken uses different symbols when fetching open orders!
 		pair, e := model.TradingPairFromString2(3, assetConverters, o.Description.AssetPair)
 		if e != nil {
@@ -262,7 +443,7 @@ func (k *krakenExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.Tr
 			Order: model.Order{
 				Pair:        pair,
 				OrderAction: model.OrderActionFromString(o.Description.Type),
-				OrderType:   model.OrderTypeFromString(o.Description.OrderType),
+				OrderType:   krakenOrderTypeFromString(o.Description.OrderType),
 				Price:       model.MustNumberFromString(o.Description.PrimaryPrice, orderConstraints.PricePrecision),
 				Volume:      model.MustNumberFromString(o.Volume, orderConstraints.VolumePrecision),
 				Timestamp:   model.MakeTimestamp(int64(o.OpenTime)),
@@ -474,6 +655,7 @@ func (k *krakenExchange) getTradeHistoryFromEndAscLimit50(tradingPair model.Trad
 		_cost := m["cost"].(string)
 		_fee := m["fee"].(string)
 		_pair := m["pair"].(string)
+		_ordertxid, _ := m["ordertxid"].(string)
 		var pair *model.TradingPair
 		pair, e = model.TradingPairFromString(4, k.assetConverter, _pair)
 		if e != nil {
@@ -491,7 +673,7 @@ func (k *krakenExchange) getTradeHistoryFromEndAscLimit50(tradingPair model.Trad
 				Order: model.Order{
 					Pair:        pair,
 					OrderAction: model.OrderActionFromString(_type),
-					OrderType:   model.OrderTypeFromString(_ordertype),
+					OrderType:   krakenOrderTypeFromString(_ordertype),
 					Price:       model.MustNumberFromString(_price, orderConstraints.PricePrecision),
 					Volume:      model.MustNumberFromString(_vol, orderConstraints.VolumePrecision),
 					Timestamp:   ts,
@@ -499,7 +681,7 @@ func (k *krakenExchange) getTradeHistoryFromEndAscLimit50(tradingPair model.Trad
 				TransactionID: model.MakeTransactionID(_txid),
 				Cost:          model.MustNumberFromString(_cost, feeCostPrecision),
 				Fee:           model.MustNumberFromString(_fee, feeCostPrecision),
-				// OrderID unavailable?
+				OrderID:       _ordertxid,
 			})
 		}
 	}
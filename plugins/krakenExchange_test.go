@@ -430,6 +430,43 @@ func TestAddOrder(t *testing.T) {
 	assert.Fail(t, "force fail")
 }
 
+func TestKrakenOrderArgs(t *testing.T) {
+	price := model.NumberFromFloat(0.12, 5)
+	stopPrice := model.NumberFromFloat(0.11, 5)
+	testCases := []struct {
+		orderType     model.OrderType
+		stopPrice     *model.Number
+		wantOrderType string
+		wantArgs      map[string]string
+		wantErr       bool
+	}{
+		{model.OrderTypeMarket, nil, "market", map[string]string{}, false},
+		{model.OrderTypeLimit, nil, "limit", map[string]string{"price": "0.12000"}, false},
+		{model.OrderTypeImmediateOrCancel, nil, "limit", map[string]string{"price": "0.12000", "timeinforce": "IOC"}, false},
+		{model.OrderTypeStopLimit, stopPrice, "stop-loss-limit", map[string]string{"price": "0.11000", "price2": "0.12000"}, false},
+		{model.OrderTypeStopLimit, nil, "", nil, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.orderType.String(), func(t *testing.T) {
+			orderType, args, e := krakenOrderArgs(&model.Order{
+				OrderType: k.orderType,
+				Price:     price,
+				StopPrice: k.stopPrice,
+			})
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOrderType, orderType)
+			assert.Equal(t, k.wantArgs, args)
+		})
+	}
+}
+
 func TestCancelOrder(t *testing.T) {
 	if testing.Short() {
 		return
@@ -17,15 +17,17 @@ type makerModeFilter struct {
 	tradingPair  *model.TradingPair
 	exchangeShim api.ExchangeShim
 	sdex         *SDEX
+	fe           *FilterExplainer
 }
 
 // MakeFilterMakerMode makes a submit filter based on the passed in submitMode
-func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair, fe *FilterExplainer) SubmitFilter {
 	return &makerModeFilter{
 		name:         "makeModeFilter",
 		tradingPair:  tradingPair,
 		exchangeShim: exchangeShim,
 		sdex:         sdex,
+		fe:           fe,
 	}
 }
 
@@ -42,19 +44,37 @@ func (f *makerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProto
 		return nil, fmt.Errorf("could not get assets: %s", e)
 	}
 
+	// the top prices are captured from the inner fn so the reason shows the prices that the op was compared against
+	var topBidPrice, topAskPrice *model.Number
 	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
-		topBidPrice, e := f.topOrderPriceExcludingTrader(ob.Bids(), buyingOffers, false)
+		topBidPrice, e = f.topOrderPriceExcludingTrader(ob.Bids(), buyingOffers, false)
 		if e != nil {
 			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for bids: %s", e)
 		}
-		topAskPrice, e := f.topOrderPriceExcludingTrader(ob.Asks(), sellingOffers, true)
+		topAskPrice, e = f.topOrderPriceExcludingTrader(ob.Asks(), sellingOffers, true)
 		if e != nil {
 			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for asks: %s", e)
 		}
 
 		return f.transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, op)
 	}
-	ops, e = filterOps(f.name, baseAsset, quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+		isSell, e := utils.IsSelling(baseAsset, quoteAsset, before.Selling, before.Buying)
+		if e != nil {
+			return "would take liquidity from the market"
+		}
+		if isSell && topBidPrice != nil {
+			return fmt.Sprintf("sell price %s would cross the top bid of %s (excluding our offers)", before.Price, topBidPrice.AsString())
+		}
+		if !isSell && topAskPrice != nil {
+			price, e := strconv.ParseFloat(before.Price, 64)
+			if e == nil && price > 0 {
+				return fmt.Sprintf("buy price %.7f would cross the top ask of %s (excluding our offers)", 1/price, topAskPrice.AsString())
+			}
+		}
+		return "would take liquidity from the market"
+	}
+	ops, e = filterOps(f.name, f.fe, baseAsset, quoteAsset, sellingOffers, buyingOffers, ops, innerFn, reasonFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// MaxOrdersFilterConfig limits the number of offers on each side of the book and the size of each individual offer
+type MaxOrdersFilterConfig struct {
+	MaxOffersPerSide   uint16  // 0 means there is no limit on the number of offers
+	MaxOrderBaseAmount float64 // denominated in units of the base asset, 0 means there is no limit on the size of an offer
+}
+
+type maxOrdersFilter struct {
+	name       string
+	config     *MaxOrdersFilterConfig
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+	fe         *FilterExplainer
+}
+
+// MakeFilterMaxOrders makes a submit filter that limits the number of offers per side and the amount of each offer
+func MakeFilterMaxOrders(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *MaxOrdersFilterConfig, fe *FilterExplainer) (SubmitFilter, error) {
+	if e := config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &maxOrdersFilter{
+		name:       "maxOrdersFilter",
+		config:     config,
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+		fe:         fe,
+	}, nil
+}
+
+var _ SubmitFilter = &maxOrdersFilter{}
+
+// Validate ensures validity
+func (c *MaxOrdersFilterConfig) Validate() error {
+	if c.MaxOrderBaseAmount < 0 {
+		return fmt.Errorf("MaxOrderBaseAmount cannot be negative: %.8f", c.MaxOrderBaseAmount)
+	}
+
+	if c.MaxOffersPerSide == 0 && c.MaxOrderBaseAmount == 0 {
+		return fmt.Errorf("need at least one of MaxOffersPerSide or MaxOrderBaseAmount to be non-zero")
+	}
+	return nil
+}
+
+// String is the stringer method
+func (c *MaxOrdersFilterConfig) String() string {
+	return fmt.Sprintf("MaxOrdersFilterConfig[MaxOffersPerSide=%d, MaxOrderBaseAmount=%.8f]", c.MaxOffersPerSide, c.MaxOrderBaseAmount)
+}
+
+// Apply relies on filterOps visiting each side of the book starting from the offer closest to the mid price, so the offers that
+// exceed the per-side limit are always the ones farthest from the mid price
+func (f *maxOrdersFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	numSellOffers := uint16(0)
+	numBuyOffers := uint16(0)
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return f.maxOrdersFilterFn(&numSellOffers, &numBuyOffers, op)
+	}
+
+	reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+		if after == nil {
+			return fmt.Sprintf("already have the maximum of %d offers on this side", f.config.MaxOffersPerSide)
+		}
+		return fmt.Sprintf("amount capped to the max order size of %.8f units of the base asset", f.config.MaxOrderBaseAmount)
+	}
+	ops, e := filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn, reasonFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	log.Printf("maxOrdersFilter: numSellOffers=%d, numBuyOffers=%d (%s)\n", numSellOffers, numBuyOffers, f.config)
+	return ops, nil
+}
+
+func (f *maxOrdersFilter) maxOrdersFilterFn(numSellOffers *uint16, numBuyOffers *uint16, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	counter := numBuyOffers
+	if isSell {
+		counter = numSellOffers
+	}
+	if f.config.MaxOffersPerSide > 0 && *counter >= f.config.MaxOffersPerSide {
+		return nil, nil
+	}
+
+	if f.config.MaxOrderBaseAmount > 0 {
+		op, e = capOpBaseAmount(op, isSell, f.config.MaxOrderBaseAmount)
+		if e != nil {
+			return nil, fmt.Errorf("could not cap amount of op: %s", e)
+		}
+	}
+
+	*counter++
+	return op, nil
+}
+
+// capOpBaseAmount reduces the amount of the op so it does not exceed maxBaseAmount. The amount on a buy op is denominated in the
+// quote asset and its price is inverted (base/quote) so we need to convert the base cap into quote units for buy ops.
+func capOpBaseAmount(op *txnbuild.ManageSellOffer, isSell bool, maxBaseAmount float64) (*txnbuild.ManageSellOffer, error) {
+	amount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+
+	maxAmount := maxBaseAmount
+	if !isSell {
+		price, e := strconv.ParseFloat(op.Price, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+		}
+		if price <= 0 {
+			return nil, fmt.Errorf("invalid price (%s) on buy op, needs to be positive", op.Price)
+		}
+		maxAmount = maxBaseAmount / price
+	}
+
+	if amount <= maxAmount {
+		return op, nil
+	}
+	op.Amount = fmt.Sprintf("%.7f", maxAmount)
+	return op, nil
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMaxOrdersQuoteAsset = hProtocol.Asset{
+	Type:   "credit_alphanum4",
+	Code:   "USD",
+	Issuer: "GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX",
+}
+
+func makeMaxOrdersTestOp(isSell bool, amount float64, price float64) *txnbuild.ManageSellOffer {
+	quote := txnbuild.CreditAsset{Code: testMaxOrdersQuoteAsset.Code, Issuer: testMaxOrdersQuoteAsset.Issuer}
+	op := &txnbuild.ManageSellOffer{
+		Selling: txnbuild.NativeAsset{},
+		Buying:  quote,
+		Amount:  fmt.Sprintf("%.7f", amount),
+		Price:   fmt.Sprintf("%.7f", price),
+	}
+	if !isSell {
+		op.Selling, op.Buying = op.Buying, op.Selling
+	}
+	return op
+}
+
+func TestMaxOrdersFilterFn(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      *MaxOrdersFilterConfig
+		numSell     uint16
+		numBuy      uint16
+		inputOp     *txnbuild.ManageSellOffer
+		wantOp      *txnbuild.ManageSellOffer
+		wantNumSell uint16
+		wantNumBuy  uint16
+	}{
+		{
+			name:        "sell under count limit",
+			config:      &MaxOrdersFilterConfig{MaxOffersPerSide: 2},
+			numSell:     1,
+			inputOp:     makeMaxOrdersTestOp(true, 10.0, 0.5),
+			wantOp:      makeMaxOrdersTestOp(true, 10.0, 0.5),
+			wantNumSell: 2,
+		}, {
+			name:        "sell at count limit",
+			config:      &MaxOrdersFilterConfig{MaxOffersPerSide: 2},
+			numSell:     2,
+			inputOp:     makeMaxOrdersTestOp(true, 10.0, 0.5),
+			wantOp:      nil,
+			wantNumSell: 2,
+		}, {
+			name:        "buy not affected by sell count",
+			config:      &MaxOrdersFilterConfig{MaxOffersPerSide: 2},
+			numSell:     2,
+			inputOp:     makeMaxOrdersTestOp(false, 10.0, 2.0),
+			wantOp:      makeMaxOrdersTestOp(false, 10.0, 2.0),
+			wantNumSell: 2,
+			wantNumBuy:  1,
+		}, {
+			name:        "sell amount capped",
+			config:      &MaxOrdersFilterConfig{MaxOrderBaseAmount: 5.0},
+			inputOp:     makeMaxOrdersTestOp(true, 10.0, 0.5),
+			wantOp:      makeMaxOrdersTestOp(true, 5.0, 0.5),
+			wantNumSell: 1,
+		}, {
+			name:        "sell amount under cap",
+			config:      &MaxOrdersFilterConfig{MaxOrderBaseAmount: 5.0},
+			inputOp:     makeMaxOrdersTestOp(true, 4.0, 0.5),
+			wantOp:      makeMaxOrdersTestOp(true, 4.0, 0.5),
+			wantNumSell: 1,
+		}, {
+			// buy op sells 10 quote units at 2 base per quote unit = 20 base units, capped at 5 base units = 2.5 quote units
+			name:       "buy amount capped in base units",
+			config:     &MaxOrdersFilterConfig{MaxOrderBaseAmount: 5.0},
+			inputOp:    makeMaxOrdersTestOp(false, 10.0, 2.0),
+			wantOp:     makeMaxOrdersTestOp(false, 2.5, 2.0),
+			wantNumBuy: 1,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &maxOrdersFilter{
+				name:       "maxOrdersFilter",
+				config:     k.config,
+				baseAsset:  utils.NativeAsset,
+				quoteAsset: testMaxOrdersQuoteAsset,
+			}
+
+			numSell, numBuy := k.numSell, k.numBuy
+			actual, e := f.maxOrdersFilterFn(&numSell, &numBuy, k.inputOp)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOp, actual)
+			assert.Equal(t, k.wantNumSell, numSell)
+			assert.Equal(t, k.wantNumBuy, numBuy)
+		})
+	}
+}
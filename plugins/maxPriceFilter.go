@@ -19,15 +19,17 @@ type maxPriceFilter struct {
 	config     *MaxPriceFilterConfig
 	baseAsset  hProtocol.Asset
 	quoteAsset hProtocol.Asset
+	fe         *FilterExplainer
 }
 
 // MakeFilterMaxPrice makes a submit filter that limits orders placed based on the price
-func MakeFilterMaxPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *MaxPriceFilterConfig) (SubmitFilter, error) {
+func MakeFilterMaxPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *MaxPriceFilterConfig, fe *FilterExplainer) (SubmitFilter, error) {
 	return &maxPriceFilter{
 		name:       "maxPriceFilter",
 		config:     config,
 		baseAsset:  baseAsset,
 		quoteAsset: quoteAsset,
+		fe:         fe,
 	}, nil
 }
 
@@ -47,13 +49,17 @@ func (c *MaxPriceFilterConfig) String() string {
 }
 
 func (f *maxPriceFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.maxPriceFilterFn)
+	ops, e := filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.maxPriceFilterFn, f.reasonFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
 	return ops, nil
 }
 
+func (f *maxPriceFilter) reasonFn(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+	return fmt.Sprintf("sell price %s is above the maximum price of %.8f", before.Price, *f.config.MaxPrice)
+}
+
 func (f *maxPriceFilter) maxPriceFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
 	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
 	if e != nil {
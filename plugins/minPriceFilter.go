@@ -19,15 +19,17 @@ type minPriceFilter struct {
 	config     *MinPriceFilterConfig
 	baseAsset  hProtocol.Asset
 	quoteAsset hProtocol.Asset
+	fe         *FilterExplainer
 }
 
 // MakeFilterMinPrice makes a submit filter that limits orders placed based on the price
-func MakeFilterMinPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *MinPriceFilterConfig) (SubmitFilter, error) {
+func MakeFilterMinPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *MinPriceFilterConfig, fe *FilterExplainer) (SubmitFilter, error) {
 	return &minPriceFilter{
 		name:       "minPriceFilter",
 		config:     config,
 		baseAsset:  baseAsset,
 		quoteAsset: quoteAsset,
+		fe:         fe,
 	}, nil
 }
 
@@ -47,13 +49,17 @@ func (c *MinPriceFilterConfig) String() string {
 }
 
 func (f *minPriceFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.minPriceFilterFn)
+	ops, e := filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.minPriceFilterFn, f.reasonFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
 	return ops, nil
 }
 
+func (f *minPriceFilter) reasonFn(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+	return fmt.Sprintf("sell price %s is below the minimum price of %.8f", before.Price, *f.config.MinPrice)
+}
+
 func (f *minPriceFilter) minPriceFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
 	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
 	if e != nil {
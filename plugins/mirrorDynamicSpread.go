@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// defaultDynamicSpreadWindow is the number of orderbook fetches used to measure volatility and latency when DYNAMIC_SPREAD_WINDOW is not set
+const defaultDynamicSpreadWindow = 20
+
+// orderBookLatencyAPI is implemented by exchanges whose requests go through a networking.RateLimiter, so the latency of an orderbook request
+// can be measured without the time it waited for the rate limit, which says nothing about how stale the orderbook is
+type orderBookLatencyAPI interface {
+	// orderBookLatency returns the network time of an orderbook request sent at or after since, false if there was no such request
+	orderBookLatency(since time.Time) (time.Duration, bool)
+}
+
+// orderBookFetchLatency is the network time of the orderbook request of the exchange that was started at fetchStart and ended now,
+// for exchanges that do not implement orderBookLatencyAPI this is the whole time taken by the call
+func orderBookFetchLatency(exchange interface{}, fetchStart time.Time) time.Duration {
+	if latencyAPI, ok := exchange.(orderBookLatencyAPI); ok {
+		if latency, ok := latencyAPI.orderBookLatency(fetchStart); ok {
+			return latency
+		}
+	}
+	return time.Since(fetchStart)
+}
+
+// spreadSample is a measurement taken from one GetOrderBook call on a backing exchange
+type spreadSample struct {
+	midPrice float64 // 0 if the orderbook did not have both bids and asks
+	latency  time.Duration
+}
+
+// dynamicSpread widens the per level spread of a backing exchange with the realized volatility of its mid price and the latency of its
+// orderbook requests, both measured over the last windowSize calls to GetOrderBook
+type dynamicSpread struct {
+	baseSpread             float64
+	volatilityMultiplier   float64
+	latencySpreadPerSecond float64
+	maxSpread              float64
+	windowSize             int
+
+	// uninitialized
+	samples []spreadSample
+}
+
+// makeDynamicSpread is a factory method
+func makeDynamicSpread(baseSpread float64, volatilityMultiplier float64, latencySpreadPerSecond float64, maxSpread float64, windowSize int) (*dynamicSpread, error) {
+	if windowSize == 0 {
+		windowSize = defaultDynamicSpreadWindow
+	}
+	if windowSize < 2 {
+		return nil, fmt.Errorf("DYNAMIC_SPREAD_WINDOW needs to be at least 2 to measure volatility, but was %d", windowSize)
+	}
+	if volatilityMultiplier < 0 {
+		return nil, fmt.Errorf("DYNAMIC_SPREAD_VOLATILITY_MULTIPLIER needs to be >= 0, but was %f", volatilityMultiplier)
+	}
+	if latencySpreadPerSecond < 0 {
+		return nil, fmt.Errorf("DYNAMIC_SPREAD_LATENCY_SPREAD_PER_SECOND needs to be >= 0, but was %f", latencySpreadPerSecond)
+	}
+	if maxSpread < baseSpread || maxSpread >= 1.0 {
+		return nil, fmt.Errorf("DYNAMIC_SPREAD_MAX needs to be in the range [PER_LEVEL_SPREAD, 1.0), but was %f (PER_LEVEL_SPREAD=%f)", maxSpread, baseSpread)
+	}
+
+	return &dynamicSpread{
+		baseSpread:             baseSpread,
+		volatilityMultiplier:   volatilityMultiplier,
+		latencySpreadPerSecond: latencySpreadPerSecond,
+		maxSpread:              maxSpread,
+		windowSize:             windowSize,
+		samples:                []spreadSample{},
+	}, nil
+}
+
+// addSample records the mid price and latency of a GetOrderBook call, dropping the oldest sample once the window is full
+func (d *dynamicSpread) addSample(midPrice float64, latency time.Duration) {
+	d.samples = append(d.samples, spreadSample{
+		midPrice: midPrice,
+		latency:  latency,
+	})
+	if len(d.samples) > d.windowSize {
+		d.samples = d.samples[len(d.samples)-d.windowSize:]
+	}
+}
+
+// volatility is the standard deviation of the log returns between consecutive mid prices in the window, 0 if there are fewer than 2 returns
+func (d *dynamicSpread) volatility() float64 {
+	returns := []float64{}
+	for i := 1; i < len(d.samples); i++ {
+		prev := d.samples[i-1].midPrice
+		curr := d.samples[i].midPrice
+		if prev <= 0 || curr <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(curr/prev))
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean = mean / float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance = variance / float64(len(returns)-1)
+	return math.Sqrt(variance)
+}
+
+// averageLatency is the mean latency of the GetOrderBook calls in the window
+func (d *dynamicSpread) averageLatency() time.Duration {
+	if len(d.samples) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, s := range d.samples {
+		total += s.latency
+	}
+	return total / time.Duration(len(d.samples))
+}
+
+// spread is the per level spread to use, capped at maxSpread
+func (d *dynamicSpread) spread() float64 {
+	spread := d.baseSpread +
+		d.volatilityMultiplier*d.volatility() +
+		d.latencySpreadPerSecond*d.averageLatency().Seconds()
+	return math.Min(spread, d.maxSpread)
+}
@@ -0,0 +1,124 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeDynamicSpread(t *testing.T) {
+	testCases := []struct {
+		name           string
+		volMultiplier  float64
+		latencySpread  float64
+		maxSpread      float64
+		windowSize     int
+		wantWindowSize int
+		wantErr        bool
+	}{
+		{"defaults window", 1.0, 0.001, 0.05, 0, defaultDynamicSpreadWindow, false},
+		{"custom window", 1.0, 0.001, 0.05, 5, 5, false},
+		{"window too small", 1.0, 0.001, 0.05, 1, 0, true},
+		{"negative volatility multiplier", -1.0, 0.001, 0.05, 5, 0, true},
+		{"negative latency spread", 1.0, -0.001, 0.05, 5, 0, true},
+		{"max below base spread", 1.0, 0.001, 0.001, 5, 0, true},
+		{"max not below 1", 1.0, 0.001, 1.0, 5, 0, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			d, e := makeDynamicSpread(0.005, k.volMultiplier, k.latencySpread, k.maxSpread, k.windowSize)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantWindowSize, d.windowSize)
+		})
+	}
+}
+
+func TestDynamicSpread(t *testing.T) {
+	testCases := []struct {
+		name               string
+		midPrices          []float64
+		latencies          []time.Duration
+		wantVolatility     float64
+		wantAverageLatency time.Duration
+		wantSpread         float64
+	}{
+		{
+			name:               "no samples uses base spread",
+			midPrices:          []float64{},
+			latencies:          []time.Duration{},
+			wantVolatility:     0,
+			wantAverageLatency: 0,
+			wantSpread:         0.005,
+		}, {
+			name:               "flat market adds latency only",
+			midPrices:          []float64{0.1, 0.1, 0.1, 0.1},
+			latencies:          []time.Duration{time.Second, time.Second, 3 * time.Second, 3 * time.Second},
+			wantVolatility:     0,
+			wantAverageLatency: 2 * time.Second,
+			wantSpread:         0.007,
+		}, {
+			name:               "volatile market",
+			midPrices:          []float64{0.1, 0.1 * math.Exp(0.01), 0.1, 0.1 * math.Exp(0.01)},
+			latencies:          []time.Duration{0, 0, 0, 0},
+			wantVolatility:     0.011547005,
+			wantAverageLatency: 0,
+			wantSpread:         0.005 + 2*0.011547005,
+		}, {
+			name:               "missing mid prices are skipped",
+			midPrices:          []float64{0.1, 0, 0.1, 0.1 * math.Exp(0.02), 0.1},
+			latencies:          []time.Duration{0, 0, 0, 0, 0},
+			wantVolatility:     0.028284271,
+			wantAverageLatency: 0,
+			wantSpread:         0.005 + 2*0.028284271,
+		}, {
+			name:               "capped at max spread",
+			midPrices:          []float64{0.1, 0.2, 0.1, 0.2},
+			latencies:          []time.Duration{0, 0, 0, 0},
+			wantVolatility:     0.800377,
+			wantAverageLatency: 0,
+			wantSpread:         0.1,
+		}, {
+			name:               "only the last window of samples is used",
+			midPrices:          []float64{0.1, 0.2, 0.1, 0.2, 0.1, 0.1, 0.1, 0.1, 0.1},
+			latencies:          []time.Duration{time.Minute, 0, 0, 0, 0, 0, 0, 0, 0},
+			wantVolatility:     0,
+			wantAverageLatency: 0,
+			wantSpread:         0.005,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			d, e := makeDynamicSpread(0.005, 2.0, 0.001, 0.1, 5)
+			if !assert.NoError(t, e) {
+				return
+			}
+			for i, mid := range k.midPrices {
+				d.addSample(mid, k.latencies[i])
+			}
+
+			assert.InDelta(t, k.wantVolatility, d.volatility(), 0.000001)
+			assert.Equal(t, k.wantAverageLatency, d.averageLatency())
+			assert.InDelta(t, k.wantSpread, d.spread(), 0.000001)
+		})
+	}
+}
+
+func TestMidPrice(t *testing.T) {
+	bids := []model.Order{{Price: model.NumberFromFloat(0.099, 7)}}
+	asks := []model.Order{{Price: model.NumberFromFloat(0.101, 7)}}
+
+	assert.InDelta(t, 0.1, midPrice(bids, asks), 0.0000001)
+	assert.Equal(t, 0.0, midPrice(bids, []model.Order{}))
+	assert.Equal(t, 0.0, midPrice([]model.Order{}, asks))
+}
@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// mirrorHedgeWriter is a FillHandler on a backing exchange of the mirror strategy that copies the fill price and fees of each offset order onto
+// the strategy_mirror_trade_triggers row that placed it. It needs to be registered after the FillDBWriter of the backing exchange since it
+// aggregates the fills of the order from the trades table.
+type mirrorHedgeWriter struct {
+	db              *sql.DB
+	backingMarketID string
+}
+
+var _ api.FillHandler = &mirrorHedgeWriter{}
+
+// makeMirrorHedgeWriter is a factory method
+func makeMirrorHedgeWriter(db *sql.DB, backingMarketID string) api.FillHandler {
+	return &mirrorHedgeWriter{
+		db:              db,
+		backingMarketID: backingMarketID,
+	}
+}
+
+// HandleFill impl
+func (w *mirrorHedgeWriter) HandleFill(trade model.Trade) error {
+	orderID := trade.OrderID
+	if orderID == "" {
+		log.Printf("backing trade (txid=%s) has no orderID so it cannot be matched to a mirror trade trigger, the hedge will be missing from the mirror report\n", utils.CheckedString(trade.TransactionID))
+		return nil
+	}
+
+	result, e := w.db.Exec(kelpdb.SqlStrategyMirrorTradeTriggersUpdateBackingFill, w.backingMarketID, orderID)
+	if e != nil {
+		return fmt.Errorf("could not update the backing fill of the trade triggers for backing order (backing_market_id=%s, backing_order_id=%s): %s", w.backingMarketID, orderID, e)
+	}
+	numRows, e := result.RowsAffected()
+	if e != nil {
+		return fmt.Errorf("could not check the number of trade triggers updated for backing order (backing_market_id=%s, backing_order_id=%s): %s", w.backingMarketID, orderID, e)
+	}
+	if numRows > 0 {
+		log.Printf("updated backing fill of %d trade trigger(s) for backing order (backing_market_id=%s, backing_order_id=%s)\n", numRows, w.backingMarketID, orderID)
+	}
+	return nil
+}
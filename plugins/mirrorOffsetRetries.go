@@ -0,0 +1,462 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+)
+
+// statuses of an offset in the strategy_mirror_offset_retries table
+const (
+	offsetStatusPending = "pending"
+	// the last attempt failed with an error that does not show whether the order was placed, so the offset is only looked up by its
+	// clientOrderID and not placed again until "kelp offsets retry" marks it as pending
+	offsetStatusUnverified = "unverified"
+	offsetStatusCancelled  = "cancelled"
+)
+
+// nonSubmittingErrorMarkers are parts of AddOrder errors that show the order was rejected or never sent, lowercase
+var nonSubmittingErrorMarkers = []string{
+	"insufficient",      // insufficient funds or balance (kraken, binance, ccxt's InsufficientFunds)
+	"invalid arguments", // kraken's EGeneral:Invalid arguments
+	"invalidorder",      // ccxt's InvalidOrder
+	"eorder:",           // kraken's order errors
+	"filter failure",    // binance's order filters, e.g. MIN_NOTIONAL
+	"rate limit",        // the request was throttled
+	"ratelimitexceeded", // ccxt's RateLimitExceeded
+	"connection refused",
+	"no such host",
+	"cannot submit",
+	"not supported",
+	"unsupported order type",
+}
+
+// defaults for the OFFSET_RETRY_* config params of the mirror strategy
+const (
+	defaultOffsetRetryBaseDelaySeconds = 10
+	defaultOffsetRetryMaxDelaySeconds  = 600
+	defaultOffsetRetryMaxAgeSeconds    = 3600
+)
+
+// pendingOffset is an offset order that could not be placed on the backing exchange and is waiting to be retried
+type pendingOffset struct {
+	txID          string // txID of the trade on the primary exchange that is being offset
+	action        model.OrderAction
+	baseVolume    *model.Number // base volume that is committed to this offset in the baseSurplus
+	price         *model.Number // price of the trade on the primary exchange
+	status        string
+	attempts      int
+	createdAt     time.Time
+	nextAttemptAt time.Time
+	lastError     string
+	alerted       bool
+}
+
+// offsetStatusAfterError is the status of an offset whose order failed with the error, an offset is only placed again without
+// checking the backing exchange when the error shows that the order was not placed
+func offsetStatusAfterError(e error) string {
+	msg := strings.ToLower(e.Error())
+	for _, marker := range nonSubmittingErrorMarkers {
+		if strings.Contains(msg, marker) {
+			return offsetStatusPending
+		}
+	}
+	return offsetStatusUnverified
+}
+
+// longOffsetClientOrderIDDigits is the number of digits a venue needs to accept to use the full 64-bit hash as the clientOrderID
+const longOffsetClientOrderIDDigits = 20
+
+// offsetClientOrderID is the clientOrderID of every attempt to offset the trade with the txID, derived from the txID so that
+// an earlier attempt can be found on the backing exchange. It is the full 64-bit hash of the txID when the exchange accepts
+// clientOrderIDs with maxDigits >= longOffsetClientOrderIDDigits, otherwise it has the format of model.MakeClientOrderID
+// (e.g. kraken's 32-bit userref) and is more likely to be the same as the clientOrderID of another trade.
+func offsetClientOrderID(txID string, maxDigits int) string {
+	if maxDigits >= longOffsetClientOrderIDDigits {
+		h := fnv.New64a()
+		h.Write([]byte(txID))
+		return strconv.FormatUint(h.Sum64(), 10)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(txID))
+	return strconv.FormatInt(int64(h.Sum32()%(1<<31-1))+1, 10)
+}
+
+// offsetOrderMatches returns true if the order that was found by the clientOrderID of the offset has the action and the volume that
+// were placed for the offset, so an order of another trade whose txID hashes to the same clientOrderID is not taken as the offset
+func offsetOrderMatches(p *pendingOffset, order *model.OpenOrder, volumePrecision int8) bool {
+	if order.OrderAction != p.action {
+		return false
+	}
+	placedVolume := model.NumberByCappingPrecision(p.baseVolume, volumePrecision)
+	return math.Abs(order.Volume.AsFloat()-placedVolume.AsFloat()) < math.Pow(10, -float64(volumePrecision))/2
+}
+
+// unfilledOffsetSeparator joins the txID of the trade on the primary exchange and the orderID of an ioc offset order that was not fully filled
+const unfilledOffsetSeparator = ":unfilled:"
+
+// unfilledOffsetTxID is the txID used to queue the unfilled part of an ioc offset order, it is different from the txID of the trade
+// since the trade already has a trade trigger for the ioc order
+func unfilledOffsetTxID(txID string, orderID string) string {
+	return txID + unfilledOffsetSeparator + orderID
+}
+
+// offsetRetryDelay is the exponential backoff before the next retry of an offset that has failed the given number of times, capped at maxDelay
+func offsetRetryDelay(attempts int, baseDelay time.Duration, maxDelay time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// needsAlert returns true if the offset has been pending for longer than maxAge and we have not alerted on it yet
+func (p *pendingOffset) needsAlert(now time.Time, maxAge time.Duration) bool {
+	return !p.alerted && now.Sub(p.createdAt) > maxAge
+}
+
+// loadPendingOffsets reads the queued offsets of the market, oldest first
+func loadPendingOffsets(db *sql.DB, marketID string) ([]*pendingOffset, error) {
+	rows, e := db.Query(kelpdb.SqlQueryStrategyMirrorOffsetRetries, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not query offset retries for marketID '%s': %s", marketID, e)
+	}
+	defer rows.Close()
+
+	offsets := []*pendingOffset{}
+	for rows.Next() {
+		var action string
+		var baseVolume, price float64
+		p := &pendingOffset{}
+		e = rows.Scan(&p.txID, &action, &baseVolume, &price, &p.status, &p.attempts, &p.createdAt, &p.nextAttemptAt, &p.lastError, &p.alerted)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan offset retry row: %s", e)
+		}
+		p.action = model.OrderActionFromString(action)
+		if p.action.String() != action {
+			return nil, fmt.Errorf("invalid order action '%s' in offset retry for txid '%s'", action, p.txID)
+		}
+		p.baseVolume = model.NumberFromFloat(baseVolume, model.NumberConstants.Zero.Precision())
+		p.price = model.NumberFromFloat(price, model.NumberConstants.Zero.Precision())
+		offsets = append(offsets, p)
+	}
+	e = rows.Err()
+	if e != nil {
+		return nil, fmt.Errorf("error while iterating over offset retry rows: %s", e)
+	}
+	return offsets, nil
+}
+
+// committedPendingOffsets sums the base volume of the queued offsets for each action, this volume is committed in the baseSurplus
+func committedPendingOffsets(offsets []*pendingOffset) map[model.OrderAction]*model.Number {
+	committed := map[model.OrderAction]*model.Number{
+		model.OrderActionBuy:  model.NumberConstants.Zero,
+		model.OrderActionSell: model.NumberConstants.Zero,
+	}
+	for _, p := range offsets {
+		committed[p.action] = committed[p.action].Add(*p.baseVolume)
+	}
+	return committed
+}
+
+// enqueueOffset queues an offset that could not be placed so it is retried with a backoff, the volume stays committed in the baseSurplus
+func (s *mirrorStrategy) enqueueOffset(txID string, action model.OrderAction, baseVolume *model.Number, price *model.Number, status string, cause error) error {
+	now := time.Now().UTC()
+	p := &pendingOffset{
+		txID:          txID,
+		action:        action,
+		baseVolume:    baseVolume,
+		price:         price,
+		status:        status,
+		attempts:      1,
+		createdAt:     now,
+		nextAttemptAt: now.Add(offsetRetryDelay(1, s.offsetRetryBaseDelay, s.offsetRetryMaxDelay)),
+		lastError:     cause.Error(),
+		alerted:       false,
+	}
+	_, e := s.db.Exec(kelpdb.SqlStrategyMirrorOffsetRetriesInsert,
+		s.marketID,
+		p.txID,
+		p.action.String(),
+		p.baseVolume.AsFloat(),
+		p.price.AsFloat(),
+		p.status,
+		p.attempts,
+		p.createdAt,
+		p.nextAttemptAt,
+		p.lastError,
+		p.alerted,
+	)
+	if e != nil {
+		return fmt.Errorf("could not insert offset retry for txid '%s': %s", txID, e)
+	}
+	s.pendingOffsets[txID] = true
+
+	log.Printf("offset-queued | tradeID=%s | status=%s | newOrderAction=%s | newOrderBaseAmt=%f | tradePriceQuote=%f | nextAttemptAt=%s | error=%s\n",
+		p.txID,
+		p.status,
+		p.action.String(),
+		p.baseVolume.AsFloat(),
+		p.price.AsFloat(),
+		p.nextAttemptAt.Format(time.RFC3339),
+		p.lastError)
+	return nil
+}
+
+// retryPendingOffsets retries the queued offsets that are due and removes the ones that were cancelled, errors are only logged
+func (s *mirrorStrategy) retryPendingOffsets(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	offsets, e := loadPendingOffsets(s.db, s.marketID)
+	if e != nil {
+		log.Printf("unable to load offset retries, not retrying any offsets in this update cycle: %s\n", e)
+		return
+	}
+
+	s.pendingOffsets = map[string]bool{}
+	for _, p := range offsets {
+		s.pendingOffsets[p.txID] = true
+		if p.status == offsetStatusCancelled {
+			s.removePendingOffset(p, p.baseVolume)
+			log.Printf("offset-cancelled | tradeID=%s | newOrderAction=%s | newOrderBaseAmt=%f | baseSurplusTotal=%f | baseSurplusCommitted=%f\n",
+				p.txID,
+				p.action.String(),
+				p.baseVolume.AsFloat(),
+				s.baseSurplus[p.action].total.AsFloat(),
+				s.baseSurplus[p.action].committed.AsFloat())
+			continue
+		}
+
+		if now.Before(p.nextAttemptAt) {
+			if s.alertPendingOffset(p, now) {
+				s.updatePendingOffset(p)
+			}
+			continue
+		}
+		s.retryOffset(p, now)
+	}
+}
+
+// retryOffset makes one attempt to place a queued offset on the backing exchange
+func (s *mirrorStrategy) retryOffset(p *pendingOffset, now time.Time) {
+	// the offset may have been placed before we could remove it from the queue, we should never offset a trade twice
+	queryResult, e := s.strategyMirrorTradeTriggerExistsQuery.QueryRow(p.txID)
+	if e != nil {
+		log.Printf("unable to fetch trade trigger for transactionID '%s', not retrying the offset in this update cycle: %s\n", p.txID, e)
+		return
+	}
+	if rowExists, ok := queryResult.(bool); ok && rowExists {
+		log.Printf("queued offset for trade with txid '%s' was already placed because we have a row in the strategy_mirror_trade_triggers table with this txid, removing it from the queue\n", p.txID)
+		s.removePendingOffset(p, p.baseVolume)
+		return
+	}
+
+	if p.status == offsetStatusUnverified {
+		s.verifyOffset(p, now)
+		return
+	}
+
+	venue := s.routeOffset(p.action, p.baseVolume)
+	newOrder := model.Order{
+		Pair:          venue.pair,
+		OrderAction:   p.action,
+		OrderType:     s.offsetOrderType,
+		Price:         s.offsetPrice(p.price, p.action, venue.constraints),
+		Volume:        model.NumberByCappingPrecision(p.baseVolume, venue.constraints.VolumePrecision),
+		Timestamp:     nil,
+		ClientOrderID: venue.clientOrderID(p.txID),
+	}
+	tradeCursor := venue.tradeCursorForFill(newOrder.OrderType)
+	transactionID, e := venue.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
+	if e == nil && transactionID == nil {
+		e = fmt.Errorf("transactionID was <nil>")
+	}
+	if e != nil {
+		p.attempts++
+		p.status = offsetStatusAfterError(e)
+		p.nextAttemptAt = now.Add(offsetRetryDelay(p.attempts, s.offsetRetryBaseDelay, s.offsetRetryMaxDelay))
+		p.lastError = fmt.Sprintf("error when offsetting trade on backing exchange '%s' (newOrder=%s): %s", venue.name, newOrder, e)
+		s.alertPendingOffset(p, now)
+		s.updatePendingOffset(p)
+		log.Printf("offset-retry-failed | tradeID=%s | status=%s | backingExchange=%s | newOrderAction=%s | newOrderBaseAmt=%f | attempts=%d | nextAttemptAt=%s | error=%s\n",
+			p.txID,
+			p.status,
+			venue.name,
+			p.action.String(),
+			newOrder.Volume.AsFloat(),
+			p.attempts,
+			p.nextAttemptAt.Format(time.RFC3339),
+			p.lastError)
+		return
+	}
+
+	venue.cachedBaseBalance, venue.cachedQuoteBalance = nil, nil
+	// the order was placed so update the baseSurplus for the placed volume and release the volume committed to the queued offset
+	newTotal := s.baseSurplus[p.action].total.Subtract(*newOrder.Volume)
+	e = s.recordOffset(p.txID, venue.marketID, transactionID.String(), p.action, newTotal, p.txID)
+	if e != nil {
+		// the offset was placed so it should still leave the queue, otherwise it would be placed again on the next retry
+		log.Printf("error when recording the queued offset with txID=%s (newOrder=%s), removing it from the queue without a trade trigger: %s\n", transactionID.String(), newOrder, e)
+		s.removePendingOffset(p, newOrder.Volume)
+	} else {
+		s.baseSurplus[p.action].total = newTotal
+		s.baseSurplus[p.action].committed = s.baseSurplus[p.action].committed.Subtract(*p.baseVolume)
+		delete(s.pendingOffsets, p.txID)
+	}
+
+	log.Printf("offset-retry-success | tradeID=%s | backingExchange=%s | newOrderAction=%s | attempts=%d | baseSurplusTotal=%f | baseSurplusCommitted=%f | newOrderBaseAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
+		p.txID,
+		venue.name,
+		p.action.String(),
+		p.attempts,
+		s.baseSurplus[p.action].total.AsFloat(),
+		s.baseSurplus[p.action].committed.AsFloat(),
+		newOrder.Volume.AsFloat(),
+		newOrder.Price.AsFloat(),
+		transactionID)
+
+	if tradeCursor != nil {
+		e = s.requeueUnfilledOffset(p.txID, venue, &newOrder, transactionID.String(), tradeCursor, p.price)
+		if e != nil {
+			log.Printf("error when checking the fill of the ioc offset order (txID=%s) for a queued offset: %s\n", transactionID.String(), e)
+		}
+	}
+
+	trades, e := venue.fillTracker.FillTrackSingleIteration()
+	if e != nil {
+		log.Printf("unable to track a single iteration of fills from the backing exchange '%s' after retrying an offset: %s\n", venue.name, e)
+		return
+	}
+	log.Printf("found %d trades on load from backing exchange '%s' after retrying an offset\n", len(trades), venue.name)
+}
+
+// verifyOffset looks for the order of an earlier attempt of the offset on the venues that can find orders by their clientOrderID,
+// first in the open orders and then in the closed orders of the venues that support it. An order that is found is recorded as the
+// offset and the volume of a closed order that was not filled is queued to be offset again. Otherwise we cannot tell whether it was
+// placed (the exchange may not return it once it was filled) so it is not placed again, it waits for "kelp offsets retry" or
+// "kelp offsets cancel" and alerts once it is older than the max age.
+func (s *mirrorStrategy) verifyOffset(p *pendingOffset, now time.Time) {
+	for _, venue := range s.venues {
+		clientOrderID := venue.clientOrderID(p.txID)
+		if clientOrderID == "" {
+			continue
+		}
+
+		order, closed, e := venue.findOrderByClientID(clientOrderID)
+		if e != nil {
+			log.Printf("could not look up the queued offset for txid '%s' by clientOrderID '%s' on backing exchange '%s': %s\n", p.txID, clientOrderID, venue.name, e)
+			continue
+		}
+		if order == nil {
+			continue
+		}
+		if !offsetOrderMatches(p, order, venue.constraints.VolumePrecision) {
+			log.Printf("order '%s' with clientOrderID '%s' on backing exchange '%s' (action=%s, volume=%s) does not match the queued offset for txid '%s' (action=%s, baseVolume=%f), it belongs to another trade\n",
+				order.ID, clientOrderID, venue.name, order.OrderAction.String(), order.Volume.AsString(), p.txID, p.action.String(), p.baseVolume.AsFloat())
+			continue
+		}
+
+		if closed && order.VolumeExecuted.AsFloat() <= 0.0 {
+			// the earlier attempt was placed but closed without a fill so the offset was not done and can be placed again
+			p.status = offsetStatusPending
+			p.nextAttemptAt = now
+			s.updatePendingOffset(p)
+			log.Printf("offset-not-filled | tradeID=%s | backingExchange=%s | clientOrderID=%s | transactionID=%s | newOrderBaseAmt=%f | the earlier attempt was closed without a fill, placing it again\n", p.txID, venue.name, clientOrderID, order.ID, p.baseVolume.AsFloat())
+			return
+		}
+
+		log.Printf("offset-verified | tradeID=%s | backingExchange=%s | clientOrderID=%s | transactionID=%s | closed=%v | newOrderBaseAmt=%f\n", p.txID, venue.name, clientOrderID, order.ID, closed, p.baseVolume.AsFloat())
+		newTotal := s.baseSurplus[p.action].total.Subtract(*p.baseVolume)
+		e = s.recordOffset(p.txID, venue.marketID, order.ID, p.action, newTotal, p.txID)
+		if e != nil {
+			log.Printf("error when recording the queued offset with txID=%s that was found on backing exchange '%s', will look it up again: %s\n", order.ID, venue.name, e)
+			return
+		}
+		s.baseSurplus[p.action].total = newTotal
+		s.baseSurplus[p.action].committed = s.baseSurplus[p.action].committed.Subtract(*p.baseVolume)
+		delete(s.pendingOffsets, p.txID)
+
+		if closed {
+			e = s.requeueUnfilledVolume(p.txID, &order.Order, order.ID, order.VolumeExecuted, p.price)
+			if e != nil {
+				log.Printf("error when queueing the unfilled volume of the closed offset order (txID=%s) for a queued offset: %s\n", order.ID, e)
+			}
+		}
+		return
+	}
+
+	p.nextAttemptAt = now.Add(offsetRetryDelay(p.attempts, s.offsetRetryBaseDelay, s.offsetRetryMaxDelay))
+	s.alertPendingOffset(p, now)
+	s.updatePendingOffset(p)
+	log.Printf("offset-unverified | tradeID=%s | newOrderAction=%s | newOrderBaseAmt=%f | nextAttemptAt=%s | error=%s | not placing it again because the last attempt may have been placed, "+
+		"use \"kelp offsets retry %s\" to place it again or \"kelp offsets cancel %s\" to drop it after checking the backing exchange\n",
+		p.txID,
+		p.action.String(),
+		p.baseVolume.AsFloat(),
+		p.nextAttemptAt.Format(time.RFC3339),
+		p.lastError,
+		p.txID,
+		p.txID)
+}
+
+// removePendingOffset deletes the offset from the queue and releases the volume committed to it, also removing dropVolume from the total baseSurplus
+func (s *mirrorStrategy) removePendingOffset(p *pendingOffset, dropVolume *model.Number) {
+	s.baseSurplus[p.action].total = s.baseSurplus[p.action].total.Subtract(*dropVolume)
+	s.baseSurplus[p.action].committed = s.baseSurplus[p.action].committed.Subtract(*p.baseVolume)
+	s.saveBaseSurplus(p.action)
+
+	_, e := s.db.Exec(kelpdb.SqlStrategyMirrorOffsetRetriesDelete, s.marketID, p.txID)
+	if e != nil {
+		log.Printf("error deleting offset retry for txid '%s': %s\n", p.txID, e)
+	}
+	delete(s.pendingOffsets, p.txID)
+}
+
+// updatePendingOffset saves the result of the last attempt of a queued offset, errors are only logged
+func (s *mirrorStrategy) updatePendingOffset(p *pendingOffset) {
+	_, e := s.db.Exec(kelpdb.SqlStrategyMirrorOffsetRetriesUpdateAttempt, s.marketID, p.txID, p.status, p.attempts, p.nextAttemptAt, p.lastError, p.alerted)
+	if e != nil {
+		log.Printf("error updating offset retry for txid '%s': %s\n", p.txID, e)
+	}
+}
+
+// alertPendingOffset triggers an alert once for an offset that has been pending for longer than the max age, returns true if it alerted
+func (s *mirrorStrategy) alertPendingOffset(p *pendingOffset, now time.Time) bool {
+	if s.alert == nil || !p.needsAlert(now, s.offsetRetryMaxAge) {
+		return false
+	}
+
+	description := fmt.Sprintf("mirror strategy could not offset trade '%s' on the backing exchange for %s", p.txID, now.Sub(p.createdAt).Round(time.Second))
+	e := s.alert.Trigger(description, map[string]interface{}{
+		"market_id":   s.marketID,
+		"txid":        p.txID,
+		"status":      p.status,
+		"action":      p.action.String(),
+		"base_volume": p.baseVolume.AsFloat(),
+		"attempts":    p.attempts,
+		"last_error":  p.lastError,
+	})
+	if e != nil {
+		log.Printf("unable to trigger alert for the offset of txid '%s', will try again in the next update cycle: %s\n", p.txID, e)
+		return false
+	}
+	p.alerted = true
+	return true
+}
@@ -0,0 +1,198 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetRetryDelay(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+		{6, 300 * time.Second},
+		{50, 300 * time.Second},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%d", k.attempts), func(t *testing.T) {
+			assert.Equal(t, k.want, offsetRetryDelay(k.attempts, 10*time.Second, 300*time.Second))
+		})
+	}
+}
+
+func TestOffsetStatusAfterError(t *testing.T) {
+	testCases := []struct {
+		err  string
+		want string
+	}{
+		{"EOrder:Insufficient funds", offsetStatusPending},
+		{"EGeneral:Invalid arguments:volume", offsetStatusPending},
+		{"ccxt returned an InvalidOrder error", offsetStatusPending},
+		{"code=-1013, msg=Filter failure: MIN_NOTIONAL", offsetStatusPending},
+		{"EAPI:Rate limit exceeded", offsetStatusPending},
+		{"dial tcp: lookup api.kraken.com: no such host", offsetStatusPending},
+		{"cannot submit an ioc order as maker only", offsetStatusPending},
+		{"net/http: request canceled (Client.Timeout exceeded while awaiting headers)", offsetStatusUnverified},
+		{"EService:Unavailable", offsetStatusUnverified},
+		{"invalid character '<' looking for beginning of value", offsetStatusUnverified},
+		{"transactionID was <nil>", offsetStatusUnverified},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.err, func(t *testing.T) {
+			assert.Equal(t, k.want, offsetStatusAfterError(fmt.Errorf("%s", k.err)))
+		})
+	}
+}
+
+func TestOffsetClientOrderID(t *testing.T) {
+	for _, txID := range []string{"", "123-0", "TXID1-ABCDE-FGHIJK", "123-0:unfilled:O1"} {
+		t.Run(txID, func(t *testing.T) {
+			id := offsetClientOrderID(txID, 0)
+			assert.Equal(t, id, offsetClientOrderID(txID, 0))
+			assert.Equal(t, id, offsetClientOrderID(txID, 19))
+
+			// kraken needs a positive 32-bit integer
+			n, e := strconv.ParseInt(id, 10, 32)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.True(t, n > 0)
+
+			longID := offsetClientOrderID(txID, 20)
+			assert.Equal(t, longID, offsetClientOrderID(txID, 36))
+			assert.True(t, len(longID) <= 20)
+			_, e = strconv.ParseUint(longID, 10, 64)
+			assert.NoError(t, e)
+		})
+	}
+	assert.NotEqual(t, offsetClientOrderID("123-0", 0), offsetClientOrderID("123-0:unfilled:O1", 0))
+	assert.NotEqual(t, offsetClientOrderID("123-0", 20), offsetClientOrderID("123-0:unfilled:O1", 20))
+}
+
+func TestOffsetOrderMatches(t *testing.T) {
+	p := &pendingOffset{
+		txID:       "tx1",
+		action:     model.OrderActionSell,
+		baseVolume: model.NumberFromFloat(10.123456, 7),
+	}
+	testCases := []struct {
+		name   string
+		action model.OrderAction
+		volume float64
+		want   bool
+	}{
+		{"placed volume", model.OrderActionSell, 10.12, true},
+		{"other action", model.OrderActionBuy, 10.12, false},
+		{"other volume", model.OrderActionSell, 10.13, false},
+		{"smaller volume", model.OrderActionSell, 10.11, false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			order := &model.OpenOrder{
+				Order: model.Order{
+					OrderAction: k.action,
+					Volume:      model.NumberFromFloat(k.volume, 2),
+				},
+			}
+			assert.Equal(t, k.want, offsetOrderMatches(p, order, 2))
+		})
+	}
+}
+
+func TestPendingOffsetNeedsAlert(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name    string
+		age     time.Duration
+		alerted bool
+		want    bool
+	}{
+		{"young", 10 * time.Minute, false, false},
+		{"exactly max age", time.Hour, false, false},
+		{"stale", 61 * time.Minute, false, true},
+		{"stale and already alerted", 61 * time.Minute, true, false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p := &pendingOffset{
+				createdAt: now.Add(-k.age),
+				alerted:   k.alerted,
+			}
+			assert.Equal(t, k.want, p.needsAlert(now, time.Hour))
+		})
+	}
+}
+
+func TestCommittedPendingOffsets(t *testing.T) {
+	offsets := []*pendingOffset{
+		{txID: "tx1", action: model.OrderActionBuy, baseVolume: model.NumberFromFloat(10.5, 7)},
+		{txID: "tx2", action: model.OrderActionSell, baseVolume: model.NumberFromFloat(3.0, 7)},
+		{txID: "tx3", action: model.OrderActionBuy, baseVolume: model.NumberFromFloat(2.25, 7)},
+	}
+
+	committed := committedPendingOffsets(offsets)
+	assert.Equal(t, 12.75, committed[model.OrderActionBuy].AsFloat())
+	assert.Equal(t, 3.0, committed[model.OrderActionSell].AsFloat())
+
+	committed = committedPendingOffsets([]*pendingOffset{})
+	assert.Equal(t, 0.0, committed[model.OrderActionBuy].AsFloat())
+	assert.Equal(t, 0.0, committed[model.OrderActionSell].AsFloat())
+}
+
+type recordingAlert struct {
+	descriptions []string
+	err          error
+}
+
+func (a *recordingAlert) Trigger(description string, details interface{}) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.descriptions = append(a.descriptions, description)
+	return nil
+}
+
+func TestAlertPendingOffset(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	makeOffset := func() *pendingOffset {
+		return &pendingOffset{
+			txID:       "tx1",
+			action:     model.OrderActionSell,
+			baseVolume: model.NumberFromFloat(5.0, 7),
+			createdAt:  now.Add(-2 * time.Hour),
+		}
+	}
+
+	// alerts once for a stale offset
+	alert := &recordingAlert{}
+	s := &mirrorStrategy{marketID: "market", alert: alert, offsetRetryMaxAge: time.Hour}
+	p := makeOffset()
+	assert.True(t, s.alertPendingOffset(p, now))
+	assert.True(t, p.alerted)
+	assert.False(t, s.alertPendingOffset(p, now))
+	assert.Equal(t, 1, len(alert.descriptions))
+
+	// does not mark the offset as alerted when the alert fails so we try again
+	s.alert = &recordingAlert{err: fmt.Errorf("pager down")}
+	p = makeOffset()
+	assert.False(t, s.alertPendingOffset(p, now))
+	assert.False(t, p.alerted)
+
+	// no alert configured
+	s.alert = nil
+	p = makeOffset()
+	assert.False(t, s.alertPendingOffset(p, now))
+}
@@ -4,9 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
 
@@ -40,13 +41,40 @@ type mirrorConfig struct {
 	AskVolumeDivideBy        *float64 `valid:"-" toml:"ASK_VOLUME_DIVIDE_BY"`
 	MaxOrderBaseCap          *float64 `valid:"-" toml:"MAX_ORDER_BASE_CAP"` // use a pointer here so we don't need to special case 0.0 everywhere and a nil value is clearly not user-entered
 	PerLevelSpread           float64  `valid:"-" toml:"PER_LEVEL_SPREAD"`
-	PricePrecisionOverride   *int8    `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`
-	VolumePrecisionOverride  *int8    `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"`
+	// DynamicSpread widens PER_LEVEL_SPREAD for each backing exchange with the volatility and latency measured from its orderbook requests
+	DynamicSpread                       bool    `valid:"-" toml:"DYNAMIC_SPREAD"`
+	DynamicSpreadWindow                 int     `valid:"-" toml:"DYNAMIC_SPREAD_WINDOW"`
+	DynamicSpreadVolatilityMultiplier   float64 `valid:"-" toml:"DYNAMIC_SPREAD_VOLATILITY_MULTIPLIER"`
+	DynamicSpreadLatencySpreadPerSecond float64 `valid:"-" toml:"DYNAMIC_SPREAD_LATENCY_SPREAD_PER_SECOND"`
+	DynamicSpreadMax                    float64 `valid:"-" toml:"DYNAMIC_SPREAD_MAX"`
+	PricePrecisionOverride              *int8   `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`
+	VolumePrecisionOverride             *int8   `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"`
 	// Deprecated: use MIN_BASE_VOLUME_OVERRIDE instead
 	MinBaseVolumeDeprecated                   *float64                 `valid:"-" toml:"MIN_BASE_VOLUME" deprecated:"true"`
 	MinBaseVolumeOverride                     *float64                 `valid:"-" toml:"MIN_BASE_VOLUME_OVERRIDE"`
 	MinQuoteVolumeOverride                    *float64                 `valid:"-" toml:"MIN_QUOTE_VOLUME_OVERRIDE"`
 	OffsetTrades                              bool                     `valid:"-" toml:"OFFSET_TRADES"`
+	OffsetOrderType                           string                   `valid:"-" toml:"OFFSET_ORDER_TYPE"`
+	OffsetMaxSlippage                         float64                  `valid:"-" toml:"OFFSET_MAX_SLIPPAGE"`
+	OffsetRetryBaseDelaySeconds               int                      `valid:"-" toml:"OFFSET_RETRY_BASE_DELAY_SECONDS"`
+	OffsetRetryMaxDelaySeconds                int                      `valid:"-" toml:"OFFSET_RETRY_MAX_DELAY_SECONDS"`
+	OffsetRetryMaxAgeSeconds                  int                      `valid:"-" toml:"OFFSET_RETRY_MAX_AGE_SECONDS"`
+	BackingDbOverrideAccountID                string                   `valid:"-" toml:"BACKING_DB_OVERRIDE__ACCOUNT_ID"`
+	BackingFillTrackerLastTradeCursorOverride string                   `valid:"-" toml:"BACKING_FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
+	ExchangeAPIKeys                           toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
+	ExchangeParams                            toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
+	ExchangeHeaders                           toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
+	// BackingExchanges are mirrored in addition to the backing exchange set with EXCHANGE
+	BackingExchanges []mirrorBackingExchangeConfig `valid:"-" toml:"BACKING_EXCHANGES"`
+}
+
+// mirrorBackingExchangeConfig contains the configuration params of a backing exchange listed in BACKING_EXCHANGES
+type mirrorBackingExchangeConfig struct {
+	Exchange                                  string                   `valid:"-" toml:"EXCHANGE"`
+	ExchangeBase                              string                   `valid:"-" toml:"EXCHANGE_BASE"`
+	ExchangeQuote                             string                   `valid:"-" toml:"EXCHANGE_QUOTE"`
+	BidVolumeDivideBy                         *float64                 `valid:"-" toml:"BID_VOLUME_DIVIDE_BY"`
+	AskVolumeDivideBy                         *float64                 `valid:"-" toml:"ASK_VOLUME_DIVIDE_BY"`
 	BackingDbOverrideAccountID                string                   `valid:"-" toml:"BACKING_DB_OVERRIDE__ACCOUNT_ID"`
 	BackingFillTrackerLastTradeCursorOverride string                   `valid:"-" toml:"BACKING_FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
 	ExchangeAPIKeys                           toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
@@ -60,9 +88,40 @@ func (c mirrorConfig) String() string {
 		"EXCHANGE_API_KEYS": utils.Hide,
 		"EXCHANGE_PARAMS":   utils.Hide,
 		"EXCHANGE_HEADERS":  utils.Hide,
+		"BACKING_EXCHANGES": utils.Hide,
 	})
 }
 
+// backingExchangeConfigs returns the config of every backing exchange, starting with the one set with EXCHANGE.
+// The volume divisors and db account ID of the top level of the config are used for the BACKING_EXCHANGES that do not set them.
+func (c mirrorConfig) backingExchangeConfigs() []mirrorBackingExchangeConfig {
+	configs := []mirrorBackingExchangeConfig{{
+		Exchange:                   c.Exchange,
+		ExchangeBase:               c.ExchangeBase,
+		ExchangeQuote:              c.ExchangeQuote,
+		BidVolumeDivideBy:          c.BidVolumeDivideBy,
+		AskVolumeDivideBy:          c.AskVolumeDivideBy,
+		BackingDbOverrideAccountID: c.BackingDbOverrideAccountID,
+		BackingFillTrackerLastTradeCursorOverride: c.BackingFillTrackerLastTradeCursorOverride,
+		ExchangeAPIKeys: c.ExchangeAPIKeys,
+		ExchangeParams:  c.ExchangeParams,
+		ExchangeHeaders: c.ExchangeHeaders,
+	}}
+	for _, b := range c.BackingExchanges {
+		if b.BidVolumeDivideBy == nil {
+			b.BidVolumeDivideBy = c.BidVolumeDivideBy
+		}
+		if b.AskVolumeDivideBy == nil {
+			b.AskVolumeDivideBy = c.AskVolumeDivideBy
+		}
+		if b.BackingDbOverrideAccountID == "" {
+			b.BackingDbOverrideAccountID = c.BackingDbOverrideAccountID
+		}
+		configs = append(configs, b)
+	}
+	return configs
+}
+
 // assetSurplus holds information about how many units of an asset needs to be offset on the exchange
 // negative values mean we have eagerly offset an asset, likely because of minBaseVolume requirements of the backingExchange
 type assetSurplus struct {
@@ -78,7 +137,25 @@ func makeAssetSurplus() *assetSurplus {
 	}
 }
 
-// mirrorStrategy is a strategy to mirror the orderbook of a given exchange
+// mirrorVenue is a backing exchange whose orderbook is mirrored and where trades can be offset
+type mirrorVenue struct {
+	name              string
+	exchange          api.Exchange
+	pair              *model.TradingPair
+	constraints       *model.OrderConstraints
+	marketID          string
+	fillTracker       api.FillTracker
+	bidVolumeDivideBy float64
+	askVolumeDivideBy float64
+	dynamicSpread     *dynamicSpread // nil when the spread is fixed at PER_LEVEL_SPREAD
+
+	// fetched once per update cycle so offsets can be routed without fetching them again, guarded by the strategy's mutex
+	cachedBaseBalance  *model.Number                 // nil when not fetched in the current update cycle
+	cachedQuoteBalance *model.Number                 // nil when not fetched in the current update cycle
+	cachedTopOfBook    map[model.OrderAction]float64 // price an offset order with the action would get, missing when not fetched
+}
+
+// mirrorStrategy is a strategy to mirror the orderbook of one or more exchanges
 type mirrorStrategy struct {
 	sdex                                  *SDEX
 	ieif                                  *IEIF
@@ -86,21 +163,23 @@ type mirrorStrategy struct {
 	quoteAsset                            *hProtocol.Asset
 	primaryConstraints                    *model.OrderConstraints
 	marketID                              string
-	backingPair                           *model.TradingPair
-	backingConstraints                    *model.OrderConstraints
-	backingMarketID                       string
-	backingFillTracker                    api.FillTracker
+	venues                                []*mirrorVenue
+	minBackingBaseVolume                  *model.Number // smallest minBaseVolume of all the venues
 	strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
 	orderbookDepth                        int
 	perLevelSpread                        float64
-	bidVolumeDivideBy                     float64
-	askVolumeDivideBy                     float64
 	maybeMaxOrderBaseCap                  *float64 // using a nil value makes it clear whether this value exists or not
-	exchange                              api.Exchange
 	offsetTrades                          bool
+	offsetOrderType                       model.OrderType
+	offsetMaxSlippage                     float64
+	offsetRetryBaseDelay                  time.Duration
+	offsetRetryMaxDelay                   time.Duration
+	offsetRetryMaxAge                     time.Duration // offsets that are pending for longer than this trigger an alert
 	mutex                                 *sync.Mutex
 	baseSurplus                           map[model.OrderAction]*assetSurplus // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
+	pendingOffsets                        map[string]bool                     // txIDs of the trades whose offsets are queued for a retry
 	db                                    *sql.DB
+	alert                                 api.Alert
 
 	// uninitialized
 	sellOnPrimaryBalanceCoordinator *balanceCoordinator
@@ -147,50 +226,32 @@ func makeMirrorStrategy(
 	marketID string,
 	config *mirrorConfig,
 	db *sql.DB,
+	alert api.Alert,
 	simMode bool,
 ) (api.Strategy, error) {
 	convertDeprecatedMirrorConfigValues(config)
-	var bidVolumeDivideBy float64
-	var askVolumeDivideBy float64
-	if config.BidVolumeDivideBy == nil {
-		bidVolumeDivideBy = 1.0
-	} else {
-		bidVolumeDivideBy = *config.BidVolumeDivideBy
-	}
-	if config.AskVolumeDivideBy == nil {
-		askVolumeDivideBy = 1.0
-	} else {
-		askVolumeDivideBy = *config.AskVolumeDivideBy
-	}
-	if bidVolumeDivideBy == -1.0 && askVolumeDivideBy == -1.0 {
-		utils.PrintErrorHintf("both BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY cannot be -1.0")
-		return nil, fmt.Errorf("invalid mirror strategy config file, cannot set both BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY to -1.0")
-	}
-	if bidVolumeDivideBy != -1.0 && bidVolumeDivideBy <= 0 {
-		utils.PrintErrorHintf("need to set a valid value for BID_VOLUME_DIVIDE_BY, needs to be -1.0 or > 0")
-		return nil, fmt.Errorf("invalid mirror strategy config file, BID_VOLUME_DIVIDE_BY needs to be -1.0 or > 0")
-	}
-	if askVolumeDivideBy != -1.0 && askVolumeDivideBy <= 0 {
-		utils.PrintErrorHintf("need to set a valid value for ASK_VOLUME_DIVIDE_BY, needs to be -1.0 or > 0")
-		return nil, fmt.Errorf("invalid mirror strategy config file, ASK_VOLUME_DIVIDE_BY needs to be -1.0 or > 0")
-	}
 
-	var exchange api.Exchange
 	var e error
 	var strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
+	offsetOrderType := model.OrderTypeLimit
+	savedBaseSurplus := map[string]float64{}
+	offsets := []*pendingOffset{}
 	if config.OffsetTrades {
 		if db == nil {
 			return nil, fmt.Errorf("db should not be nil when OffsetTrades is enabled")
 		}
 
-		exchangeAPIKeys := config.ExchangeAPIKeys.ToExchangeAPIKeys()
-		exchangeParams := config.ExchangeParams.ToExchangeParams()
-		exchangeHeaders := config.ExchangeHeaders.ToExchangeHeaders()
-		exchange, e = MakeTradingExchange(config.Exchange, exchangeAPIKeys, exchangeParams, exchangeHeaders, simMode)
+		offsetOrderType, e = parseOffsetOrderType(config.OffsetOrderType)
 		if e != nil {
-			return nil, e
+			utils.PrintErrorHintf("OFFSET_ORDER_TYPE needs to be one of 'limit', 'market' or 'ioc' in the mirror strategy config file")
+			return nil, fmt.Errorf("invalid mirror strategy config file: %s", e)
+		}
+		if config.OffsetMaxSlippage < 0.0 || config.OffsetMaxSlippage >= 1.0 {
+			return nil, fmt.Errorf("need to specify OFFSET_MAX_SLIPPAGE config param in the range [0.0, 1.0) in mirror strategy config file")
+		}
+		if config.OffsetRetryBaseDelaySeconds < 0 || config.OffsetRetryMaxDelaySeconds < 0 || config.OffsetRetryMaxAgeSeconds < 0 {
+			return nil, fmt.Errorf("need to specify non-negative OFFSET_RETRY_BASE_DELAY_SECONDS, OFFSET_RETRY_MAX_DELAY_SECONDS and OFFSET_RETRY_MAX_AGE_SECONDS config params in mirror strategy config file")
 		}
-
 		if config.MinBaseVolumeOverride != nil && *config.MinBaseVolumeOverride <= 0.0 {
 			return nil, fmt.Errorf("need to specify positive MIN_BASE_VOLUME_OVERRIDE config param in mirror strategy config file")
 		}
@@ -203,53 +264,254 @@ func makeMirrorStrategy(
 		if config.PricePrecisionOverride != nil && *config.PricePrecisionOverride < 0 {
 			return nil, fmt.Errorf("need to specify non-negative PRICE_PRECISION_OVERRIDE config param in mirror strategy config file")
 		}
-		if config.BackingDbOverrideAccountID == "" {
+
+		strategyMirrorTradeTriggerExistsQuery, e = queries.MakeStrategyMirrorTradeTriggerExists(db, marketID)
+		if e != nil {
+			return nil, fmt.Errorf("unable to create strategyMirrorTradeTriggerExistsQuery: %s", e)
+		}
+
+		savedBaseSurplus, e = loadBaseSurplus(db, marketID)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load the base surplus of the mirror strategy: %s", e)
+		}
+		offsets, e = loadPendingOffsets(db, marketID)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load the queued offsets of the mirror strategy: %s", e)
+		}
+	}
+	baseSurplus, e := makeBaseSurplus(savedBaseSurplus)
+	if e != nil {
+		return nil, fmt.Errorf("unable to restore the base surplus of the mirror strategy: %s", e)
+	}
+	// the volume of queued offsets stays committed so it is only offset by the retries
+	pendingOffsets := map[string]bool{}
+	for action, committed := range committedPendingOffsets(offsets) {
+		baseSurplus[action].committed = committed
+	}
+	for _, p := range offsets {
+		pendingOffsets[p.txID] = true
+	}
+	if config.OffsetTrades {
+		log.Printf("restored baseSurplus from db: buyTotal=%f, buyCommitted=%f, sellTotal=%f, sellCommitted=%f, queuedOffsets=%d\n",
+			baseSurplus[model.OrderActionBuy].total.AsFloat(),
+			baseSurplus[model.OrderActionBuy].committed.AsFloat(),
+			baseSurplus[model.OrderActionSell].total.AsFloat(),
+			baseSurplus[model.OrderActionSell].committed.AsFloat(),
+			len(offsets))
+	}
+
+	// we have two sets of (tradingPair, orderConstraints): the primaryExchange and the backing exchanges
+	primaryConstraints := sdex.GetOrderConstraints(pair)
+	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
+
+	venues := []*mirrorVenue{}
+	hasBids := false
+	hasAsks := false
+	for _, venueConfig := range config.backingExchangeConfigs() {
+		// the unfilled part of an ioc offset is found from the trades of the offset order so the trades need to have the orderID
+		if config.OffsetTrades && offsetOrderType.IsImmediateOrCancel() && !getExchanges()[venueConfig.Exchange].TradeHasOrderId {
+			utils.PrintErrorHintf("OFFSET_ORDER_TYPE cannot be 'ioc' for backing exchange '%s' because its trades do not include the orderID, use 'limit' or 'market' instead", venueConfig.Exchange)
+			return nil, fmt.Errorf("invalid mirror strategy config file, OFFSET_ORDER_TYPE cannot be 'ioc' for backing exchange '%s'", venueConfig.Exchange)
+		}
+		venue, e := makeMirrorVenue(config, venueConfig, db, simMode)
+		if e != nil {
+			return nil, e
+		}
+		venues = append(venues, venue)
+		hasBids = hasBids || venue.bidVolumeDivideBy != -1.0
+		hasAsks = hasAsks || venue.askVolumeDivideBy != -1.0
+	}
+	if !hasBids && !hasAsks {
+		utils.PrintErrorHintf("both BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY cannot be -1.0")
+		return nil, fmt.Errorf("invalid mirror strategy config file, cannot set both BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY to -1.0")
+	}
+
+	minBackingBaseVolume := minVenueBaseVolume(venues)
+	if config.MaxOrderBaseCap != nil {
+		if *config.MaxOrderBaseCap < minBackingBaseVolume.AsFloat() {
+			utils.PrintErrorHintf("MAX_ORDER_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxOrderBaseCap, minBackingBaseVolume.AsString())
+			return nil, fmt.Errorf("MAX_ORDER_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxOrderBaseCap, minBackingBaseVolume.AsString())
+		}
+		if *config.MaxOrderBaseCap <= 0.0 {
+			utils.PrintErrorHintf("invalid mirror strategy config file, if you set a value for MAX_ORDER_BASE_CAP it needs to be > 0.0, leaving it unset does not constrain the order size")
+			return nil, fmt.Errorf("invalid mirror strategy config file, if you set a value for MAX_ORDER_BASE_CAP it needs to be > 0.0, leaving it unset does not constrain the order size")
+		}
+	}
+
+	if config.OrderbookDepth > int(maxOrderbookDepth) {
+		return nil, fmt.Errorf("cannot construct the mirrorStrategy, ORDERBOOK_DEPTH config param should not exceed %d", maxOrderbookDepth)
+	}
+
+	return &mirrorStrategy{
+		sdex:                                  sdex,
+		ieif:                                  ieif,
+		baseAsset:                             baseAsset,
+		quoteAsset:                            quoteAsset,
+		primaryConstraints:                    primaryConstraints,
+		marketID:                              marketID,
+		venues:                                venues,
+		minBackingBaseVolume:                  minBackingBaseVolume,
+		strategyMirrorTradeTriggerExistsQuery: strategyMirrorTradeTriggerExistsQuery,
+		orderbookDepth:                        config.OrderbookDepth,
+		perLevelSpread:                        config.PerLevelSpread,
+		maybeMaxOrderBaseCap:                  config.MaxOrderBaseCap,
+		offsetTrades:                          config.OffsetTrades,
+		offsetOrderType:                       offsetOrderType,
+		offsetMaxSlippage:                     config.OffsetMaxSlippage,
+		offsetRetryBaseDelay:                  secondsOrDefault(config.OffsetRetryBaseDelaySeconds, defaultOffsetRetryBaseDelaySeconds),
+		offsetRetryMaxDelay:                   secondsOrDefault(config.OffsetRetryMaxDelaySeconds, defaultOffsetRetryMaxDelaySeconds),
+		offsetRetryMaxAge:                     secondsOrDefault(config.OffsetRetryMaxAgeSeconds, defaultOffsetRetryMaxAgeSeconds),
+		mutex:                                 &sync.Mutex{},
+		baseSurplus:                           baseSurplus,
+		pendingOffsets:                        pendingOffsets,
+		db:                                    db,
+		alert:                                 alert,
+	}, nil
+}
+
+// secondsOrDefault converts a config value in seconds to a duration, using the default when the value is not set
+func secondsOrDefault(seconds int, defaultSeconds int) time.Duration {
+	if seconds == 0 {
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// loadBaseSurplus reads the total base surplus of each order action that was saved for the market, keyed by the order action string
+func loadBaseSurplus(db *sql.DB, marketID string) (map[string]float64, error) {
+	rows, e := db.Query(kelpdb.SqlQueryStrategyMirrorBaseSurplus, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not query base surplus for marketID '%s': %s", marketID, e)
+	}
+	defer rows.Close()
+
+	totals := map[string]float64{}
+	for rows.Next() {
+		var action string
+		var total float64
+		e = rows.Scan(&action, &total)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan base surplus row: %s", e)
+		}
+		totals[action] = total
+	}
+	e = rows.Err()
+	if e != nil {
+		return nil, fmt.Errorf("error while iterating over base surplus rows: %s", e)
+	}
+	return totals, nil
+}
+
+// makeBaseSurplus builds the baseSurplus map from the saved totals. Nothing is committed after a restart so any surplus that was
+// being offset when the bot stopped is picked up again by the next fill.
+func makeBaseSurplus(savedTotals map[string]float64) (map[model.OrderAction]*assetSurplus, error) {
+	baseSurplus := map[model.OrderAction]*assetSurplus{
+		model.OrderActionBuy:  makeAssetSurplus(),
+		model.OrderActionSell: makeAssetSurplus(),
+	}
+	for actionString, total := range savedTotals {
+		action := model.OrderActionFromString(actionString)
+		if action.String() != actionString {
+			return nil, fmt.Errorf("invalid order action '%s' in saved base surplus", actionString)
+		}
+		baseSurplus[action].total = model.NumberFromFloat(total, model.NumberConstants.Zero.Precision())
+	}
+	return baseSurplus, nil
+}
+
+// parseVolumeDivideBy defaults unset values to 1.0 and checks that the values are -1.0 (do not mirror this side) or > 0
+func parseVolumeDivideBy(venueConfig mirrorBackingExchangeConfig) (float64 /*bidVolumeDivideBy*/, float64 /*askVolumeDivideBy*/, error) {
+	bidVolumeDivideBy := 1.0
+	if venueConfig.BidVolumeDivideBy != nil {
+		bidVolumeDivideBy = *venueConfig.BidVolumeDivideBy
+	}
+	askVolumeDivideBy := 1.0
+	if venueConfig.AskVolumeDivideBy != nil {
+		askVolumeDivideBy = *venueConfig.AskVolumeDivideBy
+	}
+
+	if bidVolumeDivideBy != -1.0 && bidVolumeDivideBy <= 0 {
+		utils.PrintErrorHintf("need to set a valid value for BID_VOLUME_DIVIDE_BY for backing exchange '%s', needs to be -1.0 or > 0", venueConfig.Exchange)
+		return 0, 0, fmt.Errorf("invalid mirror strategy config file, BID_VOLUME_DIVIDE_BY for backing exchange '%s' needs to be -1.0 or > 0", venueConfig.Exchange)
+	}
+	if askVolumeDivideBy != -1.0 && askVolumeDivideBy <= 0 {
+		utils.PrintErrorHintf("need to set a valid value for ASK_VOLUME_DIVIDE_BY for backing exchange '%s', needs to be -1.0 or > 0", venueConfig.Exchange)
+		return 0, 0, fmt.Errorf("invalid mirror strategy config file, ASK_VOLUME_DIVIDE_BY for backing exchange '%s' needs to be -1.0 or > 0", venueConfig.Exchange)
+	}
+	return bidVolumeDivideBy, askVolumeDivideBy, nil
+}
+
+// makeMirrorVenue makes the exchange, fill tracker and order constraints of a backing exchange
+func makeMirrorVenue(config *mirrorConfig, venueConfig mirrorBackingExchangeConfig, db *sql.DB, simMode bool) (*mirrorVenue, error) {
+	bidVolumeDivideBy, askVolumeDivideBy, e := parseVolumeDivideBy(venueConfig)
+	if e != nil {
+		return nil, e
+	}
+
+	var venueDynamicSpread *dynamicSpread
+	if config.DynamicSpread {
+		venueDynamicSpread, e = makeDynamicSpread(
+			config.PerLevelSpread,
+			config.DynamicSpreadVolatilityMultiplier,
+			config.DynamicSpreadLatencySpreadPerSecond,
+			config.DynamicSpreadMax,
+			config.DynamicSpreadWindow,
+		)
+		if e != nil {
+			return nil, fmt.Errorf("invalid mirror strategy config file: %s", e)
+		}
+	}
+
+	var exchange api.Exchange
+	if config.OffsetTrades {
+		if venueConfig.BackingDbOverrideAccountID == "" {
 			utils.PrintErrorHintf("BACKING_DB_OVERRIDE__ACCOUNT_ID needs to be set in the mirror strategy config file when OFFSET_TRADES is enabled so we can assign an account_id to trades that are fetched from the backing exchange before writing them in the db")
 			return nil, fmt.Errorf("invalid mirror strategy config file, need to set BACKING_DB_OVERRIDE__ACCOUNT_ID")
 		}
 
-		strategyMirrorTradeTriggerExistsQuery, e = queries.MakeStrategyMirrorTradeTriggerExists(db, marketID)
+		exchangeAPIKeys := venueConfig.ExchangeAPIKeys.ToExchangeAPIKeys()
+		exchangeParams := venueConfig.ExchangeParams.ToExchangeParams()
+		exchangeHeaders := venueConfig.ExchangeHeaders.ToExchangeHeaders()
+		exchange, e = MakeTradingExchange(venueConfig.Exchange, exchangeAPIKeys, exchangeParams, exchangeHeaders, simMode)
 		if e != nil {
-			return nil, fmt.Errorf("unable to create strategyMirrorTradeTriggerExistsQuery: %s", e)
+			return nil, e
 		}
 	} else {
-		exchange, e = MakeExchange(config.Exchange, simMode)
+		exchange, e = MakeExchange(venueConfig.Exchange, simMode)
 		if e != nil {
 			return nil, e
 		}
 	}
 
-	// we have two sets of (tradingPair, orderConstraints): the primaryExchange and the backingExchange
-	primaryConstraints := sdex.GetOrderConstraints(pair)
 	// backingPair is taken from the mirror strategy config not from the passed in trading pair
 	backingPair := &model.TradingPair{
-		Base:  exchange.GetAssetConverter().MustFromString(config.ExchangeBase),
-		Quote: exchange.GetAssetConverter().MustFromString(config.ExchangeQuote),
+		Base:  exchange.GetAssetConverter().MustFromString(venueConfig.ExchangeBase),
+		Quote: exchange.GetAssetConverter().MustFromString(venueConfig.ExchangeQuote),
 	}
 
 	// make fill tracker for backing exchange
 	var backingFillTracker api.FillTracker
 	if config.OffsetTrades {
 		var backingLastCursor interface{}
-		if config.BackingFillTrackerLastTradeCursorOverride == "" {
+		if venueConfig.BackingFillTrackerLastTradeCursorOverride == "" {
 			// loads cursor by fetching from exchange
 			backingLastCursor, e = exchange.GetLatestTradeCursor()
 			if e != nil {
-				return nil, fmt.Errorf("could not get last trade cursor from backing exchange in mirrorStrategy: %s", e)
+				return nil, fmt.Errorf("could not get last trade cursor from backing exchange '%s' in mirrorStrategy: %s", venueConfig.Exchange, e)
 			}
-			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in mirror strategy (no override specified): %v\n", backingLastCursor)
+			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange '%s' in mirror strategy (no override specified): %v\n", venueConfig.Exchange, backingLastCursor)
 		} else {
 			// loads cursor from config file
-			backingLastCursor = config.BackingFillTrackerLastTradeCursorOverride
-			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in mirror strategy (used override value): %v\n", backingLastCursor)
+			backingLastCursor = venueConfig.BackingFillTrackerLastTradeCursorOverride
+			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange '%s' in mirror strategy (used override value): %v\n", venueConfig.Exchange, backingLastCursor)
 		}
 		backingFillTracker = MakeFillTracker(backingPair, multithreading.MakeThreadTracker(), exchange, 0, 0, backingLastCursor)
 		backingFillTracker.RegisterHandler(MakeFillLogger())
 		backingAssetDisplayFn := model.MakePassthroughAssetDisplayFn()
-		if config.Exchange == "sdex" {
+		if venueConfig.Exchange == "sdex" {
 			return nil, fmt.Errorf("we cannot mirror trades from SDEX for now (programmer: need to create sdexAssetMap to inject into the backingAssetDisplayFn)")
 		}
-		fillDBWriter := MakeFillDBWriter(db, backingAssetDisplayFn, config.Exchange, config.BackingDbOverrideAccountID)
+		fillDBWriter := MakeFillDBWriter(db, backingAssetDisplayFn, venueConfig.Exchange, venueConfig.BackingDbOverrideAccountID)
 		backingFillTracker.RegisterHandler(fillDBWriter)
 	}
 
@@ -280,70 +542,72 @@ func makeMirrorStrategy(
 		))
 	}
 	backingConstraints := exchange.GetOrderConstraints(backingPair)
-	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
-	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
-	if config.MaxOrderBaseCap != nil {
-		if *config.MaxOrderBaseCap < backingConstraints.MinBaseVolume.AsFloat() {
-			utils.PrintErrorHintf("MAX_ORDER_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxOrderBaseCap, backingConstraints.MinBaseVolume.AsString())
-			return nil, fmt.Errorf("MAX_ORDER_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxOrderBaseCap, backingConstraints.MinBaseVolume.AsString())
-		}
-		if *config.MaxOrderBaseCap <= 0.0 {
-			utils.PrintErrorHintf("invalid mirror strategy config file, if you set a value for MAX_ORDER_BASE_CAP it needs to be > 0.0, leaving it unset does not constrain the order size")
-			return nil, fmt.Errorf("invalid mirror strategy config file, if you set a value for MAX_ORDER_BASE_CAP it needs to be > 0.0, leaving it unset does not constrain the order size")
-		}
-	}
+	log.Printf("backingExchange='%s', backingPair='%s', backingConstraints=%s\n", venueConfig.Exchange, backingPair, backingConstraints)
 
 	// insert into database if needed
 	var backingMarketID string
 	if db != nil {
-		backingMarketID, e = FetchOrRegisterMarketID(db, config.Exchange, config.ExchangeBase, config.ExchangeQuote)
+		backingMarketID, e = FetchOrRegisterMarketID(db, venueConfig.Exchange, venueConfig.ExchangeBase, venueConfig.ExchangeQuote)
 		if e != nil {
 			return nil, fmt.Errorf("error calling FetchOrRegisterMarketID: %s", e)
 		}
 	}
+	if backingFillTracker != nil {
+		// registered after the fillDBWriter because it reads the fills of the backing order from the trades table
+		backingFillTracker.RegisterHandler(makeMirrorHedgeWriter(db, backingMarketID))
+	}
 
 	// trigger fill tracking on backing exchange at creation time
 	if backingFillTracker != nil {
 		trades, e := backingFillTracker.FillTrackSingleIteration()
 		if e != nil {
-			return nil, fmt.Errorf("unable to track a single iteration of fills from the backing exchange in factory method: %s", e)
+			return nil, fmt.Errorf("unable to track a single iteration of fills from the backing exchange '%s' in factory method: %s", venueConfig.Exchange, e)
 		}
 
-		log.Printf("found %d trades on first load from backing exchange\n", len(trades))
+		log.Printf("found %d trades on first load from backing exchange '%s'\n", len(trades), venueConfig.Exchange)
 	} else {
-		log.Printf("backingFillTracker was nil so not loading trades at creation time\n")
+		log.Printf("backingFillTracker was nil so not loading trades at creation time for backing exchange '%s'\n", venueConfig.Exchange)
+	}
+
+	return &mirrorVenue{
+		name:              venueConfig.Exchange,
+		exchange:          exchange,
+		pair:              backingPair,
+		constraints:       backingConstraints,
+		marketID:          backingMarketID,
+		fillTracker:       backingFillTracker,
+		bidVolumeDivideBy: bidVolumeDivideBy,
+		askVolumeDivideBy: askVolumeDivideBy,
+		dynamicSpread:     venueDynamicSpread,
+		cachedTopOfBook:   map[model.OrderAction]float64{},
+	}, nil
+}
+
+// minVenueBaseVolume is the smallest minBaseVolume of the venues, levels below it cannot be offset on any venue
+func minVenueBaseVolume(venues []*mirrorVenue) *model.Number {
+	minBaseVolume := &venues[0].constraints.MinBaseVolume
+	for _, v := range venues[1:] {
+		if v.constraints.MinBaseVolume.AsFloat() < minBaseVolume.AsFloat() {
+			minBaseVolume = &v.constraints.MinBaseVolume
+		}
 	}
+	return minBaseVolume
+}
 
-	if config.OrderbookDepth > int(maxOrderbookDepth) {
-		return nil, fmt.Errorf("cannot construct the mirrorStrategy, ORDERBOOK_DEPTH config param should not exceed %d", maxOrderbookDepth)
+// parseOffsetOrderType defaults to limit orders, stop-limit orders are not allowed since offsets should be placed right away
+func parseOffsetOrderType(s string) (model.OrderType, error) {
+	if s == "" {
+		return model.OrderTypeLimit, nil
 	}
 
-	return &mirrorStrategy{
-		sdex:                                  sdex,
-		ieif:                                  ieif,
-		baseAsset:                             baseAsset,
-		quoteAsset:                            quoteAsset,
-		primaryConstraints:                    primaryConstraints,
-		marketID:                              marketID,
-		backingPair:                           backingPair,
-		backingConstraints:                    backingConstraints,
-		backingMarketID:                       backingMarketID,
-		backingFillTracker:                    backingFillTracker,
-		strategyMirrorTradeTriggerExistsQuery: strategyMirrorTradeTriggerExistsQuery,
-		orderbookDepth:                        config.OrderbookDepth,
-		perLevelSpread:                        config.PerLevelSpread,
-		bidVolumeDivideBy:                     bidVolumeDivideBy,
-		askVolumeDivideBy:                     askVolumeDivideBy,
-		maybeMaxOrderBaseCap:                  config.MaxOrderBaseCap,
-		exchange:                              exchange,
-		offsetTrades:                          config.OffsetTrades,
-		mutex:                                 &sync.Mutex{},
-		baseSurplus: map[model.OrderAction]*assetSurplus{
-			model.OrderActionBuy:  makeAssetSurplus(),
-			model.OrderActionSell: makeAssetSurplus(),
-		},
-		db: db,
-	}, nil
+	orderType, e := model.ParseOrderType(s)
+	if e != nil {
+		return model.OrderTypeLimit, e
+	}
+	if orderType.IsStopLimit() {
+		return model.OrderTypeLimit, fmt.Errorf("order type '%s' cannot be used to offset trades", s)
+	}
+	return orderType, nil
 }
 
 // PruneExistingOffers deletes any extra offers
@@ -358,6 +622,15 @@ func (s *mirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA
 		return nil
 	}
 
+	s.mutex.Lock()
+	for _, v := range s.venues {
+		v.resetCache()
+	}
+	s.mutex.Unlock()
+
+	// retry queued offsets before fetching balances so the balances include any offsets placed here
+	s.retryPendingOffsets(time.Now().UTC())
+
 	baseBackingBalance, quoteBackingBalance, e := s.getBackingBalances()
 	if e != nil {
 		return fmt.Errorf("error while fetching backing balances: %s", e)
@@ -390,21 +663,53 @@ func (s *mirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA
 	return nil
 }
 
+// getBackingBalances returns the largest balances of any single venue, since each trade is offset with a single order on one venue
+// we cannot place more than this without risking an offset that no venue has the balance for. The balances are cached for routeOffset.
 func (s *mirrorStrategy) getBackingBalances() (*model.Number /*baseBackingBalance*/, *model.Number /*quoteBackingBalance*/, error) {
-	balanceMap, e := s.exchange.GetAccountBalances([]interface{}{s.backingPair.Base, s.backingPair.Quote})
+	baseBalance := model.NumberConstants.Zero
+	quoteBalance := model.NumberConstants.Zero
+	for _, v := range s.venues {
+		venueBaseBalance, venueQuoteBalance, e := v.getBalances()
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to fetch balances from backing exchange '%s': %s", v.name, e)
+		}
+		s.mutex.Lock()
+		v.cachedBaseBalance = venueBaseBalance
+		v.cachedQuoteBalance = venueQuoteBalance
+		s.mutex.Unlock()
+
+		if venueBaseBalance.AsFloat() > baseBalance.AsFloat() {
+			baseBalance = venueBaseBalance
+		}
+		if venueQuoteBalance.AsFloat() > quoteBalance.AsFloat() {
+			quoteBalance = venueQuoteBalance
+		}
+	}
+	return baseBalance, quoteBalance, nil
+}
+
+// resetCache drops the balances and top of book fetched in the previous update cycle
+func (v *mirrorVenue) resetCache() {
+	v.cachedBaseBalance = nil
+	v.cachedQuoteBalance = nil
+	v.cachedTopOfBook = map[model.OrderAction]float64{}
+}
+
+func (v *mirrorVenue) getBalances() (*model.Number /*baseBalance*/, *model.Number /*quoteBalance*/, error) {
+	balanceMap, e := v.exchange.GetAccountBalances([]interface{}{v.pair.Base, v.pair.Quote})
 	if e != nil {
 		return nil, nil, fmt.Errorf("unable to fetch balances for assets: %s", e)
 	}
 
 	// save asset balances from backing exchange to be used when placing offers in offset mode
-	baseBalance, ok := balanceMap[s.backingPair.Base]
+	baseBalance, ok := balanceMap[v.pair.Base]
 	if !ok {
-		return nil, nil, fmt.Errorf("unable to fetch balance for base asset: %s", string(s.backingPair.Base))
+		return nil, nil, fmt.Errorf("unable to fetch balance for base asset: %s", string(v.pair.Base))
 	}
 
-	quoteBalance, ok := balanceMap[s.backingPair.Quote]
+	quoteBalance, ok := balanceMap[v.pair.Quote]
 	if !ok {
-		return nil, nil, fmt.Errorf("unable to fetch balance for quote asset: %s", string(s.backingPair.Quote))
+		return nil, nil, fmt.Errorf("unable to fetch balance for quote asset: %s", string(v.pair.Quote))
 	}
 
 	return &baseBalance, &quoteBalance, nil
@@ -415,40 +720,10 @@ func (s *mirrorStrategy) UpdateWithOps(
 	buyingAOffers []hProtocol.Offer,
 	sellingAOffers []hProtocol.Offer,
 ) ([]build.TransactionMutator, error) {
-	// we want to fetch a few extra orders to account for potentially filtering out orders that don't meet the min base volume requirements
-	ordersToFetch := int32(s.orderbookDepth + numOrdersBufferMinVolumeFilter)
-	ob, e := s.exchange.GetOrderBook(s.backingPair, ordersToFetch)
+	bids, asks, e := s.consolidatedOrderbook()
 	if e != nil {
 		return nil, e
 	}
-
-	// limit bids and asks to max 50 operations each because of Stellar's limit of 100 ops/tx
-	bids := ob.Bids()
-	asks := ob.Asks()
-	log.Printf("backing orderbook before transformations, including %d additional buffer orders:\n", numOrdersBufferMinVolumeFilter)
-	printBidsAndAsks(bids, asks)
-
-	// we modify the bids and ask to represent the new orders to place so we reduce unnecessary memory allocations
-	if s.bidVolumeDivideBy == -1.0 {
-		bids = []model.Order{}
-	} else {
-		transformOrders(bids, (1 - s.perLevelSpread), (1.0 / s.bidVolumeDivideBy), s.maybeMaxOrderBaseCap)
-		// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
-		bids = filterOrdersByVolume(bids, s.backingConstraints.MinBaseVolume.AsFloat())
-		if len(bids) > s.orderbookDepth {
-			bids = bids[:s.orderbookDepth]
-		}
-	}
-	if s.askVolumeDivideBy == -1.0 {
-		asks = []model.Order{}
-	} else {
-		transformOrders(asks, (1 + s.perLevelSpread), (1.0 / s.askVolumeDivideBy), s.maybeMaxOrderBaseCap)
-		// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
-		asks = filterOrdersByVolume(asks, s.backingConstraints.MinBaseVolume.AsFloat())
-		if len(asks) > s.orderbookDepth {
-			asks = asks[:s.orderbookDepth]
-		}
-	}
 	log.Printf("new orders to be placed (after transforming and filtering orders from backing exchange):\n")
 	printBidsAndAsks(bids, asks)
 
@@ -510,6 +785,89 @@ func (s *mirrorStrategy) UpdateWithOps(
 	return api.ConvertOperation2TM(ops), nil
 }
 
+// consolidatedOrderbook transforms the orderbook of each venue with the venue's volume divisors and merges them into
+// the bids and asks to place, best prices first. Orders are filtered by the minBaseVolume of the venue they came from.
+func (s *mirrorStrategy) consolidatedOrderbook() ([]model.Order /*bids*/, []model.Order /*asks*/, error) {
+	// we want to fetch a few extra orders to account for potentially filtering out orders that don't meet the min base volume requirements
+	ordersToFetch := int32(s.orderbookDepth + numOrdersBufferMinVolumeFilter)
+	bids := []model.Order{}
+	asks := []model.Order{}
+	for _, v := range s.venues {
+		fetchStart := time.Now()
+		ob, e := v.exchange.GetOrderBook(v.pair, ordersToFetch)
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to fetch orderbook from backing exchange '%s': %s", v.name, e)
+		}
+		latency := orderBookFetchLatency(v.exchange, fetchStart)
+
+		venueBids := ob.Bids()
+		venueAsks := ob.Asks()
+		// cache the top of book before the orders are transformed, a buy offset takes the best ask and a sell offset takes the best bid
+		s.mutex.Lock()
+		if len(venueAsks) > 0 {
+			v.cachedTopOfBook[model.OrderActionBuy] = venueAsks[0].Price.AsFloat()
+		}
+		if len(venueBids) > 0 {
+			v.cachedTopOfBook[model.OrderActionSell] = venueBids[0].Price.AsFloat()
+		}
+		s.mutex.Unlock()
+		log.Printf("backing orderbook of '%s' before transformations, including %d additional buffer orders:\n", v.name, numOrdersBufferMinVolumeFilter)
+		printBidsAndAsks(venueBids, venueAsks)
+
+		spread := s.perLevelSpread
+		if v.dynamicSpread != nil {
+			v.dynamicSpread.addSample(midPrice(venueBids, venueAsks), latency)
+			spread = v.dynamicSpread.spread()
+			log.Printf("dynamic spread for backing exchange '%s': spread=%f, perLevelSpread=%f, volatility=%f, averageLatency=%s, numSamples=%d\n",
+				v.name, spread, s.perLevelSpread, v.dynamicSpread.volatility(), v.dynamicSpread.averageLatency(), len(v.dynamicSpread.samples))
+		}
+
+		// we modify the bids and ask to represent the new orders to place so we reduce unnecessary memory allocations
+		if v.bidVolumeDivideBy != -1.0 {
+			transformOrders(venueBids, (1 - spread), (1.0 / v.bidVolumeDivideBy), s.maybeMaxOrderBaseCap)
+			// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
+			bids = append(bids, filterOrdersByVolume(venueBids, v.constraints.MinBaseVolume.AsFloat())...)
+		}
+		if v.askVolumeDivideBy != -1.0 {
+			transformOrders(venueAsks, (1 + spread), (1.0 / v.askVolumeDivideBy), s.maybeMaxOrderBaseCap)
+			// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
+			asks = append(asks, filterOrdersByVolume(venueAsks, v.constraints.MinBaseVolume.AsFloat())...)
+		}
+	}
+
+	if len(s.venues) > 1 {
+		sortOrdersByPrice(bids, true)
+		sortOrdersByPrice(asks, false)
+	}
+
+	// limit bids and asks to max 50 operations each because of Stellar's limit of 100 ops/tx
+	if len(bids) > s.orderbookDepth {
+		bids = bids[:s.orderbookDepth]
+	}
+	if len(asks) > s.orderbookDepth {
+		asks = asks[:s.orderbookDepth]
+	}
+	return bids, asks, nil
+}
+
+// sortOrdersByPrice sorts bids in descending and asks in ascending order of price, keeping the order of levels with the same price
+func sortOrdersByPrice(orders []model.Order, isBids bool) {
+	sort.SliceStable(orders, func(i int, j int) bool {
+		if isBids {
+			return orders[i].Price.AsFloat() > orders[j].Price.AsFloat()
+		}
+		return orders[i].Price.AsFloat() < orders[j].Price.AsFloat()
+	})
+}
+
+// midPrice is the average of the best bid and the best ask, 0 if either side is empty
+func midPrice(bids []model.Order, asks []model.Order) float64 {
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0
+	}
+	return (bids[0].Price.AsFloat() + asks[0].Price.AsFloat()) / 2
+}
+
 func transformOrders(orders []model.Order, priceMultiplier float64, volumeMultiplier float64, maxVolumeCap *float64) {
 	for _, o := range orders {
 		*o.Price = *o.Price.Scale(priceMultiplier)
@@ -636,8 +994,8 @@ func (s *mirrorStrategy) updateLevels(
 			}
 
 			incrementalNativeAmountRaw := s.sdex.ComputeIncrementalNativeAmountRaw(true)
-			if vol.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
-				log.Printf("skip level creation, baseVolume (%s) < minBaseVolume (%s) of backing exchange\n", vol.AsString(), s.backingConstraints.MinBaseVolume.AsString())
+			if vol.AsFloat() < s.minBackingBaseVolume.AsFloat() {
+				log.Printf("skip level creation, baseVolume (%s) < minBaseVolume (%s) of backing exchange\n", vol.AsString(), s.minBackingBaseVolume.AsString())
 				continue
 			}
 
@@ -721,9 +1079,9 @@ func (s *mirrorStrategy) doModifyOffer(
 	// convert the precision from the backing exchange to the primary exchange
 	offerPrice := model.NumberByCappingPrecision(price, s.primaryConstraints.PricePrecision)
 	offerAmount := model.NumberByCappingPrecision(vol, s.primaryConstraints.VolumePrecision)
-	if s.offsetTrades && offerAmount.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
+	if s.offsetTrades && offerAmount.AsFloat() < s.minBackingBaseVolume.AsFloat() {
 		log.Printf("deleting level, baseVolume (%f) on backing exchange dropped below minBaseVolume of backing exchange (%f)\n",
-			offerAmount.AsFloat(), s.backingConstraints.MinBaseVolume.AsFloat())
+			offerAmount.AsFloat(), s.minBackingBaseVolume.AsFloat())
 		deleteOp := s.sdex.DeleteOffer(oldOffer)
 		return nil, &deleteOp, nil
 	}
@@ -764,29 +1122,154 @@ func (s *mirrorStrategy) GetFillHandlers() ([]api.FillHandler, error) {
 	return nil, nil
 }
 
-func (s *mirrorStrategy) baseVolumeToOffset(trade model.Trade, newOrderAction model.OrderAction) (newVolume *model.Number, ok bool) {
+// offsetPrice moves the price of the trade by the max slippage in the direction that makes the offset order more likely to fill.
+// For market orders this is only the reference price used for logging.
+func (s *mirrorStrategy) offsetPrice(tradePrice *model.Number, newOrderAction model.OrderAction, constraints *model.OrderConstraints) *model.Number {
+	price := tradePrice
+	if s.offsetMaxSlippage > 0.0 && !s.offsetOrderType.IsMarket() {
+		multiplier := 1.0 - s.offsetMaxSlippage
+		if newOrderAction.IsBuy() {
+			multiplier = 1.0 + s.offsetMaxSlippage
+		}
+		price = tradePrice.Scale(multiplier)
+	}
+	return model.NumberByCappingPrecision(price, constraints.PricePrecision)
+}
+
+func (s *mirrorStrategy) baseVolumeToOffset(trade model.Trade, newOrderAction model.OrderAction, constraints *model.OrderConstraints) (newVolume *model.Number, ok bool) {
 	uncommittedBase := s.baseSurplus[newOrderAction].total.Subtract(*s.baseSurplus[newOrderAction].committed)
 
-	if uncommittedBase.AsFloat() < s.backingConstraints.MinBaseVolume.Scale(0.5).AsFloat() {
+	if uncommittedBase.AsFloat() < constraints.MinBaseVolume.Scale(0.5).AsFloat() {
 		log.Printf("offset-skip | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | minBaseVolume=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f\n",
 			trade.TransactionID.String(),
 			trade.Volume.AsFloat(),
 			trade.Volume.Multiply(*trade.Price).AsFloat(),
 			trade.Price.AsFloat(),
-			s.backingConstraints.MinBaseVolume.AsFloat(),
+			constraints.MinBaseVolume.AsFloat(),
 			newOrderAction.String(),
 			s.baseSurplus[newOrderAction].total.AsFloat(),
 			s.baseSurplus[newOrderAction].committed.AsFloat())
 		return nil, false
 	}
 
-	if uncommittedBase.AsFloat() > s.backingConstraints.MinBaseVolume.AsFloat() {
+	if uncommittedBase.AsFloat() > constraints.MinBaseVolume.AsFloat() {
 		newVolume = uncommittedBase
 	} else {
 		// we want to offset the MinBaseVolume and take a deficit in the baseSurplus on success
-		newVolume = &s.backingConstraints.MinBaseVolume
+		newVolume = &constraints.MinBaseVolume
+	}
+	return model.NumberByCappingPrecision(newVolume, constraints.VolumePrecision), true
+}
+
+// venueQuote is the best price a venue offers for an offset order and whether it has the balance to place it
+type venueQuote struct {
+	venue      *mirrorVenue
+	price      float64
+	hasBalance bool
+}
+
+// routeOffset picks the venue to offset a trade on, see chooseOffsetVenue. With a single venue there is nothing to fetch, otherwise
+// the balances and top of book of the current update cycle are used and only fetched for venues where they are not cached.
+func (s *mirrorStrategy) routeOffset(newOrderAction model.OrderAction, baseVolume *model.Number) *mirrorVenue {
+	if len(s.venues) == 1 {
+		return s.venues[0]
+	}
+
+	quotes := []venueQuote{}
+	for _, v := range s.venues {
+		price, e := v.topOfBookPrice(newOrderAction)
+		if e != nil {
+			log.Printf("offset-route | skipping backing exchange '%s' because we could not fetch its top of book: %s\n", v.name, e)
+			continue
+		}
+
+		hasBalance, e := v.hasBalanceToOffset(newOrderAction, baseVolume, price)
+		if e != nil {
+			log.Printf("offset-route | could not check balance on backing exchange '%s', assuming it does not have enough: %s\n", v.name, e)
+		}
+		log.Printf("offset-route | backingExchange=%s | newOrderAction=%s | price=%f | baseVolume=%f | hasBalance=%v\n", v.name, newOrderAction.String(), price, baseVolume.AsFloat(), hasBalance)
+		quotes = append(quotes, venueQuote{venue: v, price: price, hasBalance: hasBalance})
+	}
+
+	venue := chooseOffsetVenue(quotes, newOrderAction)
+	if venue == nil {
+		log.Printf("offset-route | could not fetch the top of book from any backing exchange, using '%s'\n", s.venues[0].name)
+		return s.venues[0]
+	}
+	return venue
+}
+
+// chooseOffsetVenue returns the venue with the best price among the ones that have the balance to offset,
+// or the venue with the best price if none of them have enough balance. Returns nil if there are no quotes.
+func chooseOffsetVenue(quotes []venueQuote, newOrderAction model.OrderAction) *mirrorVenue {
+	isBetter := func(a venueQuote, b *venueQuote) bool {
+		if b == nil {
+			return true
+		}
+		// we want to buy as low and sell as high as possible
+		if newOrderAction.IsBuy() {
+			return a.price < b.price
+		}
+		return a.price > b.price
+	}
+
+	var best, bestWithBalance *venueQuote
+	for i := range quotes {
+		q := quotes[i]
+		if isBetter(q, best) {
+			best = &q
+		}
+		if q.hasBalance && isBetter(q, bestWithBalance) {
+			bestWithBalance = &q
+		}
+	}
+
+	if bestWithBalance != nil {
+		return bestWithBalance.venue
+	}
+	if best != nil {
+		return best.venue
+	}
+	return nil
+}
+
+// topOfBookPrice is the price an offset order would get on this venue: the lowest ask for a buy and the highest bid for a sell
+func (v *mirrorVenue) topOfBookPrice(newOrderAction model.OrderAction) (float64, error) {
+	if price, ok := v.cachedTopOfBook[newOrderAction]; ok {
+		return price, nil
+	}
+
+	ob, e := v.exchange.GetOrderBook(v.pair, 1)
+	if e != nil {
+		return 0, fmt.Errorf("unable to fetch orderbook: %s", e)
 	}
-	return model.NumberByCappingPrecision(newVolume, s.backingConstraints.VolumePrecision), true
+
+	orders := ob.Bids()
+	if newOrderAction.IsBuy() {
+		orders = ob.Asks()
+	}
+	if len(orders) == 0 {
+		return 0, fmt.Errorf("orderbook has no orders on the side needed to %s", newOrderAction.String())
+	}
+	return orders[0].Price.AsFloat(), nil
+}
+
+// hasBalanceToOffset checks for the quote balance to buy or the base balance to sell baseVolume at the price
+func (v *mirrorVenue) hasBalanceToOffset(newOrderAction model.OrderAction, baseVolume *model.Number, price float64) (bool, error) {
+	baseBalance, quoteBalance := v.cachedBaseBalance, v.cachedQuoteBalance
+	if baseBalance == nil || quoteBalance == nil {
+		var e error
+		baseBalance, quoteBalance, e = v.getBalances()
+		if e != nil {
+			return false, e
+		}
+		v.cachedBaseBalance, v.cachedQuoteBalance = baseBalance, quoteBalance
+	}
+
+	if newOrderAction.IsBuy() {
+		return quoteBalance.AsFloat() >= baseVolume.AsFloat()*price, nil
+	}
+	return baseBalance.AsFloat() >= baseVolume.AsFloat(), nil
 }
 
 // HandleFill impl
@@ -808,12 +1291,19 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		log.Printf("trade with txid '%s' was previously handled because we have a row in the strategy_mirror_trade_triggers table with this txid, not handling again and returning\n", trade.TransactionID.String())
 		return nil
 	}
+	if s.pendingOffsets[trade.TransactionID.String()] {
+		log.Printf("trade with txid '%s' was previously handled because its offset is queued for a retry, not handling again and returning\n", trade.TransactionID.String())
+		return nil
+	}
 
 	newOrderAction := trade.OrderAction.Reverse()
 	// increase the baseSurplus for the additional amount that needs to be offset because of the incoming trade
 	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Add(*trade.Volume)
+	s.saveBaseSurplus(newOrderAction)
 
-	newVolume, ok := s.baseVolumeToOffset(trade, newOrderAction)
+	uncommittedBase := s.baseSurplus[newOrderAction].total.Subtract(*s.baseSurplus[newOrderAction].committed)
+	venue := s.routeOffset(newOrderAction, uncommittedBase)
+	newVolume, ok := s.baseVolumeToOffset(trade, newOrderAction, venue.constraints)
 	if !ok {
 		return nil
 	}
@@ -821,87 +1311,251 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Add(*newVolume)
 
 	newOrder := model.Order{
-		Pair:        s.backingPair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
-		OrderAction: newOrderAction,
-		OrderType:   model.OrderTypeLimit,
-		Price:       model.NumberByCappingPrecision(trade.Price, s.backingConstraints.PricePrecision),
-		Volume:      newVolume,
-		Timestamp:   nil,
-	}
-	log.Printf("offset-attempt | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f\n",
+		Pair:          venue.pair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
+		OrderAction:   newOrderAction,
+		OrderType:     s.offsetOrderType,
+		Price:         s.offsetPrice(trade.Price, newOrderAction, venue.constraints),
+		Volume:        newVolume,
+		Timestamp:     nil,
+		ClientOrderID: venue.clientOrderID(trade.TransactionID.String()),
+	}
+	log.Printf("offset-attempt | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | backingExchange=%s | newOrderAction=%s | newOrderType=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f\n",
 		trade.TransactionID.String(),
 		trade.Volume.AsFloat(),
 		trade.Volume.Multiply(*trade.Price).AsFloat(),
 		trade.Price.AsFloat(),
+		venue.name,
 		newOrderAction.String(),
+		newOrder.OrderType.String(),
 		s.baseSurplus[newOrderAction].total.AsFloat(),
 		s.baseSurplus[newOrderAction].committed.AsFloat(),
-		s.backingConstraints.MinBaseVolume.AsFloat(),
+		venue.constraints.MinBaseVolume.AsFloat(),
 		newOrder.Volume.AsFloat(),
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat())
 
 	// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
-	transactionID, e := s.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
-	if e != nil {
-		return fmt.Errorf("error when offsetting trade (newOrder=%s): %s", newOrder, e)
+	tradeCursor := venue.tradeCursorForFill(newOrder.OrderType)
+	transactionID, e := venue.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
+	if e == nil && transactionID == nil {
+		e = fmt.Errorf("transactionID was <nil>")
 	}
-	if transactionID == nil {
-		return fmt.Errorf("error when offsetting trade (newOrder=%s): transactionID was <nil>", newOrder)
+	if e != nil {
+		offsetErr := fmt.Errorf("error when offsetting trade on backing exchange '%s' (newOrder=%s): %s", venue.name, newOrder, e)
+		// newVolume stays committed while the offset is queued so the next fill does not offset it again
+		qe := s.enqueueOffset(trade.TransactionID.String(), newOrderAction, newVolume, trade.Price, offsetStatusAfterError(e), offsetErr)
+		if qe != nil {
+			return fmt.Errorf("%s, and unable to queue the offset for a retry: %s", offsetErr, qe)
+		}
+		return nil
 	}
-	// insert into the db immediately after placing order on backing exchange
-	e = s.insertTradeTrigger(trade.TransactionID.String(), transactionID.String())
+	// write to the db immediately after placing order on backing exchange
+	newTotal := s.baseSurplus[newOrderAction].total.Subtract(*newVolume)
+	e = s.recordOffset(trade.TransactionID.String(), venue.marketID, transactionID.String(), newOrderAction, newTotal, "")
 	if e != nil {
-		return fmt.Errorf("error when inserting trade trigger with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), newOrder, e)
+		return fmt.Errorf("error when recording the offset with txID=%s (newOrder=%s): %s", transactionID.String(), newOrder, e)
 	}
 
+	// the offset changed the balances of the venue so they are fetched again the next time they are needed
+	venue.cachedBaseBalance, venue.cachedQuoteBalance = nil, nil
+
 	// update the baseSurplus on success
-	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Subtract(*newVolume)
+	s.baseSurplus[newOrderAction].total = newTotal
 	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Subtract(*newVolume)
 
-	log.Printf("offset-success | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
+	log.Printf("offset-success | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | backingExchange=%s | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
 		trade.TransactionID.String(),
 		trade.Volume.AsFloat(),
 		trade.Volume.Multiply(*trade.Price).AsFloat(),
 		trade.Price.AsFloat(),
+		venue.name,
 		newOrderAction.String(),
 		s.baseSurplus[newOrderAction].total.AsFloat(),
 		s.baseSurplus[newOrderAction].committed.AsFloat(),
-		s.backingConstraints.MinBaseVolume.AsFloat(),
+		venue.constraints.MinBaseVolume.AsFloat(),
 		newOrder.Volume.AsFloat(),
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat(),
 		transactionID)
 
+	if tradeCursor != nil {
+		e = s.requeueUnfilledOffset(trade.TransactionID.String(), venue, &newOrder, transactionID.String(), tradeCursor, trade.Price)
+		if e != nil {
+			return fmt.Errorf("error when checking the fill of the ioc offset order (txID=%s): %s", transactionID.String(), e)
+		}
+	}
+
 	// trigger fill tracking on backing exchange
-	trades, e := s.backingFillTracker.FillTrackSingleIteration()
+	trades, e := venue.fillTracker.FillTrackSingleIteration()
 	if e != nil {
-		return fmt.Errorf("unable to track a single iteration of fills from the backing exchange: %s", e)
+		return fmt.Errorf("unable to track a single iteration of fills from the backing exchange '%s': %s", venue.name, e)
 	}
-	log.Printf("found %d trades on load from backing exchange in HandleFill\n", len(trades))
+	log.Printf("found %d trades on load from backing exchange '%s' in HandleFill\n", len(trades), venue.name)
 
 	return nil
 }
 
-func (s *mirrorStrategy) insertTradeTrigger(primaryTxID string, backingTxID string) error {
-	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyMirrorTradeTriggersInsertTemplate,
-		s.marketID,
-		primaryTxID,
-		s.backingMarketID,
-		backingTxID,
-	)
-	_, e := s.db.Exec(sqlInsert)
+// clientOrderID is the clientOrderID of the offsets of the trade with the txID on this venue, empty if the venue cannot look orders up by it
+func (v *mirrorVenue) clientOrderID(txID string) string {
+	if _, ok := v.exchange.(api.ClientOrderIDTradeAPI); !ok {
+		return ""
+	}
+
+	maxDigits := 0
+	if longIDAPI, ok := v.exchange.(api.LongClientOrderIDAPI); ok {
+		maxDigits = longIDAPI.MaxClientOrderIDDigits()
+	}
+	return offsetClientOrderID(txID, maxDigits)
+}
+
+// findOrderByClientID looks up the order with the clientOrderID in the open orders of the venue, and then in its closed orders if the
+// venue can look those up, closed is true if the order was found in the closed orders. Returns nil if the order was not found.
+func (v *mirrorVenue) findOrderByClientID(clientOrderID string) (order *model.OpenOrder, closed bool, e error) {
+	lookupAPI, ok := v.exchange.(api.ClientOrderIDTradeAPI)
+	if !ok {
+		return nil, false, nil
+	}
+
+	order, e = lookupAPI.GetOpenOrderByClientID(v.pair, clientOrderID)
 	if e != nil {
-		if strings.Contains(e.Error(), "duplicate key value violates unique constraint \"strategy_mirror_trade_triggers_pkey\"") {
-			log.Printf("trying to reinsert trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db, ignore and continue\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID)
-			return nil
+		return nil, false, fmt.Errorf("could not look up open order: %s", e)
+	}
+	if order != nil {
+		return order, false, nil
+	}
+
+	closedLookupAPI, ok := v.exchange.(api.ClosedOrderByClientIDAPI)
+	if !ok {
+		return nil, false, nil
+	}
+	order, e = closedLookupAPI.GetClosedOrderByClientID(v.pair, clientOrderID)
+	if e != nil {
+		return nil, false, fmt.Errorf("could not look up closed order: %s", e)
+	}
+	return order, order != nil, nil
+}
+
+// tradeCursorForFill returns the latest trade cursor of the venue for ioc orders so the fill of the order can be looked up once it is placed,
+// returns nil for other order types or if the cursor cannot be fetched, in which case the order is assumed to be fully filled
+func (v *mirrorVenue) tradeCursorForFill(orderType model.OrderType) interface{} {
+	if !orderType.IsImmediateOrCancel() {
+		return nil
+	}
+
+	cursor, e := v.exchange.GetLatestTradeCursor()
+	if e != nil {
+		log.Printf("unable to fetch the latest trade cursor from backing exchange '%s', will assume that the ioc offset order is fully filled: %s\n", v.name, e)
+		return nil
+	}
+	return cursor
+}
+
+// filledVolume sums the base volume of the venue's trades after the cursor that belong to the order
+func (v *mirrorVenue) filledVolume(orderID string, cursor interface{}) (*model.Number, error) {
+	result, e := v.exchange.GetTradeHistory(*v.pair, cursor, nil)
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch trade history from backing exchange '%s': %s", v.name, e)
+	}
+
+	filled := model.NumberConstants.Zero
+	for _, t := range result.Trades {
+		if t.OrderID == orderID {
+			filled = filled.Add(*t.Volume)
 		}
+	}
+	return filled, nil
+}
+
+// requeueUnfilledOffset finds the part of an ioc offset order that was not filled and credits it back to the baseSurplus, the unfilled part
+// is queued as a new offset so it is retried, unless it is too small to place on its own in which case it is left for the next fill
+func (s *mirrorStrategy) requeueUnfilledOffset(txID string, venue *mirrorVenue, order *model.Order, orderID string, cursor interface{}, tradePrice *model.Number) error {
+	filled, e := venue.filledVolume(orderID, cursor)
+	if e != nil {
+		log.Printf("unable to find the fill of the ioc offset order (orderID=%s), assuming that it was fully filled: %s\n", orderID, e)
+		return nil
+	}
+	return s.requeueUnfilledVolume(txID, order, orderID, filled, tradePrice)
+}
+
+// requeueUnfilledVolume credits the part of the offset order that was not filled back to the baseSurplus and queues it as a new offset,
+// unless it is too small to place on its own
+func (s *mirrorStrategy) requeueUnfilledVolume(txID string, order *model.Order, orderID string, filled *model.Number, tradePrice *model.Number) error {
+	unfilled := order.Volume.Subtract(*filled)
+	if unfilled.AsFloat() <= 0.0 {
+		return nil
+	}
+	action := order.OrderAction
+	s.baseSurplus[action].total = s.baseSurplus[action].total.Add(*unfilled)
+	s.saveBaseSurplus(action)
+	log.Printf("offset-unfilled | tradeID=%s | orderID=%s | newOrderAction=%s | newOrderBaseAmt=%f | filledBaseAmt=%f | unfilledBaseAmt=%f | baseSurplusTotal=%f | baseSurplusCommitted=%f\n",
+		txID,
+		orderID,
+		action.String(),
+		order.Volume.AsFloat(),
+		filled.AsFloat(),
+		unfilled.AsFloat(),
+		s.baseSurplus[action].total.AsFloat(),
+		s.baseSurplus[action].committed.AsFloat())
+
+	if unfilled.AsFloat() < s.minBackingBaseVolume.AsFloat() {
+		return nil
+	}
+	// the unfilled volume stays committed while it is queued so the next fill does not offset it again
+	s.baseSurplus[action].committed = s.baseSurplus[action].committed.Add(*unfilled)
+	cause := fmt.Errorf("offset order (orderID=%s) only filled %s of %s", orderID, filled.AsString(), order.Volume.AsString())
+	e := s.enqueueOffset(unfilledOffsetTxID(txID, orderID), action, unfilled, tradePrice, offsetStatusPending, cause)
+	if e != nil {
+		s.baseSurplus[action].committed = s.baseSurplus[action].committed.Subtract(*unfilled)
+		return e
+	}
+	return nil
+}
 
-		// return an error on any other errors
-		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+// saveBaseSurplus writes the total base surplus of the action to the db so it survives a restart, errors are only logged
+func (s *mirrorStrategy) saveBaseSurplus(action model.OrderAction) {
+	total := s.baseSurplus[action].total
+	_, e := s.db.Exec(kelpdb.SqlStrategyMirrorBaseSurplusUpsert, s.marketID, action.String(), total.AsFloat())
+	if e != nil {
+		log.Printf("error saving baseSurplus (market_id=%s, action=%s, total=%f), it will not survive a restart: %s\n", s.marketID, action.String(), total.AsFloat(), e)
 	}
+}
 
-	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID)
+// recordOffset writes the trade trigger of a placed offset order together with the new total base surplus of the action in a single
+// transaction, so the db never has one without the other. A non-empty queuedTxID also removes that offset from the retry queue.
+func (s *mirrorStrategy) recordOffset(primaryTxID string, backingMarketID string, backingTxID string, action model.OrderAction, total *model.Number, queuedTxID string) error {
+	tx, e := s.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin transaction to record the offset: %s", e)
+	}
+
+	result, e := tx.Exec(kelpdb.SqlStrategyMirrorTradeTriggersInsert, s.marketID, primaryTxID, backingMarketID, backingTxID)
+	if e != nil {
+		tx.Rollback()
+		return fmt.Errorf("could not insert trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s): %s", s.marketID, primaryTxID, backingMarketID, backingTxID, e)
+	}
+	if rowsAffected, e := result.RowsAffected(); e == nil && rowsAffected == 0 {
+		log.Printf("trying to reinsert trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db, ignore and continue\n", s.marketID, primaryTxID, backingMarketID, backingTxID)
+	}
+
+	_, e = tx.Exec(kelpdb.SqlStrategyMirrorBaseSurplusUpsert, s.marketID, action.String(), total.AsFloat())
+	if e != nil {
+		tx.Rollback()
+		return fmt.Errorf("could not save baseSurplus (market_id=%s, action=%s, total=%f): %s", s.marketID, action.String(), total.AsFloat(), e)
+	}
+
+	if queuedTxID != "" {
+		_, e = tx.Exec(kelpdb.SqlStrategyMirrorOffsetRetriesDelete, s.marketID, queuedTxID)
+		if e != nil {
+			tx.Rollback()
+			return fmt.Errorf("could not delete offset retry for txid '%s': %s", queuedTxID, e)
+		}
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit transaction to record the offset: %s", e)
+	}
+	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) and baseSurplus (action=%s, total=%f) to db\n", s.marketID, primaryTxID, backingMarketID, backingTxID, action.String(), total.AsFloat())
 	return nil
 }
 
@@ -904,3 +904,195 @@ func TestBalanceCoordinatorCheckBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestParseOffsetOrderType(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    model.OrderType
+		wantErr bool
+	}{
+		{"", model.OrderTypeLimit, false},
+		{"limit", model.OrderTypeLimit, false},
+		{"market", model.OrderTypeMarket, false},
+		{"ioc", model.OrderTypeImmediateOrCancel, false},
+		{"stop-limit", model.OrderTypeLimit, true},
+		{"fok", model.OrderTypeLimit, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.input, func(t *testing.T) {
+			orderType, e := parseOffsetOrderType(k.input)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, orderType)
+		})
+	}
+}
+
+func TestMakeBaseSurplus(t *testing.T) {
+	testCases := []struct {
+		name      string
+		saved     map[string]float64
+		wantBuy   float64
+		wantSell  float64
+		wantError bool
+	}{
+		{"nothing saved", map[string]float64{}, 0.0, 0.0, false},
+		{"buy only", map[string]float64{"buy": 12.5}, 12.5, 0.0, false},
+		{"both with deficit", map[string]float64{"buy": 3.25, "sell": -1.5}, 3.25, -1.5, false},
+		{"invalid action", map[string]float64{"hold": 1.0}, 0.0, 0.0, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			baseSurplus, e := makeBaseSurplus(k.saved)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBuy, baseSurplus[model.OrderActionBuy].total.AsFloat())
+			assert.Equal(t, k.wantSell, baseSurplus[model.OrderActionSell].total.AsFloat())
+			assert.Equal(t, 0.0, baseSurplus[model.OrderActionBuy].committed.AsFloat())
+			assert.Equal(t, 0.0, baseSurplus[model.OrderActionSell].committed.AsFloat())
+		})
+	}
+}
+
+func TestOffsetPrice(t *testing.T) {
+	testCases := []struct {
+		name        string
+		orderType   model.OrderType
+		slippage    float64
+		orderAction model.OrderAction
+		want        *model.Number
+	}{
+		{"limit no slippage", model.OrderTypeLimit, 0.0, model.OrderActionBuy, model.NumberFromFloat(0.2, 4)},
+		{"ioc buy", model.OrderTypeImmediateOrCancel, 0.01, model.OrderActionBuy, model.NumberFromFloat(0.202, 4)},
+		{"ioc sell", model.OrderTypeImmediateOrCancel, 0.01, model.OrderActionSell, model.NumberFromFloat(0.198, 4)},
+		{"market ignores slippage", model.OrderTypeMarket, 0.01, model.OrderActionBuy, model.NumberFromFloat(0.2, 4)},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s := &mirrorStrategy{
+				offsetOrderType:   k.orderType,
+				offsetMaxSlippage: k.slippage,
+			}
+			price := s.offsetPrice(model.NumberFromFloat(0.2, 7), k.orderAction, model.MakeOrderConstraints(4, 1, 1.0))
+			assert.Equal(t, k.want.AsString(), price.AsString())
+		})
+	}
+}
+
+func TestBackingExchangeConfigs(t *testing.T) {
+	config := mirrorConfig{
+		Exchange:                   "kraken",
+		ExchangeBase:               "XLM",
+		ExchangeQuote:              "USD",
+		BidVolumeDivideBy:          pointy.Float64(2.0),
+		AskVolumeDivideBy:          pointy.Float64(-1.0),
+		BackingDbOverrideAccountID: "account1",
+		BackingExchanges: []mirrorBackingExchangeConfig{
+			{
+				Exchange:          "ccxt-binance",
+				ExchangeBase:      "XLM",
+				ExchangeQuote:     "USDT",
+				BidVolumeDivideBy: pointy.Float64(4.0),
+			}, {
+				Exchange:                   "ccxt-bitstamp",
+				ExchangeBase:               "XLM",
+				ExchangeQuote:              "USD",
+				BackingDbOverrideAccountID: "account3",
+			},
+		},
+	}
+
+	configs := config.backingExchangeConfigs()
+	if !assert.Equal(t, 3, len(configs)) {
+		return
+	}
+	assert.Equal(t, []string{"kraken", "ccxt-binance", "ccxt-bitstamp"}, []string{configs[0].Exchange, configs[1].Exchange, configs[2].Exchange})
+	// values that are not set on a backing exchange are taken from the top level of the config
+	assert.Equal(t, []float64{2.0, 4.0, 2.0}, []float64{*configs[0].BidVolumeDivideBy, *configs[1].BidVolumeDivideBy, *configs[2].BidVolumeDivideBy})
+	assert.Equal(t, []float64{-1.0, -1.0, -1.0}, []float64{*configs[0].AskVolumeDivideBy, *configs[1].AskVolumeDivideBy, *configs[2].AskVolumeDivideBy})
+	assert.Equal(t, []string{"account1", "account1", "account3"}, []string{configs[0].BackingDbOverrideAccountID, configs[1].BackingDbOverrideAccountID, configs[2].BackingDbOverrideAccountID})
+}
+
+func TestSortOrdersByPrice(t *testing.T) {
+	makeOrders := func(prices ...float64) []model.Order {
+		orders := []model.Order{}
+		for _, p := range prices {
+			orders = append(orders, model.Order{Price: model.NumberFromFloat(p, 4), Volume: model.NumberFromFloat(1.0, 1)})
+		}
+		return orders
+	}
+	pricesOf := func(orders []model.Order) []float64 {
+		prices := []float64{}
+		for _, o := range orders {
+			prices = append(prices, o.Price.AsFloat())
+		}
+		return prices
+	}
+
+	// the first venue's bids followed by the second venue's bids
+	bids := makeOrders(0.10, 0.09, 0.08, 0.095, 0.085)
+	sortOrdersByPrice(bids, true)
+	assert.Equal(t, []float64{0.10, 0.095, 0.09, 0.085, 0.08}, pricesOf(bids))
+
+	asks := makeOrders(0.11, 0.12, 0.13, 0.105, 0.125)
+	sortOrdersByPrice(asks, false)
+	assert.Equal(t, []float64{0.105, 0.11, 0.12, 0.125, 0.13}, pricesOf(asks))
+}
+
+func TestChooseOffsetVenue(t *testing.T) {
+	venueA := &mirrorVenue{name: "a"}
+	venueB := &mirrorVenue{name: "b"}
+	venueC := &mirrorVenue{name: "c"}
+	testCases := []struct {
+		name        string
+		quotes      []venueQuote
+		orderAction model.OrderAction
+		want        *mirrorVenue
+	}{
+		{
+			name:        "buy at lowest price",
+			quotes:      []venueQuote{{venueA, 0.11, true}, {venueB, 0.10, true}, {venueC, 0.12, true}},
+			orderAction: model.OrderActionBuy,
+			want:        venueB,
+		}, {
+			name:        "sell at highest price",
+			quotes:      []venueQuote{{venueA, 0.11, true}, {venueB, 0.10, true}, {venueC, 0.12, true}},
+			orderAction: model.OrderActionSell,
+			want:        venueC,
+		}, {
+			name:        "skip best price without balance",
+			quotes:      []venueQuote{{venueA, 0.11, true}, {venueB, 0.10, false}, {venueC, 0.12, true}},
+			orderAction: model.OrderActionBuy,
+			want:        venueA,
+		}, {
+			name:        "best price when no venue has balance",
+			quotes:      []venueQuote{{venueA, 0.11, false}, {venueB, 0.10, false}},
+			orderAction: model.OrderActionBuy,
+			want:        venueB,
+		}, {
+			name:        "no quotes",
+			quotes:      []venueQuote{},
+			orderAction: model.OrderActionSell,
+			want:        nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, chooseOffsetVenue(k.quotes, k.orderAction))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/stellar/kelp/kelpdb"
+)
+
+// OfferIDStore persists the mapping from the synthetic offerIDs that the BatchedExchange hands out to the orderIDs on the exchange
+type OfferIDStore interface {
+	// Load returns an empty map if nothing was saved yet
+	Load() (map[int64]string, error)
+
+	// Save replaces everything that was saved before
+	Save(offerID2OrderID map[int64]string) error
+}
+
+// fileOfferIDStore saves the mapping as a json file
+type fileOfferIDStore struct {
+	filename string
+}
+
+var _ OfferIDStore = &fileOfferIDStore{}
+
+// MakeFileOfferIDStore is a factory method
+func MakeFileOfferIDStore(filename string) OfferIDStore {
+	return &fileOfferIDStore{filename: filename}
+}
+
+// Load impl
+func (s *fileOfferIDStore) Load() (map[int64]string, error) {
+	bytes, e := ioutil.ReadFile(s.filename)
+	if os.IsNotExist(e) {
+		return map[int64]string{}, nil
+	} else if e != nil {
+		return nil, fmt.Errorf("could not read offer IDs file '%s': %s", s.filename, e)
+	}
+
+	offerID2OrderID := map[int64]string{}
+	e = json.Unmarshal(bytes, &offerID2OrderID)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse offer IDs file '%s': %s", s.filename, e)
+	}
+	return offerID2OrderID, nil
+}
+
+// Save impl, writes to a temp file first so a crash while writing does not lose the previously saved mapping
+func (s *fileOfferIDStore) Save(offerID2OrderID map[int64]string) error {
+	bytes, e := json.MarshalIndent(offerID2OrderID, "", "  ")
+	if e != nil {
+		return fmt.Errorf("could not marshal offer IDs: %s", e)
+	}
+
+	tempFile, e := ioutil.TempFile(filepath.Dir(s.filename), filepath.Base(s.filename)+".tmp")
+	if e != nil {
+		return fmt.Errorf("could not create temp file for offer IDs: %s", e)
+	}
+	_, e = tempFile.Write(bytes)
+	if e != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("could not write offer IDs to temp file '%s': %s", tempFile.Name(), e)
+	}
+	e = tempFile.Close()
+	if e != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("could not close temp file '%s': %s", tempFile.Name(), e)
+	}
+
+	e = os.Rename(tempFile.Name(), s.filename)
+	if e != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("could not move temp file '%s' to offer IDs file '%s': %s", tempFile.Name(), s.filename, e)
+	}
+	return nil
+}
+
+// postgresOfferIDStore saves the mapping in the batched_exchange_offer_ids table, keyed by market
+type postgresOfferIDStore struct {
+	db       *sql.DB
+	marketID string
+}
+
+var _ OfferIDStore = &postgresOfferIDStore{}
+
+// MakePostgresOfferIDStore is a factory method
+func MakePostgresOfferIDStore(db *sql.DB, marketID string) OfferIDStore {
+	return &postgresOfferIDStore{
+		db:       db,
+		marketID: marketID,
+	}
+}
+
+// Load impl
+func (s *postgresOfferIDStore) Load() (map[int64]string, error) {
+	rows, e := s.db.Query(kelpdb.SqlQueryBatchedExchangeOfferIDs, s.marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not query offer IDs for marketID '%s': %s", s.marketID, e)
+	}
+	defer rows.Close()
+
+	offerID2OrderID := map[int64]string{}
+	for rows.Next() {
+		var offerID int64
+		var orderID string
+		e = rows.Scan(&offerID, &orderID)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan offer ID row: %s", e)
+		}
+		offerID2OrderID[offerID] = orderID
+	}
+	e = rows.Err()
+	if e != nil {
+		return nil, fmt.Errorf("error while iterating over offer ID rows: %s", e)
+	}
+	return offerID2OrderID, nil
+}
+
+// Save impl, replaces the rows for the market in a single transaction
+func (s *postgresOfferIDStore) Save(offerID2OrderID map[int64]string) error {
+	tx, e := s.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin transaction to save offer IDs: %s", e)
+	}
+
+	_, e = tx.Exec(kelpdb.SqlBatchedExchangeOfferIDsDelete, s.marketID)
+	if e != nil {
+		tx.Rollback()
+		return fmt.Errorf("could not delete offer IDs for marketID '%s': %s", s.marketID, e)
+	}
+	for offerID, orderID := range offerID2OrderID {
+		_, e = tx.Exec(kelpdb.SqlBatchedExchangeOfferIDsInsert, s.marketID, offerID, orderID)
+		if e != nil {
+			tx.Rollback()
+			return fmt.Errorf("could not insert offer ID (offerID=%d, orderID=%s) for marketID '%s': %s", offerID, orderID, s.marketID, e)
+		}
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit transaction to save offer IDs: %s", e)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileOfferIDStore(t *testing.T) {
+	dir, e := ioutil.TempDir("", "offerIDStore")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	s := MakeFileOfferIDStore(filepath.Join(dir, "offer_ids.json"))
+
+	// loading before anything is saved returns an empty map
+	m, e := s.Load()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[int64]string{}, m)
+
+	want := map[int64]string{
+		5:                   "order-1",
+		9223372036854775807: "order-2",
+	}
+	e = s.Save(want)
+	if !assert.NoError(t, e) {
+		return
+	}
+	m, e = s.Load()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, want, m)
+
+	// saving replaces the previous mapping
+	e = s.Save(map[int64]string{7: "order-3"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	m, e = s.Load()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[int64]string{7: "order-3"}, m)
+
+	// no temp files are left behind
+	files, e := ioutil.ReadDir(dir)
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1, len(files))
+	}
+}
@@ -15,6 +15,7 @@ type orderConstraintsFilter struct {
 	oc         *model.OrderConstraints
 	baseAsset  hProtocol.Asset
 	quoteAsset hProtocol.Asset
+	fe         *FilterExplainer
 }
 
 var _ SubmitFilter = &orderConstraintsFilter{}
@@ -24,11 +25,13 @@ func MakeFilterOrderConstraints(
 	oc *model.OrderConstraints,
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
+	fe *FilterExplainer,
 ) SubmitFilter {
 	return &orderConstraintsFilter{
 		oc:         oc,
 		baseAsset:  baseAsset,
 		quoteAsset: quoteAsset,
+		fe:         fe,
 	}
 }
 
@@ -44,12 +47,13 @@ func (f *orderConstraintsFilter) Apply(
 
 	for _, op := range ops {
 		var keep bool
+		var reason string
 		var e error
 		var opPtr *txnbuild.ManageSellOffer
 
 		switch o := op.(type) {
 		case *txnbuild.ManageSellOffer:
-			keep, e = f.shouldKeepOffer(o)
+			keep, reason, e = f.shouldKeepOffer(o)
 			if e != nil {
 				return nil, fmt.Errorf("could not transform offer (pointer case): %s", e)
 			}
@@ -66,11 +70,13 @@ func (f *orderConstraintsFilter) Apply(
 			// figure out how to convert the offer to a dropped state
 			if opPtr.OfferID == 0 {
 				// new offers can be dropped, so don't add to filteredOps
+				f.fe.recordFilterExplanation("orderConstraintsFilter", filterActionDropped, opPtr, nil, reason+" so the new operation is dropped")
 			} else if opPtr.Amount != "0" {
 				// modify offers should be converted to delete offers
 				opCopy := *opPtr
 				opCopy.Amount = "0"
 				filteredOps = append(filteredOps, &opCopy)
+				f.fe.recordFilterExplanation("orderConstraintsFilter", filterActionDeleted, opPtr, &opCopy, reason+" so the existing offer is deleted")
 			} else {
 				return nil, fmt.Errorf("unable to drop manageOffer operation (probably a delete op that should not have reached here): offerID=%d, amountRaw=%s", opPtr.OfferID, opPtr.Amount)
 			}
@@ -81,25 +87,26 @@ func (f *orderConstraintsFilter) Apply(
 	return filteredOps, nil
 }
 
-func (f *orderConstraintsFilter) shouldKeepOffer(op *txnbuild.ManageSellOffer) (bool, error) {
+// shouldKeepOffer returns whether to keep the op along with the reason when it should not be kept
+func (f *orderConstraintsFilter) shouldKeepOffer(op *txnbuild.ManageSellOffer) (bool, string, error) {
 	// delete operations should never be dropped
 	amountFloat, e := strconv.ParseFloat(op.Amount, 64)
 	if e != nil {
-		return false, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+		return false, "", fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
 	}
 	if op.Amount == "0" || amountFloat == 0.0 {
 		log.Printf("orderConstraintsFilter: keeping delete operation with amount = %s\n", op.Amount)
-		return true, nil
+		return true, "", nil
 	}
 
 	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
 	if e != nil {
-		return false, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+		return false, "", fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
 	}
 
 	sellPrice, e := strconv.ParseFloat(op.Price, 64)
 	if e != nil {
-		return false, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+		return false, "", fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
 	}
 
 	if isSell {
@@ -107,14 +114,14 @@ func (f *orderConstraintsFilter) shouldKeepOffer(op *txnbuild.ManageSellOffer) (
 		quoteAmount := baseAmount * sellPrice
 		if baseAmount < f.oc.MinBaseVolume.AsFloat() {
 			log.Printf("orderConstraintsFilter: selling, keep = (baseAmount) %.8f < %s (MinBaseVolume): keep = false\n", baseAmount, f.oc.MinBaseVolume.AsString())
-			return false, nil
+			return false, fmt.Sprintf("base amount %.8f is below the exchange's min base volume of %s", baseAmount, f.oc.MinBaseVolume.AsString()), nil
 		}
 		if f.oc.MinQuoteVolume != nil && quoteAmount < f.oc.MinQuoteVolume.AsFloat() {
 			log.Printf("orderConstraintsFilter: selling, keep = (quoteAmount) %.8f < %s (MinQuoteVolume): keep = false\n", quoteAmount, f.oc.MinQuoteVolume.AsString())
-			return false, nil
+			return false, fmt.Sprintf("quote amount %.8f is below the exchange's min quote volume of %s", quoteAmount, f.oc.MinQuoteVolume.AsString()), nil
 		}
 		log.Printf("orderConstraintsFilter: selling, baseAmount=%.8f, quoteAmount=%.8f, keep = true\n", baseAmount, quoteAmount)
-		return true, nil
+		return true, "", nil
 	}
 
 	// buying
@@ -122,12 +129,12 @@ func (f *orderConstraintsFilter) shouldKeepOffer(op *txnbuild.ManageSellOffer) (
 	baseAmount := quoteAmount * sellPrice
 	if baseAmount < f.oc.MinBaseVolume.AsFloat() {
 		log.Printf("orderConstraintsFilter:  buying, keep = (baseAmount) %.8f < %s (MinBaseVolume): keep = false\n", baseAmount, f.oc.MinBaseVolume.AsString())
-		return false, nil
+		return false, fmt.Sprintf("base amount %.8f is below the exchange's min base volume of %s", baseAmount, f.oc.MinBaseVolume.AsString()), nil
 	}
 	if f.oc.MinQuoteVolume != nil && quoteAmount < f.oc.MinQuoteVolume.AsFloat() {
 		log.Printf("orderConstraintsFilter:  buying, keep = (quoteAmount) %.8f < %s (MinQuoteVolume): keep = false\n", quoteAmount, f.oc.MinQuoteVolume.AsString())
-		return false, nil
+		return false, fmt.Sprintf("quote amount %.8f is below the exchange's min quote volume of %s", quoteAmount, f.oc.MinQuoteVolume.AsString()), nil
 	}
 	log.Printf("orderConstraintsFilter:  buying, baseAmount=%.8f, quoteAmount=%.8f, keep = true\n", baseAmount, quoteAmount)
-	return true, nil
+	return true, "", nil
 }
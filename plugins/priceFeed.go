@@ -86,6 +86,14 @@ func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 		}
 		return fnFeed, nil
 	}
+
+	if makeFn, ok := registeredPriceFeeds[feedType]; ok {
+		feed, e := makeFn(url)
+		if e != nil {
+			return nil, fmt.Errorf("error while making registered '%s' price feed for URL '%s': %s", feedType, url, e)
+		}
+		return feed, nil
+	}
 	return nil, fmt.Errorf("unable to make price feed for feedType=%s and url=%s", feedType, url)
 }
 
@@ -27,6 +27,14 @@ func (c comparisonMode) keepSellOp(threshold float64, price float64) bool {
 	panic("unidentified comparisonMode")
 }
 
+// describeRejectedSell explains why keepSellOp returned false
+func (c comparisonMode) describeRejectedSell() string {
+	if c == comparisonModeOutsideExclude {
+		return "not above"
+	}
+	return "below"
+}
+
 // TODO implement passesBuy() where we use < and <= operators
 
 type priceFeedFilter struct {
@@ -35,10 +43,11 @@ type priceFeedFilter struct {
 	quoteAsset hProtocol.Asset
 	pf         api.PriceFeed
 	cm         comparisonMode
+	fe         *FilterExplainer
 }
 
 // MakeFilterPriceFeed makes a submit filter that limits orders placed based on the value of the price feed
-func MakeFilterPriceFeed(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, comparisonModeString string, pf api.PriceFeed) (SubmitFilter, error) {
+func MakeFilterPriceFeed(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, comparisonModeString string, pf api.PriceFeed, fe *FilterExplainer) (SubmitFilter, error) {
 	var cm comparisonMode
 	if comparisonModeString == "outside-exclude" {
 		cm = comparisonModeOutsideExclude
@@ -54,33 +63,45 @@ func MakeFilterPriceFeed(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset,
 		quoteAsset: quoteAsset,
 		cm:         cm,
 		pf:         pf,
+		fe:         fe,
 	}, nil
 }
 
 var _ SubmitFilter = &priceFeedFilter{}
 
 func (f *priceFeedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.priceFeedFilterFn)
+	// the threshold is captured from the inner fn so the reason shows the price feed value that was used for the op
+	var thresholdFeedPrice float64
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		newOp, threshold, e := f.priceFeedFilterFn(op)
+		thresholdFeedPrice = threshold
+		return newOp, e
+	}
+	reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+		return fmt.Sprintf("sell price %s is %s the price feed value of %.10f", before.Price, f.cm.describeRejectedSell(), thresholdFeedPrice)
+	}
+	ops, e := filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn, reasonFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
 	return ops, nil
 }
 
-func (f *priceFeedFilter) priceFeedFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+// priceFeedFilterFn returns the op to keep and the price feed value that was used as the threshold
+func (f *priceFeedFilter) priceFeedFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, float64, error) {
 	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
 	if e != nil {
-		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+		return nil, 0, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
 	}
 
 	sellPrice, e := strconv.ParseFloat(op.Price, 64)
 	if e != nil {
-		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+		return nil, 0, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
 	}
 
 	thresholdFeedPrice, e := f.pf.GetPrice()
 	if e != nil {
-		return nil, fmt.Errorf("could not get price from priceFeed: %s", e)
+		return nil, 0, fmt.Errorf("could not get price from priceFeed: %s", e)
 	}
 
 	var opRet *txnbuild.ManageSellOffer
@@ -97,5 +118,5 @@ func (f *priceFeedFilter) priceFeedFilterFn(op *txnbuild.ManageSellOffer) (*txnb
 		opRet = op
 	}
 	log.Printf("priceFeedFilter: isSell=%v, sellPrice=%.10f, thresholdFeedPrice=%.10f, keep=%v", isSell, sellPrice, thresholdFeedPrice, opRet != nil)
-	return opRet, nil
+	return opRet, thresholdFeedPrice, nil
 }
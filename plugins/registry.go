@@ -0,0 +1,212 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// The Register* functions in this file write to package level maps that are read without a lock when bots are made and run, so all
+// registration must be finished before any bot is started, e.g. by only registering from init functions. They are not safe for concurrent use.
+
+// StrategyFactoryData is the information passed to the factory method of a registered strategy
+type StrategyFactoryData struct {
+	SDEX            *SDEX
+	ExchangeShim    api.ExchangeShim
+	TradeFetcher    api.TradeFetcher
+	IEIF            *IEIF
+	TradingPair     *model.TradingPair
+	AssetBase       *hProtocol.Asset
+	AssetQuote      *hProtocol.Asset
+	MarketID        string
+	StratConfigPath string
+	SimMode         bool
+	IsTradingSdex   bool
+	FilterFactory   *FilterFactory
+	DB              *sql.DB
+	Alert           api.Alert
+}
+
+// ExchangeFactoryData is the information passed to the factory method of a registered exchange
+type ExchangeFactoryData struct {
+	SimMode        bool
+	APIKeys        []api.ExchangeAPIKey
+	ExchangeParams []api.ExchangeParam
+	Headers        []api.ExchangeHeader
+}
+
+// registeredExchanges holds the exchanges registered with RegisterExchange before the exchanges map is loaded
+var registeredExchanges = map[string]ExchangeContainer{}
+
+// registeredExchangeNames holds the names of the registered exchanges in the order they were registered so their SortOrder is stable
+var registeredExchangeNames = []string{}
+
+// registeredPriceFeeds is a map of the price feed types registered with RegisterPriceFeed
+var registeredPriceFeeds = map[string]func(url string) (api.PriceFeed, error){}
+
+// builtinExchangeNames are the native exchange integrations in the exchanges map, which cannot be registered
+var builtinExchangeNames = map[string]bool{
+	"kraken":      true,
+	"binance":     true,
+	"coinbasepro": true,
+}
+
+// ccxtExchangePrefix is the prefix of the names of the exchanges added via ccxt-rest, which cannot be registered
+const ccxtExchangePrefix = "ccxt-"
+
+// builtinPriceFeedTypes are the feed types handled by MakePriceFeed, which cannot be registered
+var builtinPriceFeedTypes = map[string]bool{
+	"crypto":   true,
+	"fiat":     true,
+	"fixed":    true,
+	"exchange": true,
+	"sdex":     true,
+	"function": true,
+}
+
+// RegisterStrategy adds a strategy so it can be used as the strategy of the trade command, allowing strategies to live outside of this
+// repository. It must be called from an init function of the package that defines the strategy, before the bot is started.
+// The SortOrder and makeFn of the container are ignored, the strategy is listed after the ones already registered.
+func RegisterStrategy(name string, container StrategyContainer, makeFn func(data StrategyFactoryData) (api.Strategy, error)) error {
+	if name == "" {
+		return fmt.Errorf("cannot register a strategy without a name")
+	}
+	if makeFn == nil {
+		return fmt.Errorf("cannot register the '%s' strategy without a factory method", name)
+	}
+	if _, ok := strategies[name]; ok {
+		return fmt.Errorf("a strategy named '%s' is already registered", name)
+	}
+
+	container.SortOrder = uint8(len(strategies))
+	container.makeFn = func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+		return makeFn(StrategyFactoryData{
+			SDEX:            strategyFactoryData.sdex,
+			ExchangeShim:    strategyFactoryData.exchangeShim,
+			TradeFetcher:    strategyFactoryData.tradeFetcher,
+			IEIF:            strategyFactoryData.ieif,
+			TradingPair:     strategyFactoryData.tradingPair,
+			AssetBase:       strategyFactoryData.assetBase,
+			AssetQuote:      strategyFactoryData.assetQuote,
+			MarketID:        strategyFactoryData.marketID,
+			StratConfigPath: strategyFactoryData.stratConfigPath,
+			SimMode:         strategyFactoryData.simMode,
+			IsTradingSdex:   strategyFactoryData.isTradingSdex,
+			FilterFactory:   strategyFactoryData.filterFactory,
+			DB:              strategyFactoryData.db,
+			Alert:           strategyFactoryData.alert,
+		})
+	}
+	strategies[name] = container
+	return nil
+}
+
+// RegisterFilter adds a filter that can be used in the FILTERS of the trader config, where the filter name is the part of the config
+// string before the first '/', and as the TYPE of a [[FILTER]] table with the rest of the config string in its CONFIG key.
+// supportsAllStrategies should only be true if the filter treats buy and sell offers the same way, otherwise the filter can only be
+// used with the sell, sell_twap and delete strategies. It must be called from an init function, before the bot is started.
+func RegisterFilter(name string, supportsAllStrategies bool, makeFn func(f *FilterFactory, configInput string) (SubmitFilter, error)) error {
+	if name == "" {
+		return fmt.Errorf("cannot register a filter without a name")
+	}
+	if makeFn == nil {
+		return fmt.Errorf("cannot register the '%s' filter without a factory method", name)
+	}
+	if _, ok := filterMap[name]; ok {
+		return fmt.Errorf("a filter named '%s' is already registered", name)
+	}
+
+	filterMap[name] = makeFn
+	if supportsAllStrategies {
+		filtersSupportedOnAllStrategies[name] = true
+	}
+	return nil
+}
+
+// RegisterPriceFeed adds a price feed type that can be used wherever a feed type and url are configured, such as DATA_TYPE_A and
+// DATA_FEED_A_URL of the buysell strategy. It must be called from an init function, before the bot is started.
+func RegisterPriceFeed(feedType string, makeFn func(url string) (api.PriceFeed, error)) error {
+	if feedType == "" {
+		return fmt.Errorf("cannot register a price feed without a feed type")
+	}
+	if makeFn == nil {
+		return fmt.Errorf("cannot register the '%s' price feed without a factory method", feedType)
+	}
+	if _, ok := builtinPriceFeedTypes[feedType]; ok {
+		return fmt.Errorf("cannot register the '%s' price feed because it is a built-in feed type", feedType)
+	}
+	if _, ok := registeredPriceFeeds[feedType]; ok {
+		return fmt.Errorf("a price feed of type '%s' is already registered", feedType)
+	}
+
+	registeredPriceFeeds[feedType] = makeFn
+	return nil
+}
+
+// RegisterExchange adds an exchange integration that can be used as the TRADING_EXCHANGE of the trader config, in the exchange price
+// feed and as a backing exchange of the mirror strategy. It must be called from an init function, before the bot is started.
+// The SortOrder and makeFn of the container are ignored, the exchange is listed after the built-in and ccxt exchanges.
+func RegisterExchange(name string, container ExchangeContainer, makeFn func(data ExchangeFactoryData) (api.Exchange, error)) error {
+	if name == "" {
+		return fmt.Errorf("cannot register an exchange without a name")
+	}
+	if makeFn == nil {
+		return fmt.Errorf("cannot register the '%s' exchange without a factory method", name)
+	}
+	if builtinExchangeNames[name] || strings.HasPrefix(name, ccxtExchangePrefix) {
+		return fmt.Errorf("cannot register the '%s' exchange because the name is used by a built-in or ccxt exchange", name)
+	}
+	if _, ok := registeredExchanges[name]; ok {
+		return fmt.Errorf("an exchange named '%s' is already registered", name)
+	}
+	if exchanges != nil {
+		if _, ok := (*exchanges)[name]; ok {
+			return fmt.Errorf("an exchange named '%s' is already registered", name)
+		}
+	}
+
+	container.makeFn = func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
+		return makeFn(ExchangeFactoryData{
+			SimMode:        exchangeFactoryData.simMode,
+			APIKeys:        exchangeFactoryData.apiKeys,
+			ExchangeParams: exchangeFactoryData.exchangeParams,
+			Headers:        exchangeFactoryData.headers,
+		})
+	}
+	registeredExchanges[name] = container
+	registeredExchangeNames = append(registeredExchangeNames, name)
+
+	// exchanges that are registered after the exchanges map was loaded are added to it directly
+	if exchanges != nil {
+		addRegisteredExchange(*exchanges, name)
+	}
+	return nil
+}
+
+// addRegisteredExchanges adds all the registered exchanges to the exchanges map, called when loading the exchanges map.
+// Registered exchanges whose name is already in the map are skipped and returned in the error.
+func addRegisteredExchanges(m map[string]ExchangeContainer) error {
+	skipped := []string{}
+	for _, name := range registeredExchangeNames {
+		if _, ok := m[name]; ok {
+			skipped = append(skipped, name)
+			continue
+		}
+		addRegisteredExchange(m, name)
+	}
+
+	if len(skipped) > 0 {
+		return fmt.Errorf("cannot add registered exchanges %v because exchanges with those names already exist", skipped)
+	}
+	return nil
+}
+
+func addRegisteredExchange(m map[string]ExchangeContainer, name string) {
+	container := registeredExchanges[name]
+	container.SortOrder = uint16(len(m))
+	m[name] = container
+}
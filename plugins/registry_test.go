@@ -0,0 +1,173 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterStrategy(t *testing.T) {
+	name := "test_registered"
+	defer delete(strategies, name)
+
+	var gotData StrategyFactoryData
+	makeFn := func(data StrategyFactoryData) (api.Strategy, error) {
+		gotData = data
+		return nil, nil
+	}
+	numStrategies := len(strategies)
+
+	e := RegisterStrategy(name, StrategyContainer{
+		SortOrder:   100,
+		Description: "test strategy",
+		NeedsConfig: false,
+		Complexity:  "Beginner",
+	}, makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, uint8(numStrategies), Strategies()[name].SortOrder)
+	assert.Equal(t, "test strategy", Strategies()[name].Description)
+
+	_, e = MakeStrategy(nil, nil, nil, nil, nil, nil, nil, "mkt", name, "", true, false, nil, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "mkt", gotData.MarketID)
+	assert.True(t, gotData.SimMode)
+
+	assert.Error(t, RegisterStrategy(name, StrategyContainer{}, makeFn))
+	assert.Error(t, RegisterStrategy("buysell", StrategyContainer{}, makeFn))
+	assert.Error(t, RegisterStrategy("", StrategyContainer{}, makeFn))
+	assert.Error(t, RegisterStrategy("test_no_make_fn", StrategyContainer{}, nil))
+}
+
+func TestRegisterFilter(t *testing.T) {
+	name := "testRegistered"
+	defer delete(filterMap, name)
+	defer delete(filtersSupportedOnAllStrategies, name)
+
+	makeFn := func(f *FilterFactory, configInput string) (SubmitFilter, error) {
+		return nil, fmt.Errorf("made with configInput %s", configInput)
+	}
+	if !assert.NoError(t, RegisterFilter(name, true, makeFn)) {
+		return
+	}
+	assert.True(t, FilterSupportsAllStrategies(name))
+
+	ff := &FilterFactory{}
+	_, e := ff.MakeFilter(name + "/abc")
+	if assert.Error(t, e) {
+		assert.Equal(t, "made with configInput testRegistered/abc", e.Error())
+	}
+
+	// registered filters can also be used as the TYPE of a [[FILTER]] table
+	fc := FilterConfig{Type: name, Config: "abc"}
+	if assert.NoError(t, fc.Validate()) {
+		filterString, e := fc.FilterString()
+		if assert.NoError(t, e) {
+			assert.Equal(t, name+"/abc", filterString)
+		}
+	}
+	fc = FilterConfig{Type: name, Action: "sell"}
+	e = fc.Validate()
+	if keyError, ok := e.(*FilterConfigKeyError); assert.True(t, ok, "error was not a *FilterConfigKeyError: %s", e) {
+		assert.Equal(t, "ACTION", keyError.Key)
+	}
+
+	assert.Error(t, RegisterFilter(name, true, makeFn))
+	assert.Error(t, RegisterFilter("volume", false, makeFn))
+	assert.Error(t, RegisterFilter("", false, makeFn))
+}
+
+func TestRegisterFilterSellOnly(t *testing.T) {
+	name := "testRegisteredSellOnly"
+	defer delete(filterMap, name)
+
+	makeFn := func(f *FilterFactory, configInput string) (SubmitFilter, error) {
+		return nil, nil
+	}
+	if !assert.NoError(t, RegisterFilter(name, false, makeFn)) {
+		return
+	}
+	assert.False(t, FilterSupportsAllStrategies(name))
+	assert.True(t, FilterSupportsAllStrategies("maxOrders"))
+}
+
+func TestRegisterPriceFeed(t *testing.T) {
+	feedType := "testRegistered"
+	defer delete(registeredPriceFeeds, feedType)
+
+	makeFn := func(url string) (api.PriceFeed, error) {
+		return newFixedFeed(url)
+	}
+	if !assert.NoError(t, RegisterPriceFeed(feedType, makeFn)) {
+		return
+	}
+
+	feed, e := MakePriceFeed(feedType, "1.5")
+	if !assert.NoError(t, e) {
+		return
+	}
+	price, e := feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.5, price)
+
+	assert.Error(t, RegisterPriceFeed(feedType, makeFn))
+	assert.Error(t, RegisterPriceFeed("fixed", makeFn))
+	assert.Error(t, RegisterPriceFeed("", makeFn))
+}
+
+func TestAddRegisteredExchanges(t *testing.T) {
+	names := []string{"test-registered-a", "test-registered-b"}
+	defer func() {
+		for _, name := range names {
+			delete(registeredExchanges, name)
+		}
+		registeredExchangeNames = registeredExchangeNames[:len(registeredExchangeNames)-len(names)]
+	}()
+
+	// register against a separate map so the test does not need to load the ccxt exchanges
+	savedExchanges := exchanges
+	exchanges = nil
+	defer func() { exchanges = savedExchanges }()
+
+	for _, name := range names {
+		e := RegisterExchange(name, ExchangeContainer{
+			Description:  name,
+			TradeEnabled: true,
+		}, func(data ExchangeFactoryData) (api.Exchange, error) {
+			return nil, nil
+		})
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+	noopMakeFn := func(data ExchangeFactoryData) (api.Exchange, error) { return nil, nil }
+	assert.Error(t, RegisterExchange(names[0], ExchangeContainer{}, noopMakeFn))
+	assert.Error(t, RegisterExchange("kraken", ExchangeContainer{}, noopMakeFn))
+	assert.Error(t, RegisterExchange("ccxt-test-registered", ExchangeContainer{}, noopMakeFn))
+
+	m := map[string]ExchangeContainer{
+		"kraken": {SortOrder: 0},
+	}
+	assert.NoError(t, addRegisteredExchanges(m))
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, uint16(1), m[names[0]].SortOrder)
+	assert.Equal(t, uint16(2), m[names[1]].SortOrder)
+	assert.True(t, m[names[1]].TradeEnabled)
+
+	// an exchange that already exists in the map is skipped and reported instead of replaced
+	m = map[string]ExchangeContainer{
+		"kraken": {SortOrder: 0},
+		names[0]: {SortOrder: 1, Description: "existing"},
+	}
+	assert.Error(t, addRegisteredExchanges(m))
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, "existing", m[names[0]].Description)
+	assert.Equal(t, uint16(2), m[names[1]].SortOrder)
+}
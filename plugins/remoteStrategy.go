@@ -0,0 +1,440 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/build"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// defaultRemoteTimeoutMillis is used when TIMEOUT_MILLIS is not set
+const defaultRemoteTimeoutMillis = 2000
+
+// remoteMethodGetLevels is the JSON-RPC method called on the remote process once in every update cycle
+const remoteMethodGetLevels = "get_levels"
+
+// remoteConfig contains the configuration params for this strategy
+type remoteConfig struct {
+	Address         string  `valid:"-" toml:"ADDRESS"`
+	TimeoutMillis   int     `valid:"-" toml:"TIMEOUT_MILLIS"`
+	OrderbookDepth  int32   `valid:"-" toml:"ORDERBOOK_DEPTH"`
+	PriceTolerance  float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+}
+
+// String impl.
+func (c remoteConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// remoteLevel is a price level in the remote strategy protocol. Prices are in units of the quote asset per unit of the base asset and
+// amounts are in units of the base asset on both sides of the orderbook.
+type remoteLevel struct {
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+}
+
+// remoteOffer is one of the bot's open offers in the remote strategy protocol, priced like a remoteLevel
+type remoteOffer struct {
+	ID     int64   `json:"id"`
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+}
+
+// remoteBalances are the balances available to the bot, in units of each asset
+type remoteBalances struct {
+	Base       float64 `json:"base"`
+	Quote      float64 `json:"quote"`
+	TrustBase  float64 `json:"trust_base"`
+	TrustQuote float64 `json:"trust_quote"`
+}
+
+// remoteGetLevelsParams are the params of the get_levels request
+type remoteGetLevelsParams struct {
+	Base            string         `json:"base"`
+	Quote           string         `json:"quote"`
+	TimestampMillis int64          `json:"timestamp_millis"`
+	Balances        remoteBalances `json:"balances"`
+	SellOffers      []remoteOffer  `json:"sell_offers"`
+	BuyOffers       []remoteOffer  `json:"buy_offers"`
+	Bids            []remoteLevel  `json:"bids"`
+	Asks            []remoteLevel  `json:"asks"`
+}
+
+// remoteGetLevelsResult is the result of the get_levels request, with the best priced level first on each side
+type remoteGetLevelsResult struct {
+	SellLevels []remoteLevel `json:"sell_levels"`
+	BuyLevels  []remoteLevel `json:"buy_levels"`
+}
+
+// remoteRequest is a JSON-RPC 2.0 request
+type remoteRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// remoteResponse is a JSON-RPC 2.0 response
+type remoteResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      uint64           `json:"id"`
+	Result  *json.RawMessage `json:"result"`
+	Error   *remoteError     `json:"error"`
+}
+
+// remoteError is the error object of a JSON-RPC 2.0 response
+type remoteError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// remoteClient makes JSON-RPC 2.0 calls to the remote process, using a new connection for each call so the remote process can be
+// restarted while the bot is running. Each request and response is a single JSON object followed by a newline.
+type remoteClient struct {
+	network string
+	address string
+	timeout time.Duration
+
+	// uninitialized
+	lastID uint64
+}
+
+// makeRemoteClient is a factory method, the address is either unix:///path/to/socket or tcp://host:port
+func makeRemoteClient(address string, timeout time.Duration) (*remoteClient, error) {
+	parts := strings.SplitN(address, "://", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid ADDRESS '%s', needs to be of the form unix:///path/to/socket or tcp://host:port", address)
+	}
+	if parts[0] != "unix" && parts[0] != "tcp" {
+		return nil, fmt.Errorf("invalid network '%s' in ADDRESS '%s', needs to be either 'unix' or 'tcp'", parts[0], address)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("the timeout needs to be > 0 but was %s", timeout)
+	}
+
+	return &remoteClient{
+		network: parts[0],
+		address: parts[1],
+		timeout: timeout,
+	}, nil
+}
+
+// call sends the request and decodes the result into result, the whole call including connecting needs to finish within the timeout
+func (c *remoteClient) call(method string, params interface{}, result interface{}) error {
+	deadline := time.Now().Add(c.timeout)
+	conn, e := net.DialTimeout(c.network, c.address, c.timeout)
+	if e != nil {
+		return fmt.Errorf("could not connect to the remote strategy at %s://%s: %s", c.network, c.address, e)
+	}
+	defer conn.Close()
+
+	e = conn.SetDeadline(deadline)
+	if e != nil {
+		return fmt.Errorf("could not set the deadline on the connection to the remote strategy: %s", e)
+	}
+
+	c.lastID++
+	request := remoteRequest{
+		JSONRPC: "2.0",
+		ID:      c.lastID,
+		Method:  method,
+		Params:  params,
+	}
+	// Encode terminates the request with a newline
+	e = json.NewEncoder(conn).Encode(request)
+	if e != nil {
+		return fmt.Errorf("could not send the '%s' request to the remote strategy: %s", method, wrapRemoteTimeout(e, c.timeout))
+	}
+
+	var response remoteResponse
+	e = json.NewDecoder(conn).Decode(&response)
+	if e != nil {
+		return fmt.Errorf("could not read the response to the '%s' request from the remote strategy: %s", method, wrapRemoteTimeout(e, c.timeout))
+	}
+	if response.ID != request.ID {
+		return fmt.Errorf("the remote strategy responded with id %d to the '%s' request with id %d", response.ID, method, request.ID)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("the remote strategy returned an error for the '%s' request (code %d): %s", method, response.Error.Code, response.Error.Message)
+	}
+	if response.Result == nil {
+		return fmt.Errorf("the remote strategy returned neither a result nor an error for the '%s' request", method)
+	}
+
+	e = json.Unmarshal(*response.Result, result)
+	if e != nil {
+		return fmt.Errorf("could not decode the result of the '%s' request from the remote strategy: %s", method, e)
+	}
+	return nil
+}
+
+func wrapRemoteTimeout(e error, timeout time.Duration) error {
+	if ne, ok := e.(net.Error); ok && ne.Timeout() {
+		return fmt.Errorf("timed out after %s: %s", timeout, e)
+	}
+	return e
+}
+
+// remoteStrategy gets the levels on both sides of the orderbook from a process outside of the bot, so the pricing logic can be written
+// in any language. The remote process is called once in every update cycle from PreUpdate, and when the call fails or times out
+// PreUpdate returns an error so the trader deletes its offers instead of leaving the previous levels in the orderbook.
+type remoteStrategy struct {
+	client           *remoteClient
+	exchangeShim     api.ExchangeShim
+	tradingPair      *model.TradingPair
+	assetBase        *hProtocol.Asset
+	assetQuote       *hProtocol.Asset
+	orderConstraints *model.OrderConstraints
+	orderbookDepth   int32
+	composeStrategy  api.Strategy
+
+	// uninitialized
+	sellLevels []api.Level
+	buyLevels  []api.Level
+}
+
+// ensure it implements Strategy
+var _ api.Strategy = &remoteStrategy{}
+
+// remoteLevelProvider provides the levels of one side of the orderbook fetched by the remoteStrategy in the current update cycle
+type remoteLevelProvider struct {
+	strategy  *remoteStrategy
+	isBuySide bool
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &remoteLevelProvider{}
+
+// makeRemoteStrategy is a factory method
+func makeRemoteStrategy(
+	sdex *SDEX,
+	exchangeShim api.ExchangeShim,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *remoteConfig,
+) (api.Strategy, error) {
+	timeoutMillis := config.TimeoutMillis
+	if timeoutMillis == 0 {
+		timeoutMillis = defaultRemoteTimeoutMillis
+	}
+	client, e := makeRemoteClient(config.Address, time.Duration(timeoutMillis)*time.Millisecond)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the remote strategy because we could not make the client: %s", e)
+	}
+	if config.OrderbookDepth < 0 {
+		return nil, fmt.Errorf("ORDERBOOK_DEPTH needs to be >= 0 but was %d", config.OrderbookDepth)
+	}
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	s := &remoteStrategy{
+		client:           client,
+		exchangeShim:     exchangeShim,
+		tradingPair:      pair,
+		assetBase:        assetBase,
+		assetQuote:       assetQuote,
+		orderConstraints: orderConstraints,
+		orderbookDepth:   config.OrderbookDepth,
+	}
+
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		&remoteLevelProvider{strategy: s, isBuySide: false},
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		&remoteLevelProvider{strategy: s, isBuySide: true},
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+	s.composeStrategy = makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	)
+	return s, nil
+}
+
+// PruneExistingOffers impl
+func (s *remoteStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	return s.composeStrategy.PruneExistingOffers(buyingAOffers, sellingAOffers)
+}
+
+// PreUpdate impl
+func (s *remoteStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	// clear the levels of the previous cycle so we never place stale levels when the remote strategy does not respond
+	s.sellLevels = nil
+	s.buyLevels = nil
+
+	params, e := s.makeGetLevelsParams(maxAssetBase, maxAssetQuote, trustBase, trustQuote)
+	if e != nil {
+		return fmt.Errorf("could not gather the params for the remote strategy: %s", e)
+	}
+
+	var result remoteGetLevelsResult
+	e = s.client.call(remoteMethodGetLevels, params, &result)
+	if e != nil {
+		return fmt.Errorf("could not get levels from the remote strategy: %s", e)
+	}
+
+	sellLevels, e := convertRemoteLevels(result.SellLevels, false, s.orderConstraints)
+	if e != nil {
+		return fmt.Errorf("invalid sell levels returned by the remote strategy: %s", e)
+	}
+	buyLevels, e := convertRemoteLevels(result.BuyLevels, true, s.orderConstraints)
+	if e != nil {
+		return fmt.Errorf("invalid buy levels returned by the remote strategy: %s", e)
+	}
+	log.Printf("remote strategy returned %d sell levels and %d buy levels\n", len(sellLevels), len(buyLevels))
+	s.sellLevels = sellLevels
+	s.buyLevels = buyLevels
+
+	return s.composeStrategy.PreUpdate(maxAssetBase, maxAssetQuote, trustBase, trustQuote)
+}
+
+// UpdateWithOps impl
+func (s *remoteStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	return s.composeStrategy.UpdateWithOps(buyingAOffers, sellingAOffers)
+}
+
+// PostUpdate impl
+func (s *remoteStrategy) PostUpdate() error {
+	return s.composeStrategy.PostUpdate()
+}
+
+// GetFillHandlers impl
+func (s *remoteStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	return s.composeStrategy.GetFillHandlers()
+}
+
+// makeGetLevelsParams fetches the bot's offers and the orderbook to send to the remote strategy along with the balances
+func (s *remoteStrategy) makeGetLevelsParams(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) (*remoteGetLevelsParams, error) {
+	offers, e := s.exchangeShim.LoadOffersHack()
+	if e != nil {
+		return nil, fmt.Errorf("could not load offers: %s", e)
+	}
+	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, *s.assetBase, *s.assetQuote)
+
+	bids := []remoteLevel{}
+	asks := []remoteLevel{}
+	if s.orderbookDepth > 0 {
+		ob, e := s.exchangeShim.GetOrderBook(s.tradingPair, s.orderbookDepth)
+		if e != nil {
+			return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+		}
+		bids = convertOrdersToRemoteLevels(ob.Bids())
+		asks = convertOrdersToRemoteLevels(ob.Asks())
+	}
+
+	return &remoteGetLevelsParams{
+		Base:            string(s.tradingPair.Base),
+		Quote:           string(s.tradingPair.Quote),
+		TimestampMillis: time.Now().UnixNano() / int64(time.Millisecond),
+		Balances: remoteBalances{
+			Base:       maxAssetBase,
+			Quote:      maxAssetQuote,
+			TrustBase:  trustBase,
+			TrustQuote: trustQuote,
+		},
+		SellOffers: convertOffersToRemoteOffers(sellingAOffers, false),
+		BuyOffers:  convertOffersToRemoteOffers(buyingAOffers, true),
+		Bids:       bids,
+		Asks:       asks,
+	}, nil
+}
+
+// convertOffersToRemoteOffers converts offers to the units of the protocol, buy offers sell the quote asset so they are inverted
+func convertOffersToRemoteOffers(offers []hProtocol.Offer, isBuySide bool) []remoteOffer {
+	remoteOffers := []remoteOffer{}
+	for _, o := range offers {
+		price := utils.GetPrice(o)
+		amount := utils.AmountStringAsFloat(o.Amount)
+		if isBuySide {
+			amount = amount * price
+			price = 1 / price
+		}
+		remoteOffers = append(remoteOffers, remoteOffer{
+			ID:     o.ID,
+			Price:  price,
+			Amount: amount,
+		})
+	}
+	return remoteOffers
+}
+
+func convertOrdersToRemoteLevels(orders []model.Order) []remoteLevel {
+	levels := []remoteLevel{}
+	for _, o := range orders {
+		levels = append(levels, remoteLevel{
+			Price:  o.Price.AsFloat(),
+			Amount: o.Volume.AsFloat(),
+		})
+	}
+	return levels
+}
+
+// convertRemoteLevels converts the levels returned by the remote strategy to the levels used by a sellSideStrategy. The buy side
+// sellSideStrategy has the assets switched so its prices are inverted, while its amounts stay in units of the base asset because
+// it divides them by the price.
+func convertRemoteLevels(remoteLevels []remoteLevel, isBuySide bool, orderConstraints *model.OrderConstraints) ([]api.Level, error) {
+	levels := []api.Level{}
+	for i, l := range remoteLevels {
+		if l.Price <= 0 {
+			return nil, fmt.Errorf("price of level at index %d needs to be > 0 but was %f", i, l.Price)
+		}
+		if l.Amount <= 0 {
+			return nil, fmt.Errorf("amount of level at index %d needs to be > 0 but was %f", i, l.Amount)
+		}
+
+		price := l.Price
+		if isBuySide {
+			price = 1 / price
+		}
+		levels = append(levels, api.Level{
+			Price:  *model.NumberFromFloat(price, orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(l.Amount, orderConstraints.VolumePrecision),
+		})
+	}
+	return levels, nil
+}
+
+// GetLevels impl.
+func (p *remoteLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	levels := p.strategy.sellLevels
+	if p.isBuySide {
+		levels = p.strategy.buyLevels
+	}
+	if levels == nil {
+		return nil, fmt.Errorf("no levels were fetched from the remote strategy in this update cycle")
+	}
+	return levels, nil
+}
+
+// GetFillHandlers impl
+func (p *remoteLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
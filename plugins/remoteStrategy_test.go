@@ -0,0 +1,240 @@
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveRemoteStrategy listens on a unix socket and answers each request with the response made by respond, or never answers when
+// respond returns nil
+func serveRemoteStrategy(t *testing.T, respond func(request remoteRequest) *remoteResponse) (string, func()) {
+	dir, e := ioutil.TempDir("", "kelp_remote_strategy")
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	socketPath := filepath.Join(dir, "strategy.sock")
+	listener, e := net.Listen("unix", socketPath)
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+
+	go func() {
+		for {
+			conn, e := listener.Accept()
+			if e != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				line, e := bufio.NewReader(conn).ReadBytes('\n')
+				if e != nil {
+					return
+				}
+				var request remoteRequest
+				if json.Unmarshal(line, &request) != nil {
+					return
+				}
+				response := respond(request)
+				if response == nil {
+					// hold the connection open until the client gives up
+					time.Sleep(time.Second)
+					return
+				}
+				_ = json.NewEncoder(conn).Encode(response)
+			}(conn)
+		}
+	}()
+
+	return "unix://" + socketPath, func() {
+		listener.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func makeRemoteResult(t *testing.T, result interface{}) *json.RawMessage {
+	b, e := json.Marshal(result)
+	if !assert.NoError(t, e) {
+		t.FailNow()
+	}
+	raw := json.RawMessage(b)
+	return &raw
+}
+
+func TestMakeRemoteClient(t *testing.T) {
+	testCases := []struct {
+		address     string
+		timeout     time.Duration
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"unix:///tmp/strategy.sock", time.Second, "unix", "/tmp/strategy.sock", false},
+		{"tcp://127.0.0.1:9000", time.Second, "tcp", "127.0.0.1:9000", false},
+		{"udp://127.0.0.1:9000", time.Second, "", "", true},
+		{"/tmp/strategy.sock", time.Second, "", "", true},
+		{"unix://", time.Second, "", "", true},
+		{"tcp://127.0.0.1:9000", 0, "", "", true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.address, func(t *testing.T) {
+			c, e := makeRemoteClient(k.address, k.timeout)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNetwork, c.network)
+			assert.Equal(t, k.wantAddress, c.address)
+		})
+	}
+}
+
+func TestRemoteClientCall(t *testing.T) {
+	wantResult := remoteGetLevelsResult{
+		SellLevels: []remoteLevel{{Price: 0.11, Amount: 100}},
+		BuyLevels:  []remoteLevel{{Price: 0.09, Amount: 200}},
+	}
+	testCases := []struct {
+		name    string
+		respond func(t *testing.T, request remoteRequest) *remoteResponse
+		wantErr bool
+	}{
+		{
+			name: "result",
+			respond: func(t *testing.T, request remoteRequest) *remoteResponse {
+				assert.Equal(t, "2.0", request.JSONRPC)
+				assert.Equal(t, remoteMethodGetLevels, request.Method)
+				return &remoteResponse{JSONRPC: "2.0", ID: request.ID, Result: makeRemoteResult(t, wantResult)}
+			},
+			wantErr: false,
+		}, {
+			name: "error",
+			respond: func(t *testing.T, request remoteRequest) *remoteResponse {
+				return &remoteResponse{JSONRPC: "2.0", ID: request.ID, Error: &remoteError{Code: -32000, Message: "no price"}}
+			},
+			wantErr: true,
+		}, {
+			name: "mismatched id",
+			respond: func(t *testing.T, request remoteRequest) *remoteResponse {
+				return &remoteResponse{JSONRPC: "2.0", ID: request.ID + 1, Result: makeRemoteResult(t, wantResult)}
+			},
+			wantErr: true,
+		}, {
+			name: "no result",
+			respond: func(t *testing.T, request remoteRequest) *remoteResponse {
+				return &remoteResponse{JSONRPC: "2.0", ID: request.ID}
+			},
+			wantErr: true,
+		}, {
+			name: "timeout",
+			respond: func(t *testing.T, request remoteRequest) *remoteResponse {
+				return nil
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			address, cleanup := serveRemoteStrategy(t, func(request remoteRequest) *remoteResponse {
+				return k.respond(t, request)
+			})
+			defer cleanup()
+
+			c, e := makeRemoteClient(address, 200*time.Millisecond)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			var result remoteGetLevelsResult
+			start := time.Now()
+			e = c.call(remoteMethodGetLevels, remoteGetLevelsParams{Base: "XLM", Quote: "USD"}, &result)
+			if k.wantErr {
+				assert.Error(t, e)
+				assert.True(t, time.Since(start) < time.Second, "call took longer than the timeout")
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, wantResult, result)
+		})
+	}
+}
+
+func TestRemoteClientCallNotListening(t *testing.T) {
+	c, e := makeRemoteClient("unix:///tmp/kelp_remote_strategy_does_not_exist.sock", 200*time.Millisecond)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	var result remoteGetLevelsResult
+	assert.Error(t, c.call(remoteMethodGetLevels, remoteGetLevelsParams{}, &result))
+}
+
+func TestConvertRemoteLevels(t *testing.T) {
+	oc := model.MakeOrderConstraints(4, 2, 1.0)
+	testCases := []struct {
+		name       string
+		levels     []remoteLevel
+		isBuySide  bool
+		wantPrices []float64
+		wantAmount []float64
+		wantErr    bool
+	}{
+		{"sell side", []remoteLevel{{Price: 0.5, Amount: 10}, {Price: 0.6, Amount: 20}}, false, []float64{0.5, 0.6}, []float64{10, 20}, false},
+		{"buy side is inverted", []remoteLevel{{Price: 0.5, Amount: 10}, {Price: 0.4, Amount: 20}}, true, []float64{2.0, 2.5}, []float64{10, 20}, false},
+		{"no levels", []remoteLevel{}, false, []float64{}, []float64{}, false},
+		{"zero price", []remoteLevel{{Price: 0, Amount: 10}}, false, nil, nil, true},
+		{"negative amount", []remoteLevel{{Price: 0.5, Amount: -10}}, true, nil, nil, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			levels, e := convertRemoteLevels(k.levels, k.isBuySide, oc)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, len(k.wantPrices), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.Equal(t, k.wantPrices[i], l.Price.AsFloat())
+				assert.Equal(t, k.wantAmount[i], l.Amount.AsFloat())
+			}
+		})
+	}
+}
+
+func TestConvertOffersToRemoteOffers(t *testing.T) {
+	offers := []hProtocol.Offer{{
+		ID:     1,
+		Amount: "50.0000000",
+		Price:  "2.0000000",
+		PriceR: hProtocol.Price{N: 2, D: 1},
+	}}
+
+	sellOffers := convertOffersToRemoteOffers(offers, false)
+	assert.Equal(t, []remoteOffer{{ID: 1, Price: 2.0, Amount: 50.0}}, sellOffers)
+
+	// a buy offer sells 50 units of the quote asset at 2 units of the base asset each, i.e. buys 100 units of base at 0.5
+	buyOffers := convertOffersToRemoteOffers(offers, true)
+	assert.Equal(t, []remoteOffer{{ID: 1, Price: 0.5, Amount: 100.0}}, buyOffers)
+}
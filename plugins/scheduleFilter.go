@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// dayOfWeekCodes maps the two-letter day codes (same as DayOfWeekFilterConfig) to a time.Weekday
+var dayOfWeekCodes = map[string]time.Weekday{
+	"Su": time.Sunday,
+	"Mo": time.Monday,
+	"Tu": time.Tuesday,
+	"We": time.Wednesday,
+	"Th": time.Thursday,
+	"Fr": time.Friday,
+	"Sa": time.Saturday,
+}
+
+// ScheduleWindow is a UTC time window within a day, represented as minutes since midnight, where Start is inclusive and End is exclusive
+type ScheduleWindow struct {
+	Start int
+	End   int
+}
+
+// String is the stringer method
+func (w ScheduleWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+func (w ScheduleWindow) contains(minuteOfDay int) bool {
+	return minuteOfDay >= w.Start && minuteOfDay < w.End
+}
+
+// ScheduleFilterConfig contains the UTC windows, indexed by time.Weekday, during which offers can be created or updated
+type ScheduleFilterConfig struct {
+	Windows [7][]ScheduleWindow
+}
+
+// Validate ensures validity
+func (c *ScheduleFilterConfig) Validate() error {
+	hasWindow := false
+	for dow, windows := range c.Windows {
+		for _, w := range windows {
+			if w.Start < 0 || w.End > 24*60 || w.Start >= w.End {
+				return fmt.Errorf("invalid window '%s' on %s, start needs to be before end and both need to be within the day", w, time.Weekday(dow))
+			}
+			hasWindow = true
+		}
+	}
+
+	if !hasWindow {
+		return fmt.Errorf("need at least one window during which trading is allowed")
+	}
+	return nil
+}
+
+// String is the stringer method
+func (c *ScheduleFilterConfig) String() string {
+	dayStrings := []string{}
+	for dow, windows := range c.Windows {
+		if len(windows) == 0 {
+			continue
+		}
+		dayStrings = append(dayStrings, fmt.Sprintf("%s=%v", time.Weekday(dow), windows))
+	}
+	return fmt.Sprintf("ScheduleFilterConfig[%s]", strings.Join(dayStrings, ", "))
+}
+
+// isOpen returns true if the passed in time falls within one of the configured windows
+func (c *ScheduleFilterConfig) isOpen(t time.Time) bool {
+	utc := t.UTC()
+	minuteOfDay := utc.Hour()*60 + utc.Minute()
+	for _, w := range c.Windows[utc.Weekday()] {
+		if w.contains(minuteOfDay) {
+			return true
+		}
+	}
+	return false
+}
+
+type scheduleFilter struct {
+	name       string
+	config     *ScheduleFilterConfig
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+	nowFn      func() time.Time
+	fe         *FilterExplainer
+}
+
+// MakeFilterSchedule makes a submit filter that only allows offers to be placed during the configured UTC windows
+func MakeFilterSchedule(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, config *ScheduleFilterConfig, fe *FilterExplainer) (SubmitFilter, error) {
+	if e := config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &scheduleFilter{
+		name:       "scheduleFilter",
+		config:     config,
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+		nowFn:      time.Now,
+		fe:         fe,
+	}, nil
+}
+
+var _ SubmitFilter = &scheduleFilter{}
+
+func (f *scheduleFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	now := f.nowFn()
+	isOpen := f.config.isOpen(now)
+	log.Printf("scheduleFilter: now=%s, isOpen=%v (%s)\n", now.UTC().Format(time.RFC3339), isOpen, f.config)
+
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		// returning nil drops new ops and deletes existing offers when we are outside the allowed windows
+		if !isOpen {
+			return nil, nil
+		}
+		return op, nil
+	}
+	reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+		return fmt.Sprintf("%s %s UTC is outside the trading windows (%s)", now.UTC().Weekday(), now.UTC().Format("15:04"), f.config)
+	}
+	ops, e := filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn, reasonFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleFilterConfigIsOpen(t *testing.T) {
+	// weekdays between 08:00 and 16:00 UTC
+	weekdays := []ScheduleWindow{{Start: 8 * 60, End: 16 * 60}}
+	config := &ScheduleFilterConfig{Windows: [7][]ScheduleWindow{
+		nil, weekdays, weekdays, weekdays, weekdays, weekdays, nil,
+	}}
+
+	testCases := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{
+			name: "monday inside window",
+			now:  time.Date(2020, time.November, 2, 12, 0, 0, 0, time.UTC),
+			want: true,
+		}, {
+			name: "monday at start of window",
+			now:  time.Date(2020, time.November, 2, 8, 0, 0, 0, time.UTC),
+			want: true,
+		}, {
+			name: "monday at end of window",
+			now:  time.Date(2020, time.November, 2, 16, 0, 0, 0, time.UTC),
+			want: false,
+		}, {
+			name: "monday before window",
+			now:  time.Date(2020, time.November, 2, 7, 59, 59, 0, time.UTC),
+			want: false,
+		}, {
+			name: "saturday inside window hours",
+			now:  time.Date(2020, time.November, 7, 12, 0, 0, 0, time.UTC),
+			want: false,
+		}, {
+			name: "non-UTC time converted to UTC",
+			now:  time.Date(2020, time.November, 2, 7, 0, 0, 0, time.FixedZone("UTC-2", -2*60*60)),
+			want: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, config.isOpen(k.now))
+		})
+	}
+}
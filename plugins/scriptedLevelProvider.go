@@ -0,0 +1,292 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// scriptedFunctionName is the function that the script needs to define
+const scriptedFunctionName = "get_levels"
+
+// defaultScriptedMaxRecentFills is used when MAX_RECENT_FILLS is not set
+const defaultScriptedMaxRecentFills = 20
+
+// defaultScriptedMaxExecutionSteps is used when MAX_EXECUTION_STEPS is not set
+const defaultScriptedMaxExecutionSteps = 1000000
+
+// scriptedLevelsScript is a loaded Starlark script that defines the get_levels function
+type scriptedLevelsScript struct {
+	filename          string
+	fn                starlark.Callable
+	maxExecutionSteps uint64
+}
+
+// loadScriptedLevelsScript executes the script once to load the get_levels function, the globals of the script are frozen afterwards
+// so every call to get_levels only depends on its input. The script is read from the file when src is nil.
+func loadScriptedLevelsScript(filename string, src interface{}, maxExecutionSteps uint64) (*scriptedLevelsScript, error) {
+	if maxExecutionSteps == 0 {
+		maxExecutionSteps = defaultScriptedMaxExecutionSteps
+	}
+
+	thread := &starlark.Thread{
+		Name:  "load " + filename,
+		Print: scriptedPrint,
+	}
+	thread.SetMaxExecutionSteps(maxExecutionSteps)
+	predeclared := starlark.StringDict{
+		"math": starlarkmath.Module,
+	}
+	globals, e := starlark.ExecFile(thread, filename, src, predeclared)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute script '%s': %s", filename, scriptedErrorString(e))
+	}
+	globals.Freeze()
+
+	v, ok := globals[scriptedFunctionName]
+	if !ok {
+		return nil, fmt.Errorf("script '%s' needs to define the function '%s(ctx)'", filename, scriptedFunctionName)
+	}
+	fn, ok := v.(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("'%s' in script '%s' needs to be a function but was of type '%s'", scriptedFunctionName, filename, v.Type())
+	}
+
+	return &scriptedLevelsScript{
+		filename:          filename,
+		fn:                fn,
+		maxExecutionSteps: maxExecutionSteps,
+	}, nil
+}
+
+// call invokes get_levels with the ctx and returns the levels as (price, amount) pairs
+func (s *scriptedLevelsScript) call(ctx starlark.Value) ([][2]float64, error) {
+	thread := &starlark.Thread{
+		Name:  scriptedFunctionName,
+		Print: scriptedPrint,
+	}
+	thread.SetMaxExecutionSteps(s.maxExecutionSteps)
+
+	result, e := starlark.Call(thread, s.fn, starlark.Tuple{ctx}, nil)
+	if e != nil {
+		return nil, fmt.Errorf("error when calling '%s' in script '%s': %s", scriptedFunctionName, s.filename, scriptedErrorString(e))
+	}
+	return parseScriptedLevels(result)
+}
+
+// parseScriptedLevels converts the value returned by get_levels, which needs to be a list of dicts with the keys "price" and "amount"
+func parseScriptedLevels(result starlark.Value) ([][2]float64, error) {
+	iterable, ok := result.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("'%s' needs to return a list of levels but returned a value of type '%s'", scriptedFunctionName, result.Type())
+	}
+
+	levels := [][2]float64{}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for i := 0; iter.Next(&item); i++ {
+		d, ok := item.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("level at index %d needs to be a dict with the keys 'price' and 'amount' but was of type '%s'", i, item.Type())
+		}
+		price, e := scriptedDictFloat(d, "price")
+		if e != nil {
+			return nil, fmt.Errorf("level at index %d: %s", i, e)
+		}
+		amount, e := scriptedDictFloat(d, "amount")
+		if e != nil {
+			return nil, fmt.Errorf("level at index %d: %s", i, e)
+		}
+		levels = append(levels, [2]float64{price, amount})
+	}
+	return levels, nil
+}
+
+func scriptedDictFloat(d *starlark.Dict, key string) (float64, error) {
+	v, found, e := d.Get(starlark.String(key))
+	if e != nil {
+		return 0, fmt.Errorf("could not read key '%s': %s", key, e)
+	}
+	if !found {
+		return 0, fmt.Errorf("missing key '%s'", key)
+	}
+	f, ok := starlark.AsFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("value of key '%s' needs to be a number but was of type '%s'", key, v.Type())
+	}
+	return f, nil
+}
+
+func scriptedPrint(thread *starlark.Thread, msg string) {
+	log.Printf("script (%s): %s\n", thread.Name, msg)
+}
+
+// scriptedErrorString includes the starlark backtrace in the error when there is one
+func scriptedErrorString(e error) string {
+	if evalErr, ok := e.(*starlark.EvalError); ok {
+		return evalErr.Backtrace()
+	}
+	return e.Error()
+}
+
+// scriptedFills keeps the most recent fills so they can be passed to the script, it is shared by the level providers of both sides
+type scriptedFills struct {
+	maxFills int
+
+	// uninitialized
+	mutex  *sync.Mutex
+	trades []model.Trade
+}
+
+// ensure it implements the FillHandler interface
+var _ api.FillHandler = &scriptedFills{}
+
+// makeScriptedFills is a factory method
+func makeScriptedFills(maxFills int) *scriptedFills {
+	return &scriptedFills{
+		maxFills: maxFills,
+		mutex:    &sync.Mutex{},
+		trades:   []model.Trade{},
+	}
+}
+
+// HandleFill impl
+func (f *scriptedFills) HandleFill(trade model.Trade) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.trades = append(f.trades, trade)
+	if len(f.trades) > f.maxFills {
+		f.trades = f.trades[len(f.trades)-f.maxFills:]
+	}
+	return nil
+}
+
+// toStarlark returns the fills as a list of dicts, oldest first
+func (f *scriptedFills) toStarlark() *starlark.List {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	fills := []starlark.Value{}
+	for _, t := range f.trades {
+		fill := starlark.NewDict(4)
+		_ = fill.SetKey(starlark.String("action"), starlark.String(t.OrderAction.String()))
+		_ = fill.SetKey(starlark.String("price"), starlark.Float(t.Price.AsFloat()))
+		_ = fill.SetKey(starlark.String("amount"), starlark.Float(t.Volume.AsFloat()))
+		timestampMillis := int64(0)
+		if t.Timestamp != nil {
+			timestampMillis = t.Timestamp.AsInt64()
+		}
+		_ = fill.SetKey(starlark.String("timestamp_millis"), starlark.MakeInt64(timestampMillis))
+		fills = append(fills, fill)
+	}
+	return starlark.NewList(fills)
+}
+
+// scriptedPriceFeed is a price feed passed to the script by name
+type scriptedPriceFeed struct {
+	name string
+	feed api.PriceFeed
+}
+
+// scriptedLevelProvider provides the levels of one side of the orderbook by calling the get_levels function of a Starlark script
+type scriptedLevelProvider struct {
+	script           *scriptedLevelsScript
+	priceFeeds       []scriptedPriceFeed
+	fills            *scriptedFills
+	orderConstraints *model.OrderConstraints
+	isBuySide        bool
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &scriptedLevelProvider{}
+
+// makeScriptedLevelProvider is a factory method
+func makeScriptedLevelProvider(
+	script *scriptedLevelsScript,
+	priceFeeds []scriptedPriceFeed,
+	fills *scriptedFills,
+	orderConstraints *model.OrderConstraints,
+	isBuySide bool,
+) api.LevelProvider {
+	return &scriptedLevelProvider{
+		script:           script,
+		priceFeeds:       priceFeeds,
+		fills:            fills,
+		orderConstraints: orderConstraints,
+		isBuySide:        isBuySide,
+	}
+}
+
+// GetLevels impl.
+func (p *scriptedLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	side := "sell"
+	if p.isBuySide {
+		side = "buy"
+		// the buy side sellSideStrategy has the assets switched, the script always works with the assets of the trading pair
+		maxAssetBase, maxAssetQuote = maxAssetQuote, maxAssetBase
+	}
+
+	prices := starlark.NewDict(len(p.priceFeeds))
+	for _, pf := range p.priceFeeds {
+		price, e := pf.feed.GetPrice()
+		if e != nil {
+			return nil, fmt.Errorf("could not get price from the '%s' price feed: %s", pf.name, e)
+		}
+		_ = prices.SetKey(starlark.String(pf.name), starlark.Float(price))
+	}
+
+	ctx := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"side":            starlark.String(side),
+		"max_asset_base":  starlark.Float(maxAssetBase),
+		"max_asset_quote": starlark.Float(maxAssetQuote),
+		"prices":          prices,
+		"fills":           p.fills.toStarlark(),
+	})
+	scriptLevels, e := p.script.call(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	return convertScriptedLevels(scriptLevels, p.isBuySide, p.orderConstraints)
+}
+
+// convertScriptedLevels converts the (price, amount) pairs returned by the script, which are priced in units of the quote asset per unit
+// of the base asset on both sides, to the levels of a sellSideStrategy. The buy side has its prices inverted because its assets are switched.
+func convertScriptedLevels(scriptLevels [][2]float64, isBuySide bool, orderConstraints *model.OrderConstraints) ([]api.Level, error) {
+	levels := []api.Level{}
+	for i, l := range scriptLevels {
+		price, amount := l[0], l[1]
+		if price <= 0 {
+			return nil, fmt.Errorf("price of level at index %d needs to be > 0 but was %f", i, price)
+		}
+		if amount <= 0 {
+			return nil, fmt.Errorf("amount of level at index %d needs to be > 0 but was %f", i, amount)
+		}
+
+		if isBuySide {
+			price = 1 / price
+		}
+		levels = append(levels, api.Level{
+			Price:  *model.NumberFromFloat(price, orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(amount, orderConstraints.VolumePrecision),
+		})
+	}
+	return levels, nil
+}
+
+// GetFillHandlers impl
+func (p *scriptedLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	// the fills are shared by both sides so only the sell side registers the handler
+	if p.isBuySide {
+		return nil, nil
+	}
+	return []api.FillHandler{p.fills}, nil
+}
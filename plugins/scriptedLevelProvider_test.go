@@ -0,0 +1,164 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testScriptLevels = `
+def get_levels(ctx):
+    mid = ctx.prices["mid"]
+    levels = []
+    for i in range(3):
+        spread = 0.01 * (i + 1)
+        if ctx.side == "sell":
+            price = mid * (1 + spread)
+        else:
+            price = mid * (1 - spread)
+        levels.append({"price": price, "amount": ctx.max_asset_base * 0.1 + len(ctx.fills)})
+    return levels
+`
+
+func TestLoadScriptedLevelsScript(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{"valid", testScriptLevels, false},
+		{"syntax error", "def get_levels(ctx)\n    return []\n", true},
+		{"missing function", "def levels(ctx):\n    return []\n", true},
+		{"not a function", "get_levels = 5\n", true},
+		{"too many steps when loading", "x = [i for i in range(10000000)]\ndef get_levels(ctx):\n    return []\n", true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := loadScriptedLevelsScript("test.star", k.src, 100000)
+			if k.wantErr {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
+
+func TestScriptedLevelProvider(t *testing.T) {
+	script, e := loadScriptedLevelsScript("test.star", testScriptLevels, 0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	feed, e := newFixedFeed("0.5")
+	if !assert.NoError(t, e) {
+		return
+	}
+	priceFeeds := []scriptedPriceFeed{{name: "mid", feed: feed}}
+	fills := makeScriptedFills(2)
+	oc := model.MakeOrderConstraints(4, 2, 1.0)
+
+	sellProvider := makeScriptedLevelProvider(script, priceFeeds, fills, oc, false)
+	buyProvider := makeScriptedLevelProvider(script, priceFeeds, fills, oc, true)
+
+	levels, e := sellProvider.GetLevels(1000.0, 50.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 3, len(levels))
+	assert.Equal(t, 0.505, levels[0].Price.AsFloat())
+	assert.Equal(t, 0.515, levels[2].Price.AsFloat())
+	assert.Equal(t, 100.0, levels[0].Amount.AsFloat())
+
+	// the buy side gets the balances switched by the sellSideStrategy, and its levels are priced in the inverted price
+	levels, e = buyProvider.GetLevels(50.0, 1000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 3, len(levels))
+	assert.Equal(t, 2.0202, levels[0].Price.AsFloat())
+	assert.Equal(t, 100.0, levels[0].Amount.AsFloat())
+
+	// only the most recent fills are kept
+	for i := 0; i < 3; i++ {
+		_ = fills.HandleFill(model.Trade{Order: model.Order{
+			OrderAction: model.OrderActionSell,
+			Price:       model.NumberFromFloat(0.505, 4),
+			Volume:      model.NumberFromFloat(10, 2),
+			Timestamp:   model.MakeTimestamp(int64(i)),
+		}})
+	}
+	levels, e = sellProvider.GetLevels(1000.0, 50.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 102.0, levels[0].Amount.AsFloat())
+
+	handlers, e := sellProvider.GetFillHandlers()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1, len(handlers))
+	}
+	handlers, e = buyProvider.GetFillHandlers()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 0, len(handlers))
+	}
+}
+
+func TestScriptedLevelProviderErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+	}{
+		{"not a list", "def get_levels(ctx):\n    return 5\n"},
+		{"not a dict", "def get_levels(ctx):\n    return [5]\n"},
+		{"missing amount", "def get_levels(ctx):\n    return [{\"price\": 1.0}]\n"},
+		{"non numeric price", "def get_levels(ctx):\n    return [{\"price\": \"1.0\", \"amount\": 1.0}]\n"},
+		{"zero price", "def get_levels(ctx):\n    return [{\"price\": 0, \"amount\": 1.0}]\n"},
+		{"runtime error", "def get_levels(ctx):\n    return [{\"price\": 1 / 0, \"amount\": 1.0}]\n"},
+		{"too many steps", "def get_levels(ctx):\n    x = [i for i in range(10000000)]\n    return []\n"},
+		{"cannot modify globals", "levels = []\ndef get_levels(ctx):\n    levels.append({\"price\": 1.0, \"amount\": 1.0})\n    return levels\n"},
+	}
+
+	oc := model.MakeOrderConstraints(4, 2, 1.0)
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			script, e := loadScriptedLevelsScript("test.star", k.src, 100000)
+			if !assert.NoError(t, e) {
+				return
+			}
+			p := makeScriptedLevelProvider(script, []scriptedPriceFeed{}, makeScriptedFills(1), oc, false)
+
+			_, e = p.GetLevels(1000.0, 50.0)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestMakeScriptedPriceFeeds(t *testing.T) {
+	testCases := []struct {
+		name    string
+		configs []scriptedPriceFeedConfig
+		wantErr bool
+	}{
+		{"no feeds", []scriptedPriceFeedConfig{}, false},
+		{"fixed feeds", []scriptedPriceFeedConfig{{Name: "a", Type: "fixed", URL: "1.0"}, {Name: "b", Type: "fixed", URL: "2.0"}}, false},
+		{"missing name", []scriptedPriceFeedConfig{{Type: "fixed", URL: "1.0"}}, true},
+		{"duplicate name", []scriptedPriceFeedConfig{{Name: "a", Type: "fixed", URL: "1.0"}, {Name: "a", Type: "fixed", URL: "2.0"}}, true},
+		{"invalid feed", []scriptedPriceFeedConfig{{Name: "a", Type: "unknown", URL: "1.0"}}, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			feeds, e := makeScriptedPriceFeeds(k.configs)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, len(k.configs), len(feeds))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// scriptedPriceFeedConfig is a price feed listed in PRICE_FEEDS, passed to the script under its NAME
+type scriptedPriceFeedConfig struct {
+	Name string `valid:"-" toml:"NAME"`
+	Type string `valid:"-" toml:"TYPE"`
+	URL  string `valid:"-" toml:"URL"`
+}
+
+// scriptedConfig contains the configuration params for this strategy
+type scriptedConfig struct {
+	ScriptFile        string                    `valid:"-" toml:"SCRIPT_FILE"`
+	MaxExecutionSteps uint64                    `valid:"-" toml:"MAX_EXECUTION_STEPS"`
+	MaxRecentFills    int                       `valid:"-" toml:"MAX_RECENT_FILLS"`
+	PriceTolerance    float64                   `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance   float64                   `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	PriceFeeds        []scriptedPriceFeedConfig `valid:"-" toml:"PRICE_FEEDS"`
+}
+
+// String impl.
+func (c scriptedConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeScriptedPriceFeeds makes the price feeds listed in the config, the names need to be unique
+func makeScriptedPriceFeeds(configs []scriptedPriceFeedConfig) ([]scriptedPriceFeed, error) {
+	names := map[string]bool{}
+	priceFeeds := []scriptedPriceFeed{}
+	for i, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("NAME of the price feed at index %d needs to be set", i)
+		}
+		if _, ok := names[c.Name]; ok {
+			return nil, fmt.Errorf("NAME of the price feed at index %d needs to be unique but '%s' was already used", i, c.Name)
+		}
+		names[c.Name] = true
+
+		feed, e := MakePriceFeed(c.Type, c.URL)
+		if e != nil {
+			return nil, fmt.Errorf("could not make the '%s' price feed: %s", c.Name, e)
+		}
+		priceFeeds = append(priceFeeds, scriptedPriceFeed{
+			name: c.Name,
+			feed: feed,
+		})
+	}
+	return priceFeeds, nil
+}
+
+// makeScriptedStrategy is a factory method
+func makeScriptedStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *scriptedConfig,
+) (api.Strategy, error) {
+	if config.ScriptFile == "" {
+		return nil, fmt.Errorf("SCRIPT_FILE needs to be set")
+	}
+	if config.MaxRecentFills < 0 {
+		return nil, fmt.Errorf("MAX_RECENT_FILLS needs to be >= 0 but was %d", config.MaxRecentFills)
+	}
+	maxRecentFills := config.MaxRecentFills
+	if maxRecentFills == 0 {
+		maxRecentFills = defaultScriptedMaxRecentFills
+	}
+
+	script, e := loadScriptedLevelsScript(config.ScriptFile, nil, config.MaxExecutionSteps)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the scripted strategy because we could not load the script: %s", e)
+	}
+	priceFeeds, e := makeScriptedPriceFeeds(config.PriceFeeds)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the scripted strategy because we could not make the price feeds: %s", e)
+	}
+	fills := makeScriptedFills(maxRecentFills)
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		makeScriptedLevelProvider(script, priceFeeds, fills, orderConstraints, false),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		makeScriptedLevelProvider(script, priceFeeds, fills, orderConstraints, true),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
@@ -262,6 +262,30 @@ func (sdex *SDEX) _assetBalance(asset hProtocol.Asset) (*api.Balance, error) {
 	return nil, errors.New("could not find a balance for the asset passed in")
 }
 
+// GetSellingLiabilities returns the amount of the asset that is committed to the open offers of the trading account, this amount
+// cannot be sent out of the account until the offers are removed
+func (sdex *SDEX) GetSellingLiabilities(asset hProtocol.Asset) (float64, error) {
+	acctReq := horizonclient.AccountRequest{AccountID: sdex.TradingAccount}
+	account, err := sdex.API.AccountDetail(acctReq)
+	if err != nil {
+		return 0, fmt.Errorf("error: unable to load account to fetch selling liabilities: %s", err)
+	}
+
+	for _, balance := range account.Balances {
+		if utils.AssetsEqual(balance.Asset, asset) {
+			if balance.SellingLiabilities == "" {
+				return 0, nil
+			}
+			l, e := strconv.ParseFloat(balance.SellingLiabilities, 64)
+			if e != nil {
+				return 0, fmt.Errorf("error: cannot parse selling liabilities: %s", e)
+			}
+			return l, nil
+		}
+	}
+	return 0, errors.New("could not find a balance for the asset passed in")
+}
+
 // GetBalanceHack impl
 func (sdex *SDEX) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
 	b, e := sdex._assetBalance(asset)
@@ -365,10 +389,53 @@ func (sdex *SDEX) SubmitOps(ops []build.TransactionMutator, submitMode api.Submi
 	return sdex.submitOps(ops, asyncCallback, true)
 }
 
+// SubmitPaymentSynch sends a payment from the trading account to the destination synchronously and returns the tx hash.
+// The memo is attached as an ID memo if it is a number and as a text memo otherwise, no memo is attached if it is empty.
+func (sdex *SDEX) SubmitPaymentSynch(destination string, asset hProtocol.Asset, amount *model.Number, memo string) (string, error) {
+	if amount.AsFloat() <= 0 {
+		return "", fmt.Errorf("error: cannot make payment, invalid amount: %s", amount.AsString())
+	}
+
+	payment := txnbuild.Payment{
+		Destination: destination,
+		Amount:      strconv.FormatFloat(amount.AsFloat(), 'f', int(sdexOrderConstraints.VolumePrecision), 64),
+		Asset:       utils.Asset2Asset(asset),
+	}
+	if sdex.SourceAccount != sdex.TradingAccount {
+		payment.SourceAccount = &txnbuild.SimpleAccount{AccountID: sdex.TradingAccount}
+	}
+
+	var txMemo txnbuild.Memo
+	if memo != "" {
+		if memoID, e := strconv.ParseUint(memo, 10, 64); e == nil {
+			txMemo = txnbuild.MemoID(memoID)
+		} else {
+			txMemo = txnbuild.MemoText(memo)
+		}
+	}
+
+	var txHash string
+	var txErr error
+	e := sdex.submitOperations([]txnbuild.Operation{&payment}, txMemo, func(hash string, e error) {
+		txHash = hash
+		txErr = e
+	}, false)
+	if e != nil {
+		return "", e
+	}
+	if txErr != nil {
+		return "", fmt.Errorf("error submitting payment: %s", txErr)
+	}
+	return txHash, nil
+}
+
 // submitOps submits the passed in operations to the network in a single transaction. Asynchronous or not based on flag.
 func (sdex *SDEX) submitOps(opsOld []build.TransactionMutator, asyncCallback func(hash string, e error), asyncMode bool) error {
-	ops := api.ConvertTM2Operation(opsOld)
+	return sdex.submitOperations(api.ConvertTM2Operation(opsOld), nil, asyncCallback, asyncMode)
+}
 
+// submitOperations submits the passed in operations to the network in a single transaction with an optional memo. Asynchronous or not based on flag.
+func (sdex *SDEX) submitOperations(ops []txnbuild.Operation, memo txnbuild.Memo, asyncCallback func(hash string, e error), asyncMode bool) error {
 	// compute fee per operation
 	opFee, e := sdex.opFeeStroopsFn()
 	if e != nil {
@@ -391,6 +458,7 @@ func (sdex *SDEX) submitOps(opsOld []build.TransactionMutator, asyncCallback fun
 			// to obtain the sequence number for the transaction.
 			IncrementSequenceNum: true,
 			Operations:           ops,
+			Memo:                 memo,
 			Timebounds:           txnbuild.NewInfiniteTimeout(),
 		},
 	)
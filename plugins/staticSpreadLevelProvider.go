@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
@@ -15,6 +16,82 @@ type StaticLevel struct {
 	AMOUNT float64 `valid:"-" json:"amount"`
 }
 
+// amount curves supported by LevelGenerator
+const (
+	LevelAmountCurveLinear      = "linear"
+	LevelAmountCurveGeometric   = "geometric"
+	LevelAmountCurveExponential = "exponential"
+)
+
+// LevelGenerator generates the StaticLevels of a ladder so deep books do not need to list every level by hand.
+// The spread of level i (starting at 0) is START_SPREAD + i * SPREAD_STEP and its amount depends on the AMOUNT_CURVE:
+//   - linear: START_AMOUNT + i * AMOUNT_STEP
+//   - geometric: START_AMOUNT * AMOUNT_FACTOR^i
+//   - exponential: START_AMOUNT * e^(AMOUNT_FACTOR * i), where AMOUNT_FACTOR is the continuous growth rate and can be negative
+type LevelGenerator struct {
+	NumLevels    int     `valid:"-" toml:"NUM_LEVELS" json:"num_levels"`
+	StartSpread  float64 `valid:"-" toml:"START_SPREAD" json:"start_spread"`
+	SpreadStep   float64 `valid:"-" toml:"SPREAD_STEP" json:"spread_step"`
+	AmountCurve  string  `valid:"-" toml:"AMOUNT_CURVE" json:"amount_curve"`
+	StartAmount  float64 `valid:"-" toml:"START_AMOUNT" json:"start_amount"`
+	AmountStep   float64 `valid:"-" toml:"AMOUNT_STEP" json:"amount_step"`
+	AmountFactor float64 `valid:"-" toml:"AMOUNT_FACTOR" json:"amount_factor"`
+}
+
+// Levels expands the generator into the StaticLevels it describes
+func (g *LevelGenerator) Levels() ([]StaticLevel, error) {
+	if g.NumLevels <= 0 {
+		return nil, fmt.Errorf("NUM_LEVELS needs to be > 0 but was %d", g.NumLevels)
+	}
+	if g.StartSpread <= 0 {
+		return nil, fmt.Errorf("START_SPREAD needs to be > 0 but was %f", g.StartSpread)
+	}
+	if g.SpreadStep < 0 {
+		return nil, fmt.Errorf("SPREAD_STEP needs to be >= 0 but was %f", g.SpreadStep)
+	}
+	if g.StartAmount <= 0 {
+		return nil, fmt.Errorf("START_AMOUNT needs to be > 0 but was %f", g.StartAmount)
+	}
+
+	var amountFn func(i int) float64
+	switch g.AmountCurve {
+	case LevelAmountCurveLinear:
+		amountFn = func(i int) float64 {
+			return g.StartAmount + float64(i)*g.AmountStep
+		}
+	case LevelAmountCurveGeometric:
+		if g.AmountFactor <= 0 {
+			return nil, fmt.Errorf("AMOUNT_FACTOR needs to be > 0 for the %s AMOUNT_CURVE but was %f", g.AmountCurve, g.AmountFactor)
+		}
+		amountFn = func(i int) float64 {
+			return g.StartAmount * math.Pow(g.AmountFactor, float64(i))
+		}
+	case LevelAmountCurveExponential:
+		amountFn = func(i int) float64 {
+			return g.StartAmount * math.Exp(g.AmountFactor*float64(i))
+		}
+	default:
+		return nil, fmt.Errorf("invalid AMOUNT_CURVE '%s', needs to be one of '%s', '%s' or '%s'", g.AmountCurve, LevelAmountCurveLinear, LevelAmountCurveGeometric, LevelAmountCurveExponential)
+	}
+
+	levels := []StaticLevel{}
+	for i := 0; i < g.NumLevels; i++ {
+		spread := g.StartSpread + float64(i)*g.SpreadStep
+		if spread >= 1.0 {
+			return nil, fmt.Errorf("spread of level %d needs to be < 1.0 but was %f, reduce NUM_LEVELS, START_SPREAD or SPREAD_STEP", i, spread)
+		}
+		amount := amountFn(i)
+		if amount <= 0 {
+			return nil, fmt.Errorf("amount of level %d needs to be > 0 but was %f, check START_AMOUNT and AMOUNT_STEP", i, amount)
+		}
+		levels = append(levels, StaticLevel{
+			SPREAD: spread,
+			AMOUNT: amount,
+		})
+	}
+	return levels, nil
+}
+
 // how much to offset your rates by. Can use percent and offset together.
 // A positive value indicates that your base asset (ASSET_A) has a higher rate than the rate received from your price feed
 // A negative value indicates that your base asset (ASSET_A) has a lower rate than the rate received from your price feed
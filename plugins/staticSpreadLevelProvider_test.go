@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelGeneratorLevels(t *testing.T) {
+	testCases := []struct {
+		name       string
+		generator  LevelGenerator
+		wantLevels []StaticLevel
+		wantErr    bool
+	}{
+		{
+			name:      "linear",
+			generator: LevelGenerator{NumLevels: 3, StartSpread: 0.001, SpreadStep: 0.0005, AmountCurve: "linear", StartAmount: 100, AmountStep: 50},
+			wantLevels: []StaticLevel{
+				{SPREAD: 0.001, AMOUNT: 100},
+				{SPREAD: 0.0015, AMOUNT: 150},
+				{SPREAD: 0.002, AMOUNT: 200},
+			},
+		}, {
+			name:      "linear with decreasing amounts",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "linear", StartAmount: 100, AmountStep: -40},
+			wantLevels: []StaticLevel{
+				{SPREAD: 0.01, AMOUNT: 100},
+				{SPREAD: 0.02, AMOUNT: 60},
+			},
+		}, {
+			name:      "exponential",
+			generator: LevelGenerator{NumLevels: 3, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "exponential", StartAmount: 100, AmountFactor: math.Log(1.5)},
+			wantLevels: []StaticLevel{
+				{SPREAD: 0.01, AMOUNT: 100},
+				{SPREAD: 0.02, AMOUNT: 150},
+				{SPREAD: 0.03, AMOUNT: 225},
+			},
+		}, {
+			name:      "exponential with decreasing amounts",
+			generator: LevelGenerator{NumLevels: 3, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "exponential", StartAmount: 100, AmountFactor: -0.5},
+			wantLevels: []StaticLevel{
+				{SPREAD: 0.01, AMOUNT: 100},
+				{SPREAD: 0.02, AMOUNT: 60.6530660},
+				{SPREAD: 0.03, AMOUNT: 36.7879441},
+			},
+		}, {
+			name:      "geometric",
+			generator: LevelGenerator{NumLevels: 4, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "geometric", StartAmount: 10, AmountFactor: 2},
+			wantLevels: []StaticLevel{
+				{SPREAD: 0.01, AMOUNT: 10},
+				{SPREAD: 0.02, AMOUNT: 20},
+				{SPREAD: 0.03, AMOUNT: 40},
+				{SPREAD: 0.04, AMOUNT: 80},
+			},
+		}, {
+			name:      "no levels",
+			generator: LevelGenerator{NumLevels: 0, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "linear", StartAmount: 10},
+			wantErr:   true,
+		}, {
+			name:      "zero start spread",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0, SpreadStep: 0.01, AmountCurve: "linear", StartAmount: 10},
+			wantErr:   true,
+		}, {
+			name:      "negative spread step",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0.01, SpreadStep: -0.01, AmountCurve: "linear", StartAmount: 10},
+			wantErr:   true,
+		}, {
+			name:      "spread reaches 1",
+			generator: LevelGenerator{NumLevels: 20, StartSpread: 0.1, SpreadStep: 0.1, AmountCurve: "linear", StartAmount: 10},
+			wantErr:   true,
+		}, {
+			name:      "zero start amount",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "linear", StartAmount: 0},
+			wantErr:   true,
+		}, {
+			name:      "linear amount reaches 0",
+			generator: LevelGenerator{NumLevels: 3, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "linear", StartAmount: 100, AmountStep: -50},
+			wantErr:   true,
+		}, {
+			name:      "geometric without factor",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "geometric", StartAmount: 10},
+			wantErr:   true,
+		}, {
+			name:      "unknown curve",
+			generator: LevelGenerator{NumLevels: 2, StartSpread: 0.01, SpreadStep: 0.01, AmountCurve: "quadratic", StartAmount: 10},
+			wantErr:   true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			levels, e := k.generator.Levels()
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, len(k.wantLevels), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.InDelta(t, k.wantLevels[i].SPREAD, l.SPREAD, 0.0000001)
+				assert.InDelta(t, k.wantLevels[i].AMOUNT, l.AMOUNT, 0.0000001)
+			}
+		})
+	}
+}
@@ -29,6 +29,10 @@ type SubmitFilter interface {
 // the existing offer. i.e. if filterFn returns a nil newOp value then we will "drop" that operation or delete the existing offer.
 type filterFn func(op *txnbuild.ManageSellOffer) (newOp *txnbuild.ManageSellOffer, e error)
 
+// filterReasonFn gives the filter-specific reason for the result of the filterFn that was just run on the before op, after is nil when
+// the filterFn rejected the op. It is only called when the filterFn changed or rejected the op so it can use state captured by the filterFn.
+type filterReasonFn func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string
+
 type filterCounter struct {
 	idx         int
 	kept        uint8
@@ -111,12 +115,14 @@ Solution: Refactor the code inside the for loop to clearly allow for reuse of fu
 */
 func filterOps(
 	filterName string,
+	fe *FilterExplainer,
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
 	sellingOffers []hProtocol.Offer,
 	buyingOffers []hProtocol.Offer,
 	ops []txnbuild.Operation,
 	fn filterFn,
+	reasonFn filterReasonFn,
 ) ([]txnbuild.Operation, error) {
 	ignoreOfferIds := ignoreOfferIDs(ops)
 	offerMap := makeOfferMap(append(sellingOffers, buyingOffers...))
@@ -169,6 +175,7 @@ func filterOps(
 			if e != nil {
 				return nil, fmt.Errorf("error while running inner filter function: %s", e)
 			}
+			explainInnerFilterFnResult(filterName, fe, reasonFn, opToTransform, newOpToPrepend, newOpToAppend, incrementValues)
 			if newOpToAppend != nil {
 				filteredOps = append(filteredOps, newOpToAppend)
 			}
@@ -194,6 +201,9 @@ func filterOps(
 
 	// convert all remaining buy and sell offers to delete offers
 	filteredOps, e := handleRemainingOffers(
+		filterName,
+		fe,
+		reasonFn,
 		&sellCounter,
 		sellingOffers,
 		ignoreOfferIds,
@@ -204,6 +214,9 @@ func filterOps(
 		return nil, fmt.Errorf("error when handling remaining sell offers: %s", e)
 	}
 	filteredOps, e = handleRemainingOffers(
+		filterName,
+		fe,
+		reasonFn,
 		&buyCounter,
 		buyingOffers,
 		ignoreOfferIds,
@@ -338,7 +351,47 @@ func runInnerFilterFn(
 	}
 }
 
+// explainInnerFilterFnResult records why the filter changed the op, ops that the filter left as-is are not recorded
+func explainInnerFilterFnResult(
+	filterName string,
+	fe *FilterExplainer,
+	reasonFn filterReasonFn,
+	opToTransform *txnbuild.ManageSellOffer,
+	newOpToPrepend *txnbuild.ManageSellOffer,
+	newOpToAppend *txnbuild.ManageSellOffer,
+	incrementValues filterCounter,
+) {
+	if fe == nil {
+		return
+	}
+
+	if incrementValues.dropped > 0 {
+		reason := "rejected by filter"
+		if reasonFn != nil {
+			reason = reasonFn(opToTransform, nil)
+		}
+		if newOpToPrepend != nil {
+			fe.recordFilterExplanation(filterName, filterActionDeleted, opToTransform, newOpToPrepend, reason+" so the existing offer is deleted")
+		} else {
+			fe.recordFilterExplanation(filterName, filterActionDropped, opToTransform, nil, reason+" so the new operation is dropped")
+		}
+		return
+	}
+
+	// the op may differ from the existing offer because of the strategy, so we only compare against the input to the filter
+	if newOpToAppend != nil && (newOpToAppend.Price != opToTransform.Price || newOpToAppend.Amount != opToTransform.Amount) {
+		reason := explainTransformation(opToTransform, newOpToAppend)
+		if reasonFn != nil {
+			reason = fmt.Sprintf("%s: %s", reasonFn(opToTransform, newOpToAppend), reason)
+		}
+		fe.recordFilterExplanation(filterName, filterActionTransformed, opToTransform, newOpToAppend, reason)
+	}
+}
+
 func handleRemainingOffers(
+	filterName string,
+	fe *FilterExplainer,
+	reasonFn filterReasonFn,
 	offerCounter *filterCounter,
 	offers []hProtocol.Offer,
 	ignoreOfferIds map[int64]bool,
@@ -363,6 +416,7 @@ func handleRemainingOffers(
 		if e != nil {
 			return nil, fmt.Errorf("error while running inner filter function for remaining offers: %s", e)
 		}
+		explainInnerFilterFnResult(filterName, fe, reasonFn, originalOfferAsOp, newOpToPrepend, newOpToAppend, incrementValues)
 		if newOpToAppend != nil {
 			filteredOps = append(filteredOps, newOpToAppend)
 		}
@@ -56,6 +56,7 @@ type volumeFilter struct {
 	quoteAsset             hProtocol.Asset
 	config                 *VolumeFilterConfig
 	dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	fe                     *FilterExplainer
 }
 
 // makeFilterVolume makes a submit filter that limits orders placed based on the daily volume traded
@@ -68,6 +69,7 @@ func makeFilterVolume(
 	quoteAsset hProtocol.Asset,
 	db *sql.DB,
 	config *VolumeFilterConfig,
+	fe *FilterExplainer,
 ) (SubmitFilter, error) {
 	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
 	baseAssetString, e := assetDisplayFn(tradingPair.Base)
@@ -99,6 +101,7 @@ func makeFilterVolume(
 		quoteAsset:             quoteAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: dailyVolumeByDateQuery,
+		fe:                     fe,
 	}, nil
 }
 
@@ -167,7 +170,21 @@ func (f *volumeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol
 		}
 		return volumeFilterFn(dailyOTB, dailyTBB, op, f.baseAsset, f.quoteAsset, limitParameters)
 	}
-	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	reasonFn := func(before *txnbuild.ManageSellOffer, after *txnbuild.ManageSellOffer) string {
+		otb, _, limit, _ := extractAllCaps(dailyOTB, dailyTBB, limitParameters{
+			baseAssetCapInBaseUnits:  f.config.BaseAssetCapInBaseUnits,
+			baseAssetCapInQuoteUnits: f.config.BaseAssetCapInQuoteUnits,
+		})
+		unitsAsset := f.baseAsset
+		if f.config.BaseAssetCapInQuoteUnits != nil {
+			unitsAsset = f.quoteAsset
+		}
+		if after == nil {
+			return fmt.Sprintf("daily %s volume cap of %.8f %s is reached (%.8f traded today, mode=%s)", f.config.action, limit, utils.Asset2String(unitsAsset), otb, f.config.mode)
+		}
+		return fmt.Sprintf("amount reduced to fit within the daily %s volume cap of %.8f %s (%.8f traded today)", f.config.action, limit, utils.Asset2String(unitsAsset), otb)
+	}
+	ops, e = filterOps(f.name, f.fe, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn, reasonFn)
 	if e != nil {
 		return nil, fmt.Errorf("could not apply filter: %s", e)
 	}
@@ -138,6 +138,7 @@ func TestMakeFilterVolume(t *testing.T) {
 							utils.NativeAsset,
 							&sql.DB{},
 							config,
+							nil,
 						)
 
 						if !assert.Nil(t, e) {
@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryRebalanceLastTransferTime fetches the time of the most recent transfer of an asset that was submitted by the rebalancer
+const sqlQueryRebalanceLastTransferTime = "SELECT date_utc FROM rebalance_transfers WHERE exchange_name = $1 AND asset = $2 AND status = $3 ORDER BY date_utc DESC LIMIT 1"
+
+// RebalanceLastTransferTime is a query that fetches the time of the last transfer of an asset submitted by the rebalancer (in either direction)
+type RebalanceLastTransferTime struct {
+	db           *sql.DB
+	sqlQuery     string
+	exchangeName string
+}
+
+var _ api.Query = &RebalanceLastTransferTime{}
+
+// MakeRebalanceLastTransferTime makes the RebalanceLastTransferTime query
+func MakeRebalanceLastTransferTime(db *sql.DB, exchangeName string) (*RebalanceLastTransferTime, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the rebalancer config file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &RebalanceLastTransferTime{
+		db:           db,
+		sqlQuery:     sqlQueryRebalanceLastTransferTime,
+		exchangeName: exchangeName,
+	}, nil
+}
+
+// Name impl.
+func (q *RebalanceLastTransferTime) Name() string {
+	return "RebalanceLastTransferTime"
+}
+
+// QueryRow impl. returns a *time.Time in UTC, which is nil when the asset was never transferred
+func (q *RebalanceLastTransferTime) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 arg (asset string), but got args %v", args)
+	} else if _, ok := args[0].(string); !ok {
+		return nil, fmt.Errorf("input arg[0] needs to be of type 'string', but was of type '%T'", args[0])
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.exchangeName, args[0], RebalanceTransferStatusSubmitted)
+	var lastTransferTime time.Time
+	e := row.Scan(&lastTransferTime)
+	if e == sql.ErrNoRows {
+		return (*time.Time)(nil), nil
+	}
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from RebalanceLastTransferTime query: %s", e)
+	}
+
+	// date_utc is stored without a time zone so we set the location to UTC explicitly
+	t := time.Date(lastTransferTime.Year(), lastTransferTime.Month(), lastTransferTime.Day(),
+		lastTransferTime.Hour(), lastTransferTime.Minute(), lastTransferTime.Second(), lastTransferTime.Nanosecond(), time.UTC)
+	return &t, nil
+}
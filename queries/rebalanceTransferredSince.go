@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryRebalanceTransferredSince sums the amount of an asset that was submitted for transfer by the rebalancer after a given time
+const sqlQueryRebalanceTransferredSince = "SELECT COALESCE(SUM(amount), 0) FROM rebalance_transfers WHERE exchange_name = $1 AND asset = $2 AND status = $3 AND date_utc >= $4"
+
+// RebalanceTransferStatusSubmitted is the status of a transfer in the rebalance_transfers table that was submitted successfully
+const RebalanceTransferStatusSubmitted = "submitted"
+
+// RebalanceTransferredSince is a query that fetches the total amount of an asset transferred by the rebalancer (in either direction) since a given time
+type RebalanceTransferredSince struct {
+	db           *sql.DB
+	sqlQuery     string
+	exchangeName string
+}
+
+var _ api.Query = &RebalanceTransferredSince{}
+
+// MakeRebalanceTransferredSince makes the RebalanceTransferredSince query
+func MakeRebalanceTransferredSince(db *sql.DB, exchangeName string) (*RebalanceTransferredSince, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the rebalancer config file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &RebalanceTransferredSince{
+		db:           db,
+		sqlQuery:     sqlQueryRebalanceTransferredSince,
+		exchangeName: exchangeName,
+	}, nil
+}
+
+// Name impl.
+func (q *RebalanceTransferredSince) Name() string {
+	return "RebalanceTransferredSince"
+}
+
+// QueryRow impl.
+func (q *RebalanceTransferredSince) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (asset string, since time.Time), but got args %v", args)
+	} else if _, ok := args[0].(string); !ok {
+		return nil, fmt.Errorf("input arg[0] needs to be of type 'string', but was of type '%T'", args[0])
+	} else if _, ok := args[1].(time.Time); !ok {
+		return nil, fmt.Errorf("input arg[1] needs to be of type 'time.Time', but was of type '%T'", args[1])
+	}
+
+	sinceUTC := args[1].(time.Time).UTC().Format(postgresdb.TimestampFormatString)
+	row := q.db.QueryRow(q.sqlQuery, q.exchangeName, args[0], RebalanceTransferStatusSubmitted, sinceUTC)
+	var total float64
+	e := row.Scan(&total)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from RebalanceTransferredSince query: %s", e)
+	}
+	return total, nil
+}
@@ -0,0 +1,210 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryStrategyMirrorHedgesAllMarkets left joins the trades of all the mirror strategy markets since a given time with their strategy_mirror_trade_triggers,
+// trades that were absorbed into the base surplus have no trigger. A trade has more than one trigger when the unfilled part of an ioc offset was offset
+// again, those triggers have the txid of the trade followed by ':unfilled:' and the orderID of the ioc order.
+const sqlQueryStrategyMirrorHedgesAllMarkets = "SELECT p.market_id, p.txid, p.date_utc, p.action, p.counter_price, p.base_volume, p.fee, t.backing_market_id, t.backing_order_id, t.backing_base_volume, t.backing_counter_cost, t.backing_fee FROM trades p LEFT JOIN strategy_mirror_trade_triggers t ON t.market_id = p.market_id AND split_part(t.txid, ':unfilled:', 1) = p.txid WHERE p.date_utc >= $1 AND p.market_id IN (SELECT market_id FROM strategy_mirror_base_surplus UNION SELECT market_id FROM strategy_mirror_trade_triggers) ORDER BY p.date_utc, p.market_id, p.txid, t.txid"
+
+// sqlQueryStrategyMirrorHedgesForMarket left joins the trades of a mirror strategy market since a given time with their strategy_mirror_trade_triggers,
+// see sqlQueryStrategyMirrorHedgesAllMarkets
+const sqlQueryStrategyMirrorHedgesForMarket = "SELECT p.market_id, p.txid, p.date_utc, p.action, p.counter_price, p.base_volume, p.fee, t.backing_market_id, t.backing_order_id, t.backing_base_volume, t.backing_counter_cost, t.backing_fee FROM trades p LEFT JOIN strategy_mirror_trade_triggers t ON t.market_id = p.market_id AND split_part(t.txid, ':unfilled:', 1) = p.txid WHERE p.date_utc >= $1 AND p.market_id = $2 ORDER BY p.date_utc, p.market_id, p.txid, t.txid"
+
+// MirrorHedge is a trade on the primary exchange along with the fills of the orders that offset it on the backing exchange.
+// The backing fields only include the trade's share of the fills, since a backing order can also offset the surplus of earlier trades.
+// The backing IDs are empty when the trade was absorbed into the base surplus and the backing volumes are nil until fills have been tracked.
+type MirrorHedge struct {
+	MarketID           string
+	TxID               string
+	DateUTC            time.Time
+	Action             string // action of the trade on the primary exchange, the backing order has the reverse action
+	Price              float64
+	BaseVolume         float64
+	Fee                float64
+	BackingMarketID    string // comma-separated when the trade was offset on more than one backing market
+	BackingOrderID     string // comma-separated when the trade was offset with more than one backing order
+	BackingBaseVolume  *float64
+	BackingCounterCost *float64
+	BackingFee         *float64
+}
+
+// addBackingOrder adds the fills of a backing order that offset the trade, only counting the share of the fills that is not more than
+// the volume of the trade that is not hedged yet, the rest of the fills offset the surplus of earlier trades
+func (h *MirrorHedge) addBackingOrder(marketID string, orderID string, baseVolume sql.NullFloat64, counterCost sql.NullFloat64, fee sql.NullFloat64) {
+	h.BackingMarketID = appendID(h.BackingMarketID, marketID)
+	h.BackingOrderID = appendID(h.BackingOrderID, orderID)
+	if !baseVolume.Valid || !counterCost.Valid || baseVolume.Float64 <= 0 {
+		return
+	}
+
+	hedged := 0.0
+	if h.BackingBaseVolume != nil {
+		hedged = *h.BackingBaseVolume
+	}
+	share := 1.0
+	if remaining := h.BaseVolume - hedged; baseVolume.Float64 > remaining {
+		share = math.Max(remaining, 0) / baseVolume.Float64
+	}
+
+	h.BackingBaseVolume = addFloat(h.BackingBaseVolume, baseVolume.Float64*share)
+	h.BackingCounterCost = addFloat(h.BackingCounterCost, counterCost.Float64*share)
+	if fee.Valid {
+		h.BackingFee = addFloat(h.BackingFee, fee.Float64*share)
+	}
+}
+
+func appendID(ids string, id string) string {
+	if ids == "" {
+		return id
+	}
+	for _, existing := range strings.Split(ids, ",") {
+		if existing == id {
+			return ids
+		}
+	}
+	return ids + "," + id
+}
+
+func addFloat(sum *float64, f float64) *float64 {
+	v := f
+	if sum != nil {
+		v += *sum
+	}
+	return &v
+}
+
+// IsHedged returns true once the backing order has fills
+func (h MirrorHedge) IsHedged() bool {
+	return h.BackingBaseVolume != nil && h.BackingCounterCost != nil && *h.BackingBaseVolume > 0
+}
+
+// HedgePrice is the average fill price of the backing order
+func (h MirrorHedge) HedgePrice() (float64, bool) {
+	if !h.IsHedged() {
+		return 0, false
+	}
+	return *h.BackingCounterCost / *h.BackingBaseVolume, true
+}
+
+// Slippage is the fraction of the primary trade price by which the hedge price is worse than the primary trade price, a negative value means
+// the hedge filled at a better price which is the spread captured by the mirror strategy
+func (h MirrorHedge) Slippage() (float64, bool) {
+	hedgePrice, ok := h.HedgePrice()
+	if !ok || h.Price == 0 {
+		return 0, false
+	}
+
+	if h.Action == DailyVolumeActionSell.String() {
+		// we sold on the primary exchange and bought the hedge on the backing exchange
+		return (hedgePrice - h.Price) / h.Price, true
+	}
+	// we bought on the primary exchange and sold the hedge on the backing exchange
+	return (h.Price - hedgePrice) / h.Price, true
+}
+
+// RealizedSpread is the spread captured in quote units on the hedged volume after the fees of both trades, assuming fees are in quote units
+func (h MirrorHedge) RealizedSpread() (float64, bool) {
+	hedgePrice, ok := h.HedgePrice()
+	if !ok {
+		return 0, false
+	}
+
+	priceDiff := hedgePrice - h.Price
+	if h.Action == DailyVolumeActionSell.String() {
+		priceDiff = h.Price - hedgePrice
+	}
+	backingFee := 0.0
+	if h.BackingFee != nil {
+		backingFee = *h.BackingFee
+	}
+	return priceDiff*(*h.BackingBaseVolume) - h.Fee - backingFee, true
+}
+
+// StrategyMirrorHedges is a query that fetches the trades offset by the mirror strategy along with their hedges
+type StrategyMirrorHedges struct {
+	db       *sql.DB
+	sqlQuery string
+	marketID string
+}
+
+var _ api.Query = &StrategyMirrorHedges{}
+
+// MakeStrategyMirrorHedges makes the StrategyMirrorHedges query, an empty marketID fetches the hedges of all markets
+func MakeStrategyMirrorHedges(db *sql.DB, marketID string) (*StrategyMirrorHedges, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	sqlQuery := sqlQueryStrategyMirrorHedgesAllMarkets
+	if marketID != "" {
+		sqlQuery = sqlQueryStrategyMirrorHedgesForMarket
+	}
+	return &StrategyMirrorHedges{
+		db:       db,
+		sqlQuery: sqlQuery,
+		marketID: marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *StrategyMirrorHedges) Name() string {
+	return "StrategyMirrorHedges"
+}
+
+// QueryRow impl.
+func (q *StrategyMirrorHedges) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 args (since time.Time), but got args %v", args)
+	} else if _, ok := args[0].(time.Time); !ok {
+		return nil, fmt.Errorf("input arg[0] needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+
+	sinceUTC := args[0].(time.Time).UTC().Format(postgresdb.TimestampFormatString)
+	queryArgs := []interface{}{sinceUTC}
+	if q.marketID != "" {
+		queryArgs = append(queryArgs, q.marketID)
+	}
+	rows, e := q.db.Query(q.sqlQuery, queryArgs...)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute StrategyMirrorHedges query: %s", e)
+	}
+	defer rows.Close()
+
+	// rows of the same trade are next to each other, one for each of its trade triggers
+	hedges := []MirrorHedge{}
+	for rows.Next() {
+		var h MirrorHedge
+		var backingMarketID, backingOrderID sql.NullString
+		var backingBaseVolume, backingCounterCost, backingFee sql.NullFloat64
+		e = rows.Scan(&h.MarketID, &h.TxID, &h.DateUTC, &h.Action, &h.Price, &h.BaseVolume, &h.Fee, &backingMarketID, &backingOrderID, &backingBaseVolume, &backingCounterCost, &backingFee)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from StrategyMirrorHedges query: %s", e)
+		}
+
+		last := len(hedges) - 1
+		if last < 0 || hedges[last].MarketID != h.MarketID || hedges[last].TxID != h.TxID {
+			hedges = append(hedges, h)
+			last++
+		}
+		if backingOrderID.Valid {
+			hedges[last].addBackingOrder(backingMarketID.String, backingOrderID.String, backingBaseVolume, backingCounterCost, backingFee)
+		}
+	}
+	e = rows.Err()
+	if e != nil {
+		return nil, fmt.Errorf("error while iterating over StrategyMirrorHedges rows: %s", e)
+	}
+	return hedges, nil
+}
@@ -0,0 +1,160 @@
+package queries
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestMirrorHedgeCalculations(t *testing.T) {
+	testCases := []struct {
+		name               string
+		hedge              MirrorHedge
+		wantHedged         bool
+		wantHedgePrice     float64
+		wantSlippage       float64
+		wantRealizedSpread float64
+	}{
+		{
+			name: "sell hedged at a better price",
+			hedge: MirrorHedge{
+				Action:             "sell",
+				Price:              0.102,
+				BaseVolume:         100,
+				Fee:                0,
+				BackingBaseVolume:  floatPtr(100),
+				BackingCounterCost: floatPtr(10),
+				BackingFee:         floatPtr(0.01),
+			},
+			wantHedged:         true,
+			wantHedgePrice:     0.1,
+			wantSlippage:       -0.0196078,
+			wantRealizedSpread: 0.19,
+		},
+		{
+			name: "buy hedged at a worse price",
+			hedge: MirrorHedge{
+				Action:             "buy",
+				Price:              0.1,
+				BaseVolume:         50,
+				Fee:                0.005,
+				BackingBaseVolume:  floatPtr(50),
+				BackingCounterCost: floatPtr(4.95),
+				BackingFee:         nil,
+			},
+			wantHedged:         true,
+			wantHedgePrice:     0.099,
+			wantSlippage:       0.01,
+			wantRealizedSpread: -0.055,
+		},
+		{
+			name: "not hedged yet",
+			hedge: MirrorHedge{
+				Action:     "sell",
+				Price:      0.1,
+				BaseVolume: 10,
+			},
+			wantHedged: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.wantHedged, k.hedge.IsHedged())
+
+			hedgePrice, ok := k.hedge.HedgePrice()
+			slippage, okSlippage := k.hedge.Slippage()
+			realizedSpread, okSpread := k.hedge.RealizedSpread()
+			assert.Equal(t, k.wantHedged, ok)
+			assert.Equal(t, k.wantHedged, okSlippage)
+			assert.Equal(t, k.wantHedged, okSpread)
+			if !k.wantHedged {
+				return
+			}
+			assert.InDelta(t, k.wantHedgePrice, hedgePrice, 0.0000001)
+			assert.InDelta(t, k.wantSlippage, slippage, 0.0000001)
+			assert.InDelta(t, k.wantRealizedSpread, realizedSpread, 0.0000001)
+		})
+	}
+}
+
+func TestMirrorHedgeAddBackingOrder(t *testing.T) {
+	type backingOrder struct {
+		orderID     string
+		baseVolume  sql.NullFloat64
+		counterCost sql.NullFloat64
+		fee         sql.NullFloat64
+	}
+	filled := func(baseVolume float64, counterCost float64, fee float64) backingOrder {
+		return backingOrder{"", sql.NullFloat64{Float64: baseVolume, Valid: true}, sql.NullFloat64{Float64: counterCost, Valid: true}, sql.NullFloat64{Float64: fee, Valid: true}}
+	}
+	withID := func(orderID string, o backingOrder) backingOrder {
+		o.orderID = orderID
+		return o
+	}
+
+	testCases := []struct {
+		name            string
+		orders          []backingOrder
+		wantOrderID     string
+		wantBaseVolume  *float64
+		wantCounterCost *float64
+		wantFee         *float64
+	}{
+		{
+			name:            "backing order for the trade only",
+			orders:          []backingOrder{withID("o1", filled(10, 1, 0.01))},
+			wantOrderID:     "o1",
+			wantBaseVolume:  floatPtr(10),
+			wantCounterCost: floatPtr(1),
+			wantFee:         floatPtr(0.01),
+		}, {
+			name:            "backing order also offset the surplus of earlier trades",
+			orders:          []backingOrder{withID("o1", filled(40, 4, 0.04))},
+			wantOrderID:     "o1",
+			wantBaseVolume:  floatPtr(10),
+			wantCounterCost: floatPtr(1),
+			wantFee:         floatPtr(0.01),
+		}, {
+			name:            "unfilled part of an ioc order offset again",
+			orders:          []backingOrder{withID("o1", filled(6, 0.6, 0)), withID("o2", filled(8, 0.8, 0))},
+			wantOrderID:     "o1,o2",
+			wantBaseVolume:  floatPtr(10),
+			wantCounterCost: floatPtr(1),
+			wantFee:         floatPtr(0),
+		}, {
+			name:        "fills not tracked yet",
+			orders:      []backingOrder{{orderID: "o1"}},
+			wantOrderID: "o1",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			h := MirrorHedge{BaseVolume: 10}
+			for _, o := range k.orders {
+				h.addBackingOrder("m1", o.orderID, o.baseVolume, o.counterCost, o.fee)
+			}
+			assert.Equal(t, "m1", h.BackingMarketID)
+			assert.Equal(t, k.wantOrderID, h.BackingOrderID)
+			assertFloatPtrInDelta(t, k.wantBaseVolume, h.BackingBaseVolume)
+			assertFloatPtrInDelta(t, k.wantCounterCost, h.BackingCounterCost)
+			assertFloatPtrInDelta(t, k.wantFee, h.BackingFee)
+		})
+	}
+}
+
+func assertFloatPtrInDelta(t *testing.T, want *float64, got *float64) {
+	if want == nil {
+		assert.Nil(t, got)
+		return
+	}
+	if assert.NotNil(t, got) {
+		assert.InDelta(t, *want, *got, 0.0000001)
+	}
+}
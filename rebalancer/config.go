@@ -0,0 +1,129 @@
+package rebalancer
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// AssetConfig represents the rebalancing configuration for a single asset held both on SDEX and on the backing exchange
+type AssetConfig struct {
+	SdexAssetCode          string  `valid:"-" toml:"SDEX_ASSET_CODE"`
+	SdexAssetIssuer        string  `valid:"-" toml:"SDEX_ASSET_ISSUER"` // empty for XLM
+	ExchangeAsset          string  `valid:"-" toml:"EXCHANGE_ASSET"`
+	TargetSdexRatio        float64 `valid:"-" toml:"TARGET_SDEX_RATIO"`         // fraction of the total holdings of this asset that we want to keep on SDEX
+	Tolerance              float64 `valid:"-" toml:"TOLERANCE"`                 // rebalance only when the SDEX ratio is further than this from the target
+	MinTransferAmount      float64 `valid:"-" toml:"MIN_TRANSFER_AMOUNT"`       // smaller transfers are skipped
+	MaxTransferAmount      float64 `valid:"-" toml:"MAX_TRANSFER_AMOUNT"`       // larger transfers are capped to this amount
+	MaxDailyTransferAmount float64 `valid:"-" toml:"MAX_DAILY_TRANSFER_AMOUNT"` // 0 means there is no daily limit, needs POSTGRES_DB when set
+	WithdrawTag            string  `valid:"-" toml:"WITHDRAW_TAG"`              // optional tag or memo sent with withdrawals from the exchange
+}
+
+// String impl.
+func (c AssetConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// Config represents the configuration params for the rebalancer
+type Config struct {
+	SourceSecretSeed    string                   `valid:"-" toml:"SOURCE_SECRET_SEED"`
+	TradingSecretSeed   string                   `valid:"-" toml:"TRADING_SECRET_SEED"`
+	HorizonURL          string                   `valid:"-" toml:"HORIZON_URL"`
+	TickIntervalSeconds int32                    `valid:"-" toml:"TICK_INTERVAL_SECONDS"`
+	CooldownMinutes     int32                    `valid:"-" toml:"COOLDOWN_MINUTES"` // minimum time between transfers of the same asset, so funds in transit are not moved twice
+	Exchange            string                   `valid:"-" toml:"EXCHANGE"`
+	ExchangeAPIKeys     toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
+	ExchangeParams      toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
+	ExchangeHeaders     toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
+	PostgresDbConfig    *postgresdb.Config       `valid:"-" toml:"POSTGRES_DB"`
+	Assets              []AssetConfig            `valid:"-" toml:"ASSET"`
+
+	TradingAccount *string
+	SourceAccount  *string // can be nil
+}
+
+// String impl.
+func (c Config) String() string {
+	return utils.StructString(c, 0, map[string]func(interface{}) interface{}{
+		"SOURCE_SECRET_SEED":  utils.SecretKey2PublicKey,
+		"TRADING_SECRET_SEED": utils.SecretKey2PublicKey,
+		"EXCHANGE_API_KEYS":   utils.Hide,
+		"EXCHANGE_PARAMS":     utils.Hide,
+		"EXCHANGE_HEADERS":    utils.Hide,
+	})
+}
+
+// Init initializes this config
+func (c *Config) Init() error {
+	var e error
+	c.TradingAccount, e = utils.ParseSecret(c.TradingSecretSeed)
+	if e != nil {
+		return e
+	}
+	// trading account should never be nil
+	if c.TradingAccount == nil {
+		return fmt.Errorf("no trading account specified")
+	}
+
+	c.SourceAccount, e = utils.ParseSecret(c.SourceSecretSeed)
+	if e != nil {
+		return e
+	}
+
+	if c.TickIntervalSeconds <= 0 {
+		return fmt.Errorf("TICK_INTERVAL_SECONDS needs to be greater than 0")
+	}
+	if c.CooldownMinutes < 0 {
+		return fmt.Errorf("COOLDOWN_MINUTES cannot be negative")
+	}
+	if c.Exchange == "" {
+		return fmt.Errorf("EXCHANGE needs to be specified")
+	}
+	if len(c.Assets) == 0 {
+		return fmt.Errorf("need at least one [[ASSET]] to rebalance")
+	}
+
+	for i, a := range c.Assets {
+		e = a.validate(c.PostgresDbConfig != nil)
+		if e != nil {
+			return fmt.Errorf("invalid [[ASSET]] at index %d (SDEX_ASSET_CODE=%s): %s", i, a.SdexAssetCode, e)
+		}
+	}
+	return nil
+}
+
+func (c AssetConfig) validate(hasDb bool) error {
+	if c.SdexAssetCode == "" {
+		return fmt.Errorf("SDEX_ASSET_CODE needs to be specified")
+	}
+	if _, e := utils.ParseAsset(c.SdexAssetCode, c.SdexAssetIssuer); e != nil {
+		return fmt.Errorf("invalid SDEX asset: %s", e)
+	}
+	if c.ExchangeAsset == "" {
+		return fmt.Errorf("EXCHANGE_ASSET needs to be specified")
+	}
+	if c.TargetSdexRatio <= 0 || c.TargetSdexRatio >= 1 {
+		return fmt.Errorf("TARGET_SDEX_RATIO needs to be greater than 0 and less than 1")
+	}
+	if c.Tolerance <= 0 || c.Tolerance >= 1 {
+		return fmt.Errorf("TOLERANCE needs to be greater than 0 and less than 1")
+	}
+	if c.MinTransferAmount < 0 {
+		return fmt.Errorf("MIN_TRANSFER_AMOUNT cannot be negative")
+	}
+	if c.MaxTransferAmount <= 0 {
+		return fmt.Errorf("MAX_TRANSFER_AMOUNT needs to be greater than 0")
+	}
+	if c.MinTransferAmount > c.MaxTransferAmount {
+		return fmt.Errorf("MIN_TRANSFER_AMOUNT cannot be greater than MAX_TRANSFER_AMOUNT")
+	}
+	if c.MaxDailyTransferAmount < 0 {
+		return fmt.Errorf("MAX_DAILY_TRANSFER_AMOUNT cannot be negative")
+	}
+	if c.MaxDailyTransferAmount > 0 && !hasDb {
+		return fmt.Errorf("MAX_DAILY_TRANSFER_AMOUNT needs POSTGRES_DB to be set so we can track the amount transferred")
+	}
+	return nil
+}
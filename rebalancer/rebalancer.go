@@ -0,0 +1,347 @@
+package rebalancer
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// directions of a transfer
+const (
+	DirectionSdexToExchange = "sdex_to_exchange"
+	DirectionExchangeToSdex = "exchange_to_sdex"
+)
+
+// status values used in the rebalance_transfers table
+const (
+	transferStatusDryRun    = "dry_run"
+	transferStatusSubmitted = queries.RebalanceTransferStatusSubmitted
+	transferStatusFailed    = "failed"
+)
+
+// transfer is a planned movement of funds between SDEX and the backing exchange
+type transfer struct {
+	direction string
+	amount    float64
+}
+
+// String is the stringer method
+func (t transfer) String() string {
+	return fmt.Sprintf("transfer[direction=%s, amount=%.7f]", t.direction, t.amount)
+}
+
+// Rebalancer moves funds between the trading account on SDEX and a backing exchange to keep the holdings of each asset close to the target ratio
+type Rebalancer struct {
+	sdex                  *plugins.SDEX
+	exchange              api.Exchange
+	exchangeName          string
+	tradingAccount        string
+	assets                []AssetConfig
+	tickInterval          time.Duration
+	cooldown              time.Duration
+	dryRun                bool
+	db                    *sql.DB                            // can be nil
+	transferredSinceQuery *queries.RebalanceTransferredSince // nil when db is nil
+	lastTransferQuery     *queries.RebalanceLastTransferTime // nil when db is nil
+
+	// initialized runtime vars
+	lastTransferTimes map[string]time.Time // only used for the cooldown when there is no db
+}
+
+// MakeRebalancer is a factory method
+func MakeRebalancer(
+	sdex *plugins.SDEX,
+	exchange api.Exchange,
+	exchangeName string,
+	tradingAccount string,
+	assets []AssetConfig,
+	tickIntervalSeconds int32,
+	cooldownMinutes int32,
+	dryRun bool,
+	db *sql.DB,
+) (*Rebalancer, error) {
+	var transferredSinceQuery *queries.RebalanceTransferredSince
+	var lastTransferQuery *queries.RebalanceLastTransferTime
+	if db != nil {
+		var e error
+		transferredSinceQuery, e = queries.MakeRebalanceTransferredSince(db, exchangeName)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make the RebalanceTransferredSince query: %s", e)
+		}
+		lastTransferQuery, e = queries.MakeRebalanceLastTransferTime(db, exchangeName)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make the RebalanceLastTransferTime query: %s", e)
+		}
+	}
+
+	if _, ok := exchange.(api.WithdrawTagAPI); !ok {
+		for _, a := range assets {
+			if a.WithdrawTag != "" {
+				return nil, fmt.Errorf("WITHDRAW_TAG is set for asset %s but exchange '%s' cannot send a tag with withdrawals", a.ExchangeAsset, exchangeName)
+			}
+		}
+	}
+
+	return &Rebalancer{
+		sdex:                  sdex,
+		exchange:              exchange,
+		exchangeName:          exchangeName,
+		tradingAccount:        tradingAccount,
+		assets:                assets,
+		tickInterval:          time.Duration(tickIntervalSeconds) * time.Second,
+		cooldown:              time.Duration(cooldownMinutes) * time.Minute,
+		dryRun:                dryRun,
+		db:                    db,
+		transferredSinceQuery: transferredSinceQuery,
+		lastTransferQuery:     lastTransferQuery,
+		lastTransferTimes:     map[string]time.Time{},
+	}, nil
+}
+
+// StartService starts the rebalancer service and runs until an unexpected error
+func (r *Rebalancer) StartService() {
+	for {
+		r.RunOnce()
+		log.Printf("sleeping for %s\n", r.tickInterval)
+		time.Sleep(r.tickInterval)
+	}
+}
+
+// RunOnce checks every asset once and makes any transfers needed, errors for an asset are logged and do not stop the other assets
+func (r *Rebalancer) RunOnce() {
+	for _, a := range r.assets {
+		e := r.rebalanceAsset(a)
+		if e != nil {
+			log.Printf("error rebalancing asset %s: %s\n", a.SdexAssetCode, e)
+		}
+	}
+}
+
+func (r *Rebalancer) rebalanceAsset(a AssetConfig) error {
+	sdexAsset, e := utils.ParseAsset(a.SdexAssetCode, a.SdexAssetIssuer)
+	if e != nil {
+		return fmt.Errorf("invalid SDEX asset: %s", e)
+	}
+	exchangeAsset, e := r.exchange.GetAssetConverter().FromString(a.ExchangeAsset)
+	if e != nil {
+		return fmt.Errorf("invalid exchange asset: %s", e)
+	}
+
+	lastTransferTime, e := r.lastTransferTime(a.ExchangeAsset)
+	if e != nil {
+		return fmt.Errorf("could not fetch the time of the last transfer: %s", e)
+	}
+	if lastTransferTime != nil && time.Since(*lastTransferTime) < r.cooldown {
+		log.Printf("asset %s: skipping, last transfer was at %s which is within the cooldown of %s\n", a.ExchangeAsset, lastTransferTime.UTC().Format(time.RFC3339), r.cooldown)
+		return nil
+	}
+
+	sdexBalance, e := r.sdex.GetBalanceHack(*sdexAsset)
+	if e != nil {
+		return fmt.Errorf("could not fetch SDEX balance: %s", e)
+	}
+	sellingLiabilities, e := r.sdex.GetSellingLiabilities(*sdexAsset)
+	if e != nil {
+		return fmt.Errorf("could not fetch SDEX selling liabilities: %s", e)
+	}
+	// the reserve and the amount committed to our open offers cannot be sent out of the account
+	sdexAvailable := math.Max(0, sdexBalance.Balance-sdexBalance.Reserve-sellingLiabilities)
+
+	exchangeBalances, e := r.exchange.GetAccountBalances([]interface{}{exchangeAsset})
+	if e != nil {
+		return fmt.Errorf("could not fetch exchange balance: %s", e)
+	}
+	exchangeBalance, ok := exchangeBalances[exchangeAsset]
+	if !ok {
+		return fmt.Errorf("exchange did not return a balance for asset %s", a.ExchangeAsset)
+	}
+	exchangeAvailable := exchangeBalance.AsFloat()
+
+	var remainingDaily *float64
+	if a.MaxDailyTransferAmount > 0 {
+		transferred, e := r.transferredSinceQuery.QueryRow(a.ExchangeAsset, time.Now().Add(-24*time.Hour))
+		if e != nil {
+			return fmt.Errorf("could not fetch the amount transferred in the last 24 hours: %s", e)
+		}
+		remaining := math.Max(0, a.MaxDailyTransferAmount-transferred.(float64))
+		remainingDaily = &remaining
+	}
+
+	t, reason := computeTransfer(a, sdexAvailable, exchangeAvailable, remainingDaily)
+	log.Printf("asset %s: sdexAvailable=%.7f, exchangeAvailable=%.7f, %s\n", a.ExchangeAsset, sdexAvailable, exchangeAvailable, reason)
+	if t == nil {
+		return nil
+	}
+	return r.executeTransfer(a, *sdexAsset, exchangeAsset, *t)
+}
+
+// lastTransferTime returns the time of the last submitted transfer of the asset, or nil if there was none. This is read from the
+// rebalance_transfers table so the cooldown holds across restarts, the in-memory times are only used when there is no db.
+func (r *Rebalancer) lastTransferTime(exchangeAsset string) (*time.Time, error) {
+	if r.lastTransferQuery == nil {
+		if t, ok := r.lastTransferTimes[exchangeAsset]; ok {
+			return &t, nil
+		}
+		return nil, nil
+	}
+
+	result, e := r.lastTransferQuery.QueryRow(exchangeAsset)
+	if e != nil {
+		return nil, e
+	}
+	return result.(*time.Time), nil
+}
+
+// computeTransfer returns the transfer needed to bring the SDEX ratio back to the target, or nil if no transfer should be made, along with the reason
+func computeTransfer(a AssetConfig, sdexAvailable float64, exchangeAvailable float64, remainingDaily *float64) (*transfer, string) {
+	total := sdexAvailable + exchangeAvailable
+	if total <= 0 {
+		return nil, "no transfer needed since there is no available balance on either venue"
+	}
+
+	sdexRatio := sdexAvailable / total
+	if math.Abs(sdexRatio-a.TargetSdexRatio) <= a.Tolerance {
+		return nil, fmt.Sprintf("no transfer needed since sdexRatio=%.4f is within the tolerance of %.4f from the target of %.4f", sdexRatio, a.Tolerance, a.TargetSdexRatio)
+	}
+
+	targetSdex := total * a.TargetSdexRatio
+	t := &transfer{
+		direction: DirectionSdexToExchange,
+		amount:    sdexAvailable - targetSdex,
+	}
+	if sdexRatio < a.TargetSdexRatio {
+		t = &transfer{
+			direction: DirectionExchangeToSdex,
+			amount:    targetSdex - sdexAvailable,
+		}
+	}
+
+	reasons := []string{fmt.Sprintf("sdexRatio=%.4f is outside the tolerance of %.4f from the target of %.4f", sdexRatio, a.Tolerance, a.TargetSdexRatio)}
+	if t.amount > a.MaxTransferAmount {
+		reasons = append(reasons, fmt.Sprintf("amount %.7f capped by MAX_TRANSFER_AMOUNT", t.amount))
+		t.amount = a.MaxTransferAmount
+	}
+	if remainingDaily != nil && t.amount > *remainingDaily {
+		reasons = append(reasons, fmt.Sprintf("amount %.7f capped by the remaining daily limit", t.amount))
+		t.amount = *remainingDaily
+	}
+	if t.amount <= 0 || t.amount < a.MinTransferAmount {
+		reasons = append(reasons, fmt.Sprintf("skipping since amount %.7f is less than MIN_TRANSFER_AMOUNT (%.7f)", t.amount, a.MinTransferAmount))
+		return nil, strings.Join(reasons, ", ")
+	}
+
+	reasons = append(reasons, t.String())
+	return t, strings.Join(reasons, ", ")
+}
+
+func (r *Rebalancer) executeTransfer(a AssetConfig, sdexAsset hProtocol.Asset, exchangeAsset model.Asset, t transfer) error {
+	amount := model.NumberFromFloat(t.amount, utils.SdexPrecision)
+	if r.dryRun {
+		log.Printf("asset %s: dry run, not making %s\n", a.ExchangeAsset, t)
+		return r.audit(a, t, transferStatusDryRun, "", nil)
+	}
+
+	var reference string
+	var e error
+	if t.direction == DirectionSdexToExchange {
+		reference, e = r.depositToExchange(sdexAsset, exchangeAsset, amount)
+	} else {
+		reference, e = r.withdrawFromExchange(exchangeAsset, amount, a.WithdrawTag)
+	}
+	if e != nil {
+		e = fmt.Errorf("unable to make %s: %s", t, e)
+		if auditErr := r.audit(a, t, transferStatusFailed, "", e); auditErr != nil {
+			log.Printf("asset %s: %s\n", a.ExchangeAsset, auditErr)
+		}
+		return e
+	}
+
+	log.Printf("asset %s: submitted %s with reference '%s'\n", a.ExchangeAsset, t, reference)
+	if r.db == nil {
+		r.lastTransferTimes[a.ExchangeAsset] = time.Now()
+	}
+	return r.audit(a, t, transferStatusSubmitted, reference, nil)
+}
+
+// depositToExchange sends a Stellar payment to the deposit address given by the exchange and returns the tx hash
+func (r *Rebalancer) depositToExchange(sdexAsset hProtocol.Asset, exchangeAsset model.Asset, amount *model.Number) (string, error) {
+	deposit, e := r.exchange.PrepareDeposit(exchangeAsset, amount)
+	if e != nil {
+		return "", fmt.Errorf("could not prepare deposit: %s", e)
+	}
+	if deposit == nil || deposit.Address == "" {
+		return "", fmt.Errorf("exchange did not return a deposit address")
+	}
+	if deposit.ExpireTs != 0 && deposit.ExpireTs < time.Now().Unix() {
+		return "", fmt.Errorf("deposit address '%s' expired at unix time %d", deposit.Address, deposit.ExpireTs)
+	}
+
+	return r.sdex.SubmitPaymentSynch(deposit.Address, sdexAsset, amount, deposit.Memo)
+}
+
+// withdrawFromExchange withdraws funds from the exchange to the trading account with the tag if it is not empty and returns the withdrawal ID
+func (r *Rebalancer) withdrawFromExchange(exchangeAsset model.Asset, amount *model.Number, tag string) (string, error) {
+	withdrawInfo, e := r.exchange.GetWithdrawInfo(exchangeAsset, amount, r.tradingAccount)
+	if e != nil {
+		return "", fmt.Errorf("could not get withdraw info: %s", e)
+	}
+	if withdrawInfo == nil {
+		return "", fmt.Errorf("exchange did not return withdraw info")
+	}
+	log.Printf("withdrawing %s %s, expecting to receive %s\n", amount.AsString(), exchangeAsset, withdrawInfo.AmountToReceive.AsString())
+
+	var withdrawal *api.WithdrawFunds
+	if tag != "" {
+		// MakeRebalancer checks that the exchange can send tags when a tag is configured
+		withdrawal, e = r.exchange.(api.WithdrawTagAPI).WithdrawFundsWithTag(exchangeAsset, amount, r.tradingAccount, tag)
+	} else {
+		withdrawal, e = r.exchange.WithdrawFunds(exchangeAsset, amount, r.tradingAccount)
+	}
+	if e != nil {
+		return "", fmt.Errorf("could not withdraw funds: %s", e)
+	}
+	if withdrawal == nil {
+		return "", fmt.Errorf("exchange did not return a withdrawal")
+	}
+	return withdrawal.WithdrawalID, nil
+}
+
+// audit writes the transfer to the rebalance_transfers table, it is a no-op if there is no db
+func (r *Rebalancer) audit(a AssetConfig, t transfer, status string, reference string, transferErr error) error {
+	if r.db == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	errorString := ""
+	if transferErr != nil {
+		errorString = transferErr.Error()
+	}
+	_, e := r.db.Exec(kelpdb.SqlRebalanceTransfersInsert,
+		fmt.Sprintf("%s_%s_%d", r.exchangeName, a.ExchangeAsset, now.UnixNano()),
+		now.Format(postgresdb.TimestampFormatString),
+		r.exchangeName,
+		a.ExchangeAsset,
+		t.direction,
+		t.amount,
+		status,
+		reference,
+		errorString,
+	)
+	if e != nil {
+		return fmt.Errorf("could not write transfer to the rebalance_transfers table: %s", e)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rebalancer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeTransfer(t *testing.T) {
+	assetConfig := AssetConfig{
+		SdexAssetCode:     "XLM",
+		ExchangeAsset:     "XLM",
+		TargetSdexRatio:   0.5,
+		Tolerance:         0.1,
+		MinTransferAmount: 100.0,
+		MaxTransferAmount: 2000.0,
+	}
+	remaining := func(v float64) *float64 { return &v }
+
+	testCases := []struct {
+		name              string
+		sdexAvailable     float64
+		exchangeAvailable float64
+		remainingDaily    *float64
+		wantTransfer      *transfer
+	}{
+		{
+			name:              "no balance",
+			sdexAvailable:     0.0,
+			exchangeAvailable: 0.0,
+			wantTransfer:      nil,
+		}, {
+			name:              "within tolerance",
+			sdexAvailable:     550.0,
+			exchangeAvailable: 450.0,
+			wantTransfer:      nil,
+		}, {
+			name:              "too much on sdex",
+			sdexAvailable:     800.0,
+			exchangeAvailable: 200.0,
+			wantTransfer:      &transfer{direction: DirectionSdexToExchange, amount: 300.0},
+		}, {
+			name:              "too much on exchange",
+			sdexAvailable:     100.0,
+			exchangeAvailable: 900.0,
+			wantTransfer:      &transfer{direction: DirectionExchangeToSdex, amount: 400.0},
+		}, {
+			name:              "capped by max transfer amount",
+			sdexAvailable:     0.0,
+			exchangeAvailable: 10000.0,
+			wantTransfer:      &transfer{direction: DirectionExchangeToSdex, amount: 2000.0},
+		}, {
+			name:              "capped by remaining daily limit",
+			sdexAvailable:     0.0,
+			exchangeAvailable: 10000.0,
+			remainingDaily:    remaining(500.0),
+			wantTransfer:      &transfer{direction: DirectionExchangeToSdex, amount: 500.0},
+		}, {
+			name:              "daily limit used up",
+			sdexAvailable:     0.0,
+			exchangeAvailable: 10000.0,
+			remainingDaily:    remaining(0.0),
+			wantTransfer:      nil,
+		}, {
+			name:              "below min transfer amount",
+			sdexAvailable:     20.0,
+			exchangeAvailable: 140.0,
+			wantTransfer:      nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, reason := computeTransfer(assetConfig, k.sdexAvailable, k.exchangeAvailable, k.remainingDaily)
+			assert.NotEqual(t, "", reason)
+			if k.wantTransfer == nil {
+				assert.Nil(t, actual)
+				return
+			}
+
+			if !assert.NotNil(t, actual) {
+				return
+			}
+			assert.Equal(t, k.wantTransfer.direction, actual.direction)
+			assert.InDelta(t, k.wantTransfer.amount, actual.amount, 0.0000001)
+		})
+	}
+}
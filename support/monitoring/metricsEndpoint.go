@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,13 +15,13 @@ import (
 // or GoogleAuth which uses a Google account for authorization.
 type metricsEndpoint struct {
 	path      string
-	metrics   Metrics
+	metrics   json.Marshaler
 	authLevel networking.AuthLevel
 }
 
 // MakeMetricsEndpoint creates an Endpoint for the monitoring server with the desired auth level.
-// The endpoint's response is always a JSON dump of the provided metrics.
-func MakeMetricsEndpoint(path string, metrics Metrics, authLevel networking.AuthLevel) (networking.Endpoint, error) {
+// The endpoint's response is always a JSON dump of the provided metrics, which can be any json.Marshaler such as Metrics.
+func MakeMetricsEndpoint(path string, metrics json.Marshaler, authLevel networking.AuthLevel) (networking.Endpoint, error) {
 	if !strings.HasPrefix(path, "/") {
 		return nil, fmt.Errorf("endpoint path must begin with /")
 	}
@@ -0,0 +1,302 @@
+package networking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is how long requests are paused when the exchange signals throttling without a Retry-After header
+const defaultRetryAfter = 5 * time.Second
+
+// throttleMarkerPrefixBytes is how much of a response body is checked for throttle markers, exchanges put the error at the start of
+// the body so we do not need to buffer large responses (e.g. trade history) or match a marker that happens to appear in the data
+const throttleMarkerPrefixBytes = 4096
+
+// RateLimiterStats are the throttling metrics of a RateLimiter
+type RateLimiterStats struct {
+	Requests          uint64  `json:"requests"`
+	ThrottledRequests uint64  `json:"throttled_requests"` // requests that had to wait before being sent
+	ThrottledMillis   int64   `json:"throttled_millis"`   // total time spent waiting
+	RetryAfters       uint64  `json:"retry_afters"`       // number of times the exchange told us to back off
+	AvailableTokens   float64 `json:"available_tokens"`
+}
+
+// RateLimiter is a token bucket that schedules the requests made to an exchange so they stay within its rate limits.
+// Every request takes the cost weight of its endpoint from the bucket and waits for the bucket to refill when it is empty.
+type RateLimiter struct {
+	name            string
+	capacity        float64
+	refillPerSecond float64
+	endpointWeights map[string]float64 // endpoints that are not in this map have a weight of 1
+
+	mutex         *sync.Mutex
+	tokens        float64 // can be negative when requests have reserved tokens that have not refilled yet
+	lastRefill    time.Time
+	pausedUntil   time.Time
+	stats         RateLimiterStats
+	lastLatencies map[string]requestLatency // the last request to each endpoint sent through WrapClient
+
+	// these can be replaced in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// MakeRateLimiter is a factory method, the bucket starts out full
+func MakeRateLimiter(name string, capacity float64, refillPerSecond float64, endpointWeights map[string]float64) *RateLimiter {
+	if endpointWeights == nil {
+		endpointWeights = map[string]float64{}
+	}
+	return &RateLimiter{
+		name:            name,
+		capacity:        capacity,
+		refillPerSecond: refillPerSecond,
+		endpointWeights: endpointWeights,
+		mutex:           &sync.Mutex{},
+		tokens:          capacity,
+		lastRefill:      time.Now(),
+		lastLatencies:   map[string]requestLatency{},
+		now:             time.Now,
+		sleep:           time.Sleep,
+	}
+}
+
+// rateLimiters holds the RateLimiter for each exchange so the exchange instances in this process share it. This is in-memory only so
+// bots running in separate processes (e.g. separate kelp trade commands) do not coordinate and each one needs to stay under its own limit.
+var rateLimiters = map[string]*RateLimiter{}
+var rateLimitersMutex = &sync.Mutex{}
+
+// GetRateLimiter returns the RateLimiter with the given name that is shared within this process, making it with the passed in config the first time it is requested
+func GetRateLimiter(name string, capacity float64, refillPerSecond float64, endpointWeights map[string]float64) *RateLimiter {
+	rateLimitersMutex.Lock()
+	defer rateLimitersMutex.Unlock()
+
+	if r, ok := rateLimiters[name]; ok {
+		return r
+	}
+	r := MakeRateLimiter(name, capacity, refillPerSecond, endpointWeights)
+	rateLimiters[name] = r
+	return r
+}
+
+// refill should be called with the mutex held
+func (r *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(r.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	r.tokens += elapsed * r.refillPerSecond
+	if r.tokens > r.capacity {
+		r.tokens = r.capacity
+	}
+	r.lastRefill = now
+}
+
+// weight returns the cost of a request to the endpoint
+func (r *RateLimiter) weight(endpoint string) float64 {
+	if w, ok := r.endpointWeights[endpoint]; ok {
+		return w
+	}
+	return 1.0
+}
+
+// Wait blocks until a request to the endpoint can be sent without going over the rate limit
+func (r *RateLimiter) Wait(endpoint string) {
+	weight := r.weight(endpoint)
+
+	r.mutex.Lock()
+	now := r.now()
+	r.refill(now)
+	wait := time.Duration(0)
+	if r.pausedUntil.After(now) {
+		wait = r.pausedUntil.Sub(now)
+	}
+	// reserve the tokens now so concurrent requests queue up behind this one
+	r.tokens -= weight
+	if r.tokens < 0 {
+		refillWait := time.Duration(-r.tokens / r.refillPerSecond * float64(time.Second))
+		if refillWait > wait {
+			wait = refillWait
+		}
+	}
+	r.stats.Requests++
+	if wait > 0 {
+		r.stats.ThrottledRequests++
+		r.stats.ThrottledMillis += int64(wait / time.Millisecond)
+	}
+	r.mutex.Unlock()
+
+	if wait > 0 {
+		log.Printf("rate limiter '%s' is waiting %s before sending request to endpoint '%s' (weight=%.2f)\n", r.name, wait, endpoint, weight)
+		r.sleep(wait)
+	}
+}
+
+// Pause stops new requests from being sent until the duration has passed, used when the exchange tells us to back off
+func (r *RateLimiter) Pause(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	until := r.now().Add(d)
+	if until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+	r.stats.RetryAfters++
+	log.Printf("rate limiter '%s' was throttled by the exchange, pausing requests for %s\n", r.name, d)
+}
+
+// requestLatency is the network time of a request, which does not include the time the request waited for the rate limit
+type requestLatency struct {
+	sentAt  time.Time
+	latency time.Duration
+}
+
+// LastLatency returns the network time of the last request to the endpoint that was sent at or after since, without the time spent
+// waiting for the rate limit. Returns false if no such request was sent through a client from WrapClient.
+func (r *RateLimiter) LastLatency(endpoint string, since time.Time) (time.Duration, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	l, ok := r.lastLatencies[endpoint]
+	if !ok || l.sentAt.Before(since) {
+		return 0, false
+	}
+	return l.latency, true
+}
+
+// Stats returns a snapshot of the throttling metrics
+func (r *RateLimiter) Stats() RateLimiterStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.refill(r.now())
+	stats := r.stats
+	stats.AvailableTokens = r.tokens
+	return stats
+}
+
+// WrapClient returns a copy of the client that schedules every request through this RateLimiter.
+// The endpoint of a request is the last element of its URL path. Requests are never retried since they may not be safe to repeat (e.g. nonces),
+// instead throttled responses are returned to the caller and pause later requests for the duration in the Retry-After header.
+// Responses with a 429 or 418 status code are throttled, as are responses whose body starts with any of the throttleMarkers within
+// the first throttleMarkerPrefixBytes.
+func (r *RateLimiter) WrapClient(client *http.Client, throttleMarkers ...string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &rateLimitedTransport{
+		limiter:         r,
+		base:            base,
+		throttleMarkers: throttleMarkers,
+	}
+	return &wrapped
+}
+
+// rateLimitedTransport is the http.RoundTripper used by RateLimiter.WrapClient
+type rateLimitedTransport struct {
+	limiter         *RateLimiter
+	base            http.RoundTripper
+	throttleMarkers []string
+}
+
+// RoundTrip impl
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := path.Base(req.URL.Path)
+	t.limiter.Wait(endpoint)
+
+	sentAt := time.Now()
+	resp, e := t.base.RoundTrip(req)
+	if e != nil {
+		return nil, e
+	}
+	latency := time.Since(sentAt)
+	t.limiter.mutex.Lock()
+	t.limiter.lastLatencies[endpoint] = requestLatency{sentAt: sentAt, latency: latency}
+	t.limiter.mutex.Unlock()
+
+	throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot
+	if !throttled && len(t.throttleMarkers) > 0 {
+		// kraken responds with a 200 status code when throttling so we cannot only check error responses, instead only the start of the body is checked
+		prefix, e := ioutil.ReadAll(io.LimitReader(resp.Body, throttleMarkerPrefixBytes))
+		if e != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("could not read http response to check for throttling: %s", e)
+		}
+		// put the prefix back in front of the rest of the body so the caller can read all of it
+		resp.Body = &prefixedReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body),
+			Closer: resp.Body,
+		}
+
+		prefixString := string(prefix)
+		for _, marker := range t.throttleMarkers {
+			if strings.Contains(prefixString, marker) {
+				throttled = true
+				break
+			}
+		}
+	}
+
+	if throttled {
+		t.limiter.Pause(parseRetryAfter(resp.Header.Get("Retry-After"), t.limiter.now()))
+	}
+	return resp, nil
+}
+
+// prefixedReadCloser reads a body whose start was already read, closing the original body
+type prefixedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// parseRetryAfter parses the Retry-After header which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, e := strconv.Atoi(value); e == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, e := http.ParseTime(value); e == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
+
+// RateLimiterMetrics serves the stats of all the shared rate limiters as JSON, for the monitoring server
+type RateLimiterMetrics struct{}
+
+var _ json.Marshaler = RateLimiterMetrics{}
+
+// MarshalJSON impl
+func (m RateLimiterMetrics) MarshalJSON() ([]byte, error) {
+	rateLimitersMutex.Lock()
+	limiters := map[string]*RateLimiter{}
+	for name, r := range rateLimiters {
+		limiters[name] = r
+	}
+	rateLimitersMutex.Unlock()
+
+	stats := map[string]RateLimiterStats{}
+	for name, r := range limiters {
+		stats[name] = r.Stats()
+	}
+	return json.Marshal(stats)
+}
@@ -0,0 +1,135 @@
+package networking
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestRateLimiter uses a fake clock that only moves forward when the rate limiter sleeps
+func makeTestRateLimiter(capacity float64, refillPerSecond float64, endpointWeights map[string]float64) (*RateLimiter, *[]time.Duration) {
+	r := MakeRateLimiter("test", capacity, refillPerSecond, endpointWeights)
+	now := time.Unix(1600000000, 0)
+	sleeps := []time.Duration{}
+	r.lastRefill = now
+	r.now = func() time.Time { return now }
+	r.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return r, &sleeps
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	r, sleeps := makeTestRateLimiter(2, 0.5, map[string]float64{"heavy": 2, "free": 0})
+
+	// the bucket starts out full
+	r.Wait("light")
+	r.Wait("free")
+	r.Wait("light")
+	assert.Equal(t, []time.Duration{}, *sleeps)
+
+	// the bucket is empty so a request of weight 2 needs to wait 4 seconds at 0.5 tokens per second
+	r.Wait("heavy")
+	assert.Equal(t, []time.Duration{4 * time.Second}, *sleeps)
+
+	stats := r.Stats()
+	assert.Equal(t, uint64(4), stats.Requests)
+	assert.Equal(t, uint64(1), stats.ThrottledRequests)
+	assert.Equal(t, int64(4000), stats.ThrottledMillis)
+	assert.Equal(t, 0.0, stats.AvailableTokens)
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	r, sleeps := makeTestRateLimiter(10, 1, nil)
+
+	r.Pause(3 * time.Second)
+	// a shorter pause does not cut the longer one short
+	r.Pause(1 * time.Second)
+	r.Wait("light")
+	r.Wait("light")
+	assert.Equal(t, []time.Duration{3 * time.Second}, *sleeps)
+	assert.Equal(t, uint64(2), r.Stats().RetryAfters)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"7", 7 * time.Second},
+		{"0", 0},
+		{"Wed, 01 Jan 2020 00:00:30 GMT", 30 * time.Second},
+		{"Tue, 31 Dec 2019 23:59:00 GMT", 0},
+		{"soon", defaultRetryAfter},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.value, func(t *testing.T) {
+			assert.Equal(t, k.want, parseRetryAfter(k.value, now))
+		})
+	}
+}
+
+func TestRateLimiterWrapClient(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		retryAfter    string
+		body          string
+		wantPause     bool
+		wantPauseTime time.Duration
+	}{
+		{"ok", http.StatusOK, "", `{"result":{}}`, false, 0},
+		{"too many requests", http.StatusTooManyRequests, "2", `{}`, true, 2 * time.Second},
+		{"marker in body", http.StatusOK, "", `{"error":["EAPI:Rate limit exceeded"]}`, true, defaultRetryAfter},
+		// only the start of the body is checked so a marker that happens to be in a large response's data is ignored
+		{"marker after prefix", http.StatusOK, "", `{"result":"` + strings.Repeat("x", throttleMarkerPrefixBytes) + `Rate limit exceeded"}`, false, 0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if k.retryAfter != "" {
+					w.Header().Set("Retry-After", k.retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(k.status)
+				w.Write([]byte(k.body))
+			}))
+			defer server.Close()
+
+			r, sleeps := makeTestRateLimiter(10, 1, nil)
+			client := r.WrapClient(server.Client(), "Rate limit exceeded")
+
+			var output interface{}
+			start := time.Now()
+			_, ok := r.LastLatency("Depth", start)
+			assert.False(t, ok)
+			// the body can still be read by the caller after it was checked for the marker
+			e := JSONRequest(client, "GET", server.URL+"/0/public/Depth", "", map[string]string{}, &output, "")
+			if k.status == http.StatusOK {
+				assert.NoError(t, e)
+			}
+			latency, ok := r.LastLatency("Depth", start)
+			assert.True(t, ok)
+			assert.True(t, latency <= time.Since(start))
+			_, ok = r.LastLatency("Depth", time.Now().Add(time.Second))
+			assert.False(t, ok)
+			assert.Equal(t, k.wantPause, r.Stats().RetryAfters == 1)
+
+			r.Wait("next")
+			if k.wantPause {
+				assert.Equal(t, []time.Duration{k.wantPauseTime}, *sleeps)
+			} else {
+				assert.Equal(t, []time.Duration{}, *sleeps)
+			}
+		})
+	}
+}
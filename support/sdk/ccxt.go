@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 
@@ -34,6 +35,7 @@ func GetBaseURL() string {
 // Ccxt Rest SDK (https://github.com/franz-see/ccxt-rest, https://github.com/ccxt/ccxt/)
 type Ccxt struct {
 	httpClient   *http.Client
+	rateLimiter  *networking.RateLimiter
 	exchangeName string
 	instanceName string
 	markets      map[string]CcxtMarket
@@ -65,6 +67,22 @@ type CcxtMarket struct {
 
 const pathExchanges = "/exchanges"
 
+// ccxtRateLimitBurst is the number of requests that can be sent at once before the exchange's rateLimit spacing applies
+const ccxtRateLimitBurst = 5.0
+
+// ccxtDefaultRateLimitMillis is used when the exchange does not report a rateLimit
+const ccxtDefaultRateLimitMillis = 1000.0
+
+// ccxtThrottleMarkers are the names of the errors that ccxt raises when the exchange rate limits us
+var ccxtThrottleMarkers = []string{"RateLimitExceeded", "DDoSProtection"}
+
+// ccxtEndpointWeights are the costs of the ccxt-rest calls that are more expensive than a single request on most exchanges
+var ccxtEndpointWeights = map[string]float64{
+	"fetchMyTrades":     2,
+	"fetchOpenOrders":   2,
+	"fetchClosedOrders": 2,
+}
+
 // MakeInitializedCcxtExchange constructs an instance of Ccxt that is bound to a specific exchange instance on the CCXT REST server
 func MakeInitializedCcxtExchange(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) (*Ccxt, error) {
 	if strings.HasSuffix(ccxtBaseURL, "/") {
@@ -148,6 +166,12 @@ func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam,
 		log.Printf("instance '%s' for exchange '%s' already exists\n", c.instanceName, c.exchangeName)
 	}
 
+	// schedule all requests to the exchange through a rate limiter that is shared with other instances of the same exchange in this process
+	e = c.initRateLimiter()
+	if e != nil {
+		return fmt.Errorf("error initializing rate limiter for exchange '%s': %s", c.exchangeName, e)
+	}
+
 	// load markets to populate fields related to markets
 	var marketsResponse interface{}
 	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/loadMarkets"
@@ -177,6 +201,36 @@ func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam,
 	return nil
 }
 
+// initRateLimiter uses the exchange's rateLimit (minimum millis between requests) reported by ccxt to configure the rate limiter
+func (c *Ccxt) initRateLimiter() error {
+	var details struct {
+		RateLimit float64 `json:"rateLimit"`
+	}
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+	e := networking.JSONRequest(c.httpClient, "GET", url, "", map[string]string{}, &details, "error")
+	if e != nil {
+		return fmt.Errorf("error fetching details of exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+
+	rateLimitMillis := details.RateLimit
+	if rateLimitMillis <= 0 {
+		rateLimitMillis = ccxtDefaultRateLimitMillis
+	}
+	rateLimiter := networking.GetRateLimiter("ccxt-"+c.exchangeName, ccxtRateLimitBurst, 1000.0/rateLimitMillis, ccxtEndpointWeights)
+	c.httpClient = rateLimiter.WrapClient(c.httpClient, ccxtThrottleMarkers...)
+	c.rateLimiter = rateLimiter
+	log.Printf("rate limiting requests to exchange '%s' to one every %.0f millis with a burst of %.0f\n", c.exchangeName, rateLimitMillis, ccxtRateLimitBurst)
+	return nil
+}
+
+// FetchOrderBookLatency returns the network time of a FetchOrderBook request sent at or after since, without the time spent waiting for the rate limit
+func (c *Ccxt) FetchOrderBookLatency(since time.Time) (time.Duration, bool) {
+	if c.rateLimiter == nil {
+		return 0, false
+	}
+	return c.rateLimiter.LastLatency("fetchOrderBook", since)
+}
+
 // makeInstanceName takes all those inputs that create a distinctly initialized instance
 func makeInstanceName(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) (string, error) {
 	keyHash := ""
@@ -509,7 +563,9 @@ type CcxtOpenOrder struct {
 	Status    string
 	Symbol    string
 	Type      string
+	StopPrice float64 // only set for stop orders on exchanges where ccxt parses the stop price
 	Timestamp int64
+	Info      map[string]interface{} // the order as returned by the exchange
 }
 
 // FetchOpenOrders calls the /fetchOpenOrders endpoint on CCXT
@@ -562,21 +618,68 @@ func (c *Ccxt) FetchOpenOrders(tradingPairs []string) (map[string][]CcxtOpenOrde
 	return result, nil
 }
 
+// FetchClosedOrders calls the /fetchClosedOrders endpoint on CCXT for the latest closed orders (filled or cancelled) of the trading pair
+func (c *Ccxt) FetchClosedOrders(tradingPair string, limit int) ([]CcxtOpenOrder, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data, the since param is null so the exchange returns its latest closed orders
+	data, e := json.Marshal(&[]interface{}{tradingPair, nil, limit})
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (tradingPair=%s, limit=%d) as an array for exchange '%s': %s", tradingPair, limit, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchClosedOrders"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching closed orders for trading pair '%s': %s", tradingPair, e)
+	}
+
+	outputList, ok := output.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the result to a []interface{}, type = %s", reflect.TypeOf(output))
+	}
+	closedOrders := []CcxtOpenOrder{}
+	for _, elem := range outputList {
+		elemMap, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("could not convert the element in the result to a map[string]interface{}, type = %s", reflect.TypeOf(elem))
+		}
+
+		var closedOrder CcxtOpenOrder
+		e = mapstructure.Decode(elemMap, &closedOrder)
+		if e != nil {
+			return nil, fmt.Errorf("could not decode closed order element (%v): %s", elemMap, e)
+		}
+		closedOrders = append(closedOrders, closedOrder)
+	}
+	return closedOrders, nil
+}
+
 // CreateLimitOrder calls the /createOrder endpoint on CCXT with a limit price and the order type set to "limit"
 func (c *Ccxt) CreateLimitOrder(tradingPair string, side string, amount float64, price float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
-	orderType := "limit"
+	return c.CreateOrder(tradingPair, "limit", side, amount, &price, maybeExchangeSpecificParams)
+}
+
+// CreateOrder calls the /createOrder endpoint on CCXT with the orderType (e.g. "market" or "limit"), maybePrice should be nil for market orders
+func (c *Ccxt) CreateOrder(tradingPair string, orderType string, side string, amount float64, maybePrice *float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
 		return nil, fmt.Errorf("symbol does not exist: %s", e)
 	}
 
 	// marshal input data
+	// the price is marshaled as null when it is nil, which is how ccxt expects market orders
 	inputData := []interface{}{
 		tradingPair,
 		orderType,
 		side,
 		amount,
-		price,
+		maybePrice,
 	}
 	if maybeExchangeSpecificParams != nil {
 		inputData = append(inputData, maybeExchangeSpecificParams)
@@ -646,3 +749,157 @@ func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder,
 
 	return &openOrder, nil
 }
+
+// EditLimitOrder calls the /editOrder endpoint on CCXT to change the price and amount of an open limit order
+func (c *Ccxt) EditLimitOrder(orderID string, tradingPair string, side string, amount float64, price float64, maybeExchangeSpecificParams interface{}) (*CcxtOpenOrder, error) {
+	orderType := "limit"
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data
+	inputData := []interface{}{
+		orderID,
+		tradingPair,
+		orderType,
+		side,
+		amount,
+		price,
+	}
+	if maybeExchangeSpecificParams != nil {
+		inputData = append(inputData, maybeExchangeSpecificParams)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/editOrder"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error editing order: %s", e)
+	}
+
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the output to a map[string]interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	var openOrder CcxtOpenOrder
+	e = mapstructure.Decode(outputMap, &openOrder)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode outputMap to openOrder (%v): %s", outputMap, e)
+	}
+
+	return &openOrder, nil
+}
+
+// CcxtLimit represents a min/max limit, where a nil value means there is no limit
+type CcxtLimit struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// CcxtCurrency represents the result of a FetchCurrencies call for a single currency
+type CcxtCurrency struct {
+	// only contains currently needed data
+	ID     string   `json:"id"`
+	Code   string   `json:"code"`
+	Active *bool    `json:"active"`
+	Fee    *float64 `json:"fee"` // withdrawal fee, nil if unknown
+	Limits struct {
+		Withdraw CcxtLimit `json:"withdraw"`
+		Deposit  CcxtLimit `json:"deposit"`
+	} `json:"limits"`
+}
+
+// FetchCurrencies calls the /fetchCurrencies endpoint on CCXT, which contains the withdrawal fees and the deposit and withdrawal limits
+func (c *Ccxt) FetchCurrencies() (map[string]CcxtCurrency, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchCurrencies"
+	output := map[string]CcxtCurrency{}
+	e := networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, "", c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching currencies: %s", e)
+	}
+	return output, nil
+}
+
+// FetchCurrency fetches the currency info for a single currency code, returns an error if the exchange does not list the currency
+func (c *Ccxt) FetchCurrency(code string) (*CcxtCurrency, error) {
+	currencies, e := c.FetchCurrencies()
+	if e != nil {
+		return nil, e
+	}
+
+	currency, ok := currencies[code]
+	if !ok {
+		return nil, fmt.Errorf("currency '%s' is not listed by exchange '%s'", code, c.exchangeName)
+	}
+	return &currency, nil
+}
+
+// CcxtDepositAddress represents the result of a FetchDepositAddress call
+type CcxtDepositAddress struct {
+	Currency string `json:"currency"`
+	Address  string `json:"address"`
+	Tag      string `json:"tag"` // memo or tag that needs to be attached to the deposit, empty if not needed
+}
+
+// FetchDepositAddress calls the /fetchDepositAddress endpoint on CCXT
+func (c *Ccxt) FetchDepositAddress(code string) (*CcxtDepositAddress, error) {
+	// marshal input data
+	inputData := []interface{}{code}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchDepositAddress"
+	var output CcxtDepositAddress
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching deposit address for currency '%s': %s", code, e)
+	}
+
+	if output.Address == "" {
+		return nil, fmt.Errorf("result from call to fetchDepositAddress for currency '%s' did not contain an address", code)
+	}
+	return &output, nil
+}
+
+// CcxtWithdrawal represents the result of a Withdraw call
+type CcxtWithdrawal struct {
+	ID string `json:"id"`
+}
+
+// Withdraw calls the /withdraw endpoint on CCXT, tag can be empty if the address does not need a memo or tag
+func (c *Ccxt) Withdraw(code string, amount float64, address string, tag string) (*CcxtWithdrawal, error) {
+	// marshal input data
+	inputData := []interface{}{
+		code,
+		amount,
+		address,
+	}
+	if tag != "" {
+		inputData = append(inputData, tag)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/withdraw"
+	var output CcxtWithdrawal
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error withdrawing currency '%s': %s", code, e)
+	}
+
+	if output.ID == "" {
+		return nil, fmt.Errorf("result from call to withdraw for currency '%s' did not contain an id", code)
+	}
+	return &output, nil
+}
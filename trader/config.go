@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/plugins"
 	"github.com/stellar/kelp/support/postgresdb"
 	"github.com/stellar/kelp/support/toml"
 	"github.com/stellar/kelp/support/utils"
@@ -50,9 +51,11 @@ type BotConfig struct {
 	MinCentralizedBaseVolumeDeprecated *float64                 `valid:"-" toml:"MIN_CENTRALIZED_BASE_VOLUME" deprecated:"true" json:"min_centralized_base_volume"`
 	CentralizedMinBaseVolumeOverride   *float64                 `valid:"-" toml:"CENTRALIZED_MIN_BASE_VOLUME_OVERRIDE" json:"centralized_min_base_volume_override"`
 	CentralizedMinQuoteVolumeOverride  *float64                 `valid:"-" toml:"CENTRALIZED_MIN_QUOTE_VOLUME_OVERRIDE" json:"centralized_min_quote_volume_override"`
+	CentralizedOfferIDsFile            string                   `valid:"-" toml:"CENTRALIZED_OFFER_IDS_FILE" json:"centralized_offer_ids_file"`
 	PostgresDbConfig                   *postgresdb.Config       `valid:"-" toml:"POSTGRES_DB" json:"postgres_db"`
 	DbOverrideAccountID                string                   `valid:"-" toml:"DB_OVERRIDE__ACCOUNT_ID" json:"db_override__account_id"`
 	Filters                            []string                 `valid:"-" toml:"FILTERS" json:"filters"`
+	FilterConfigs                      []plugins.FilterConfig   `valid:"-" toml:"FILTER" json:"filter"`
 	AlertType                          string                   `valid:"-" toml:"ALERT_TYPE" json:"alert_type"`
 	AlertAPIKey                        string                   `valid:"-" toml:"ALERT_API_KEY" json:"alert_api_key"`
 	MonitoringPort                     uint16                   `valid:"-" toml:"MONITORING_PORT" json:"monitoring_port"`
@@ -211,9 +214,32 @@ func (b *BotConfig) Init() error {
 	}
 
 	b.sourceAccount, e = utils.ParseSecret(b.SourceSecretSeed)
+	if e != nil {
+		return e
+	}
+
+	_, e = b.FilterStrings()
 	return e
 }
 
+// FilterStrings returns all the enabled filters in the string form, starting with the FILTERS list followed by the [[FILTER]] tables
+func (b *BotConfig) FilterStrings() ([]string, error) {
+	filterStrings := append([]string{}, b.Filters...)
+	for i, fc := range b.FilterConfigs {
+		// validate disabled filters too so they can be enabled later without surprises
+		filterString, e := fc.FilterString()
+		if e != nil {
+			return nil, fmt.Errorf("invalid [[FILTER]] table at index %d (TYPE=%s): %s", i, fc.Type, e)
+		}
+
+		if !fc.IsEnabled() {
+			continue
+		}
+		filterStrings = append(filterStrings, filterString)
+	}
+	return filterStrings, nil
+}
+
 // SleepMode defines when the bot sleeps, before (begin) or after (end) of update cycle
 type SleepMode string
 
@@ -0,0 +1,69 @@
+package trader
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/plugins"
+)
+
+func TestBotConfigFilterStrings(t *testing.T) {
+	minPrice := 0.04
+	maxPrice := 1.00
+	enabled := true
+	disabled := false
+
+	testCases := []struct {
+		filters       []string
+		filterConfigs []plugins.FilterConfig
+		wantFilters   []string
+		wantErr       bool
+	}{
+		{
+			filters:       []string{"price/max/1.00"},
+			filterConfigs: nil,
+			wantFilters:   []string{"price/max/1.00"},
+		}, {
+			filters: []string{"price/max/1.00"},
+			filterConfigs: []plugins.FilterConfig{
+				{Type: "price", MinPrice: &minPrice},
+			},
+			wantFilters: []string{"price/max/1.00", "price/min/0.04"},
+		}, {
+			filters: nil,
+			filterConfigs: []plugins.FilterConfig{
+				{Type: "price", Enabled: &enabled, MinPrice: &minPrice},
+				{Type: "price", Enabled: &disabled, MaxPrice: &maxPrice},
+			},
+			wantFilters: []string{"price/min/0.04"},
+		}, {
+			// disabled filters are still validated
+			filters: nil,
+			filterConfigs: []plugins.FilterConfig{
+				{Type: "price", Enabled: &disabled},
+			},
+			wantErr: true,
+		},
+	}
+
+	for i, k := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			botConfig := &BotConfig{
+				Filters:       k.filters,
+				FilterConfigs: k.filterConfigs,
+			}
+
+			actual, e := botConfig.FilterStrings()
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantFilters, actual)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -42,6 +43,7 @@ type Trader struct {
 	deleteCyclesThreshold          int64
 	submitMode                     api.SubmitMode
 	submitFilters                  []plugins.SubmitFilter
+	filterExplainer                *plugins.FilterExplainer // can be nil
 	threadTracker                  *multithreading.ThreadTracker
 	fixedIterations                *uint64
 	dataKey                        *model.BotKey
@@ -59,6 +61,10 @@ type Trader struct {
 	trustAssetB    float64
 	buyingAOffers  []hProtocol.Offer // quoted A/B
 	sellingAOffers []hProtocol.Offer // quoted B/A
+
+	// set when the filters are reloaded, applied at the start of the next update cycle
+	submitFiltersMutex sync.Mutex
+	nextSubmitFilters  []plugins.SubmitFilter
 }
 
 // MakeTrader is the factory method for the Trader struct
@@ -81,6 +87,7 @@ func MakeTrader(
 	deleteCyclesThreshold int64,
 	submitMode api.SubmitMode,
 	submitFilters []plugins.SubmitFilter,
+	filterExplainer *plugins.FilterExplainer,
 	threadTracker *multithreading.ThreadTracker,
 	fixedIterations *uint64,
 	dataKey *model.BotKey,
@@ -107,6 +114,7 @@ func MakeTrader(
 		deleteCyclesThreshold:          deleteCyclesThreshold,
 		submitMode:                     submitMode,
 		submitFilters:                  submitFilters,
+		filterExplainer:                filterExplainer,
 		threadTracker:                  threadTracker,
 		fixedIterations:                fixedIterations,
 		dataKey:                        dataKey,
@@ -118,6 +126,52 @@ func MakeTrader(
 	}
 }
 
+// SetSubmitFilters replaces the chain of submit filters, the new chain is used starting from the next update cycle
+func (t *Trader) SetSubmitFilters(submitFilters []plugins.SubmitFilter) {
+	t.submitFiltersMutex.Lock()
+	defer t.submitFiltersMutex.Unlock()
+
+	t.nextSubmitFilters = submitFilters
+}
+
+// loadNextSubmitFilters switches to the chain of submit filters set by SetSubmitFilters, if any
+func (t *Trader) loadNextSubmitFilters() {
+	t.submitFiltersMutex.Lock()
+	defer t.submitFiltersMutex.Unlock()
+
+	if t.nextSubmitFilters == nil {
+		return
+	}
+	log.Printf("switching to the reloaded chain of %d submit filters (previously %d submit filters)\n", len(t.nextSubmitFilters), len(t.submitFilters))
+	t.submitFilters = t.nextSubmitFilters
+	t.nextSubmitFilters = nil
+}
+
+// applySubmitFilters runs the ops through the chain of submit filters, switching to the chain set by SetSubmitFilters first
+func (t *Trader) applySubmitFilters(ops []txnbuild.Operation) ([]txnbuild.Operation, error) {
+	t.loadNextSubmitFilters()
+	if t.filterExplainer != nil {
+		t.filterExplainer.StartCycle(time.Now(), len(ops))
+	}
+
+	var e error
+	for i, filter := range t.submitFilters {
+		ops, e = filter.Apply(ops, t.sellingAOffers, t.buyingAOffers)
+		if e != nil {
+			e = fmt.Errorf("error in filter index %d: %s", i, e)
+			if t.filterExplainer != nil {
+				t.filterExplainer.EndCycle(0, e)
+			}
+			return nil, e
+		}
+	}
+
+	if t.filterExplainer != nil {
+		t.filterExplainer.EndCycle(len(ops), nil)
+	}
+	return ops, nil
+}
+
 // Start starts the bot with the injected strategy
 func (t *Trader) Start() {
 	log.Println("----------------------------------------------------------------------------------------------------")
@@ -510,19 +564,16 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		}
 	}
 
-	ops := api.ConvertMSO2Ops(msos)
-	for i, filter := range t.submitFilters {
-		ops, e = filter.Apply(ops, t.sellingAOffers, t.buyingAOffers)
-		if e != nil {
-			log.Printf("error in filter index %d: %s\n", i, e)
-			t.deleteAllOffers(false)
-			return plugins.UpdateLoopResult{
-				Success:            false,
-				NumPruneOps:        numPruneOps,
-				NumUpdateOpsDelete: numUpdateOpsDelete,
-				NumUpdateOpsUpdate: numUpdateOpsUpdate,
-				NumUpdateOpsCreate: numUpdateOpsCreate,
-			}
+	ops, e := t.applySubmitFilters(api.ConvertMSO2Ops(msos))
+	if e != nil {
+		log.Println(e)
+		t.deleteAllOffers(false)
+		return plugins.UpdateLoopResult{
+			Success:            false,
+			NumPruneOps:        numPruneOps,
+			NumUpdateOpsDelete: numUpdateOpsDelete,
+			NumUpdateOpsUpdate: numUpdateOpsUpdate,
+			NumUpdateOpsCreate: numUpdateOpsCreate,
 		}
 	}
 
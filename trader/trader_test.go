@@ -11,6 +11,7 @@ import (
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
 )
 
 func TestIsStateSynchronized(t *testing.T) {
@@ -346,3 +347,80 @@ func createTestMSO(msoType string) *txnbuild.ManageSellOffer {
 	}
 	return &mso
 }
+
+// recordingSubmitFilter records its name every time it is applied and drops all ops when dropOps is set
+type recordingSubmitFilter struct {
+	name    string
+	dropOps bool
+	err     error
+	applied *[]string
+}
+
+func (f *recordingSubmitFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	*f.applied = append(*f.applied, f.name)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.dropOps {
+		return []txnbuild.Operation{}, nil
+	}
+	return ops, nil
+}
+
+func TestApplySubmitFiltersReloadedBetweenCycles(t *testing.T) {
+	applied := []string{}
+	first := &recordingSubmitFilter{name: "first", applied: &applied}
+	second := &recordingSubmitFilter{name: "second", applied: &applied}
+	dropAll := &recordingSubmitFilter{name: "dropAll", dropOps: true, applied: &applied}
+	failing := &recordingSubmitFilter{name: "failing", err: fmt.Errorf("failed"), applied: &applied}
+	ops := []txnbuild.Operation{createTestMSO("create"), createTestMSO("update")}
+
+	bot := &Trader{submitFilters: []plugins.SubmitFilter{first}}
+
+	// the initial chain is used until a new chain is set
+	gotOps, e := bot.applySubmitFilters(ops)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2, len(gotOps))
+	assert.Equal(t, []string{"first"}, applied)
+
+	// setting a new chain does not affect the current cycle, only the next update cycle
+	bot.SetSubmitFilters([]plugins.SubmitFilter{second, dropAll})
+	assert.Equal(t, []plugins.SubmitFilter{first}, bot.submitFilters)
+
+	applied = applied[:0]
+	gotOps, e = bot.applySubmitFilters(ops)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(gotOps))
+	assert.Equal(t, []string{"second", "dropAll"}, applied)
+
+	// the reloaded chain stays in place for the following cycles
+	applied = applied[:0]
+	_, e = bot.applySubmitFilters(ops)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{"second", "dropAll"}, applied)
+
+	// a reloaded chain that fails returns an error that names the index of the failing filter
+	bot.SetSubmitFilters([]plugins.SubmitFilter{first, failing, second})
+	applied = applied[:0]
+	_, e = bot.applySubmitFilters(ops)
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "error in filter index 1")
+	}
+	assert.Equal(t, []string{"first", "failing"}, applied)
+
+	// an empty chain removes all the filters
+	bot.SetSubmitFilters([]plugins.SubmitFilter{})
+	applied = applied[:0]
+	gotOps, e = bot.applySubmitFilters(ops)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2, len(gotOps))
+	assert.Equal(t, []string{}, applied)
+}